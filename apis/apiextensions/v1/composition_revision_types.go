@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+)
+
+// CompositionRevision label keys.
+const (
+	// LabelCompositionName is the name of the Composition a
+	// CompositionRevision was derived from. It is omitted when the name of
+	// the Composition is longer than a label value may be.
+	LabelCompositionName = "crossplane.io/composition-name"
+
+	// LabelCompositionSpecHash is a hash of the Composition spec a
+	// CompositionRevision was derived from.
+	LabelCompositionSpecHash = "crossplane.io/composition-spec-hash"
+)
+
+// An UpdatePolicy determines how a composite resource should be updated when
+// the Composition it uses is updated.
+type UpdatePolicy string
+
+// Update policies.
+const (
+	// UpdateAutomatic means the composite resource will use the latest
+	// revision of its Composition as soon as it is created.
+	UpdateAutomatic UpdatePolicy = "Automatic"
+
+	// UpdateManual means the composite resource will continue to use the
+	// revision it references until it is manually updated to reference
+	// another.
+	UpdateManual UpdatePolicy = "Manual"
+)
+
+// CompositionRevisionSpec specifies the desired state of the composition
+// revision. It is an immutable snapshot of the spec of the Composition the
+// revision was derived from.
+type CompositionRevisionSpec struct {
+	CompositionSpec `json:",inline"`
+
+	// Revision number. Newer revisions have larger numbers.
+	// +immutable
+	Revision int64 `json:"revision"`
+}
+
+// CompositionRevisionStatus shows the observed state of the composition
+// revision.
+type CompositionRevisionStatus struct {
+	xpv1.ConditionedStatus `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +genclient
+// +genclient:nonNamespaced
+
+// A CompositionRevision represents a revision in time of a Composition.
+// Revisions are created by Crossplane; they should be treated as immutable.
+// +kubebuilder:printcolumn:name="REVISION",type="string",JSONPath=".spec.revision"
+// +kubebuilder:printcolumn:name="XR-KIND",type="string",JSONPath=".spec.compositeTypeRef.kind"
+// +kubebuilder:printcolumn:name="XR-APIVERSION",type="string",JSONPath=".spec.compositeTypeRef.apiVersion"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=crossplane
+type CompositionRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CompositionRevisionSpec   `json:"spec,omitempty"`
+	Status CompositionRevisionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CompositionRevisionList contains a list of CompositionRevisions.
+type CompositionRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CompositionRevision `json:"items"`
+}
@@ -17,40 +17,79 @@ limitations under the License.
 package v1
 
 import (
+	"crypto/sha1" // nolint:gosec // Used for hashing, not security.
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 )
 
 const (
-	errMathNoMultiplier         = "no input is given"
+	errMathNoOperation          = "no math operation is given"
+	errMathTooManyOperations    = "only one math operation may be given"
 	errMathInputNonNumber       = "input is required to be a number for math transformer"
+	errMathDivideByZero         = "cannot divide by zero"
+	errMathClampNoBounds        = "clamp requires a min or max"
+	errMathClampBounds          = "clamp min must not be greater than max"
+	errFmtMathInvalidOperand    = "%q is not a valid math operand"
+	errFmtMathResultType        = "math result type %s is not supported"
 	errPatchSetType             = "a patch in a PatchSet cannot be of type PatchSet"
 	errCombineRequiresVariables = "combine patch types require at least one variable"
 
-	errFmtRequiredField                = "%s is required by type %s"
-	errFmtUndefinedPatchSet            = "cannot find PatchSet by name %s"
-	errFmtInvalidPatchType             = "patch type %s is unsupported"
-	errFmtConvertInputTypeNotSupported = "input type %s is not supported"
-	errFmtConversionPairNotSupported   = "conversion from %s to %s is not supported"
-	errFmtTransformAtIndex             = "transform at index %d returned error"
-	errFmtTypeNotSupported             = "transform type %s is not supported"
-	errFmtTransformConfigMissing       = "given transform type %s requires configuration"
-	errFmtTransformTypeFailed          = "%s transform could not resolve"
-	errFmtMapTypeNotSupported          = "type %s is not supported for map transform"
-	errFmtMapNotFound                  = "key %s is not found in map"
-	errFmtCombineStrategyNotSupported  = "combine strategy %s is not supported"
-	errFmtCombineConfigMissing         = "given combine strategy %s requires configuration"
-	errFmtCombineStrategyFailed        = "%s strategy could not combine"
+	errStringInputNonString = "input is required to be a string for this string transform"
+	errStringInputNonArray  = "input is required to be an array for a Join string transform"
+	errStringRegexpCompile  = "cannot compile regexp"
+	errStringDecodeBase64   = "cannot decode base64 string"
+	errMapFallbackConflict  = "a fallback value cannot be specified when falling back to the input"
+	errMapUnmarshalValue    = "cannot unmarshal map value"
+
+	errFmtRequiredField                   = "%s is required by type %s"
+	errFmtMergeOptionsNotSupported        = "merge options are not supported by type %s"
+	errFmtUndefinedPatchSet               = "cannot find PatchSet by name %s"
+	errFmtInvalidPatchType                = "patch type %s is unsupported"
+	errFmtConvertInputTypeNotSupported    = "input type %s is not supported"
+	errFmtConversionPairNotSupported      = "conversion from %s to %s is not supported"
+	errFmtTransformAtIndex                = "transform at index %d returned error"
+	errFmtTypeNotSupported                = "transform type %s is not supported"
+	errFmtTransformConfigMissing          = "given transform type %s requires configuration"
+	errFmtTransformTypeFailed             = "%s transform could not resolve"
+	errFmtTransformTypeInvalid            = "%s transform is invalid"
+	errFmtStringTransformTypeNotSupported = "string transform type %s is not supported"
+	errFmtStringTransformConfigMissing    = "string transform type %s requires configuration"
+	errFmtStringConversionNotSupported    = "string conversion %s is not supported"
+	errFmtStringHashNotSupported          = "hash algorithm %s is not supported"
+	errFmtStringHashLength                = "hash length %d must be at least 1"
+	errFmtStringRegexpNoMatch             = "regexp %q had no matches"
+	errFmtStringRegexpNoGroup             = "regexp %q has no group %d"
+	errFmtMapTypeNotSupported             = "type %s is not supported for map transform"
+	errFmtMapFallbackToNotSupported       = "fallback to %s is not supported"
+	errFmtMapFallbackType                 = "a map fallback cannot be specified for a %s transform"
+	errFmtMapNotFound                     = "key %s is not found in map"
+	errFmtCombineStrategyNotSupported     = "combine strategy %s is not supported"
+	errFmtCombineConfigMissing            = "given combine strategy %s requires configuration"
+	errFmtCombineStrategyFailed           = "%s strategy could not combine"
+	errFmtCombineVariableName             = "variable at index %d must specify a name"
+	errFmtCombineVariableDuplicate        = "variable name %s is not unique"
+	errFmtCombineVariableNonNumber        = "variable at index %d is not a number"
+	errFmtCombineVariableFieldPath        = "variable at index %d must specify a fromFieldPath"
 )
 
 // CompositionSpec specifies the desired state of the definition.
@@ -66,9 +105,33 @@ type CompositionSpec struct {
 	// +optional
 	PatchSets []PatchSet `json:"patchSets,omitempty"`
 
+	// Mode determines how this Composition composes resources. Resources mode
+	// Compositions render a composed resource from each of their resource
+	// templates. GoTemplate mode Compositions render composed resources using
+	// a Go template.
+	// +optional
+	// +kubebuilder:validation:Enum=Resources;GoTemplate
+	// +kubebuilder:default=Resources
+	Mode *CompositionMode `json:"mode,omitempty"`
+
 	// Resources is the list of resource templates that will be used when a
-	// composite resource referring to this composition is created.
-	Resources []ComposedTemplate `json:"resources"`
+	// composite resource referring to this composition is created. Resources
+	// must not be specified by GoTemplate mode Compositions.
+	// +optional
+	Resources []ComposedTemplate `json:"resources,omitempty"`
+
+	// GoTemplate renders the composed resources of a GoTemplate mode
+	// Composition.
+	// +optional
+	GoTemplate *GoTemplate `json:"goTemplate,omitempty"`
+
+	// Functions is an optional pipeline of composition functions. Functions
+	// are run in order after the resource templates have been rendered. Each
+	// function is supplied the observed and desired state of the composite
+	// resource and its composed resources, and returns an updated desired
+	// state. All resource templates must be named in order to use functions.
+	// +optional
+	Functions []Function `json:"functions,omitempty"`
 
 	// WriteConnectionSecretsToNamespace specifies the namespace in which the
 	// connection secrets of composite resource dynamically provisioned using
@@ -111,6 +174,100 @@ func (cs *CompositionSpec) InlinePatchSets() error {
 	return nil
 }
 
+// A CompositionMode determines how a Composition composes resources.
+type CompositionMode string
+
+// Composition modes.
+const (
+	// CompositionModeResources Compositions render a composed resource from
+	// each of their resource templates.
+	CompositionModeResources CompositionMode = "Resources"
+
+	// CompositionModeGoTemplate Compositions render composed resources using a
+	// Go template.
+	CompositionModeGoTemplate CompositionMode = "GoTemplate"
+)
+
+// A GoTemplate renders composed resources using a Go text/template.
+type GoTemplate struct {
+	// Source of the Go template. The template must render a YAML stream of
+	// composed resources. Each composed resource must be named using the
+	// crossplane.io/composition-resource-name annotation. The composite
+	// resource is available as .Composite, and the observed composed
+	// resources as .Observed, keyed by name.
+	Source string `json:"source"`
+
+	// Resources configures the connection details and readiness checks of the
+	// composed resources rendered by the template.
+	// +optional
+	Resources []TemplatedResource `json:"resources,omitempty"`
+}
+
+// A TemplatedResource configures a composed resource rendered by a Go
+// template.
+type TemplatedResource struct {
+	// Name of the composed resource, as specified by its
+	// crossplane.io/composition-resource-name annotation.
+	Name string `json:"name"`
+
+	// ConnectionDetails lists the propagation secret keys from this composed
+	// resource to the composition instance connection secret.
+	// +optional
+	ConnectionDetails []ConnectionDetail `json:"connectionDetails,omitempty"`
+
+	// ReadinessChecks allows users to define custom readiness checks. All
+	// checks have to return true in order for resource to be considered
+	// ready. The default readiness check is to have the "Ready" condition to
+	// be "True". Well-known Kubernetes kinds that don't have a "Ready"
+	// condition, like Deployments and Jobs, are checked according to their
+	// status by default.
+	// +optional
+	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+}
+
+// A FunctionType is a type of composition function.
+type FunctionType string
+
+// FunctionType types.
+const (
+	// FunctionTypeExec functions are executables that read a FunctionIO
+	// document from stdin and write an updated FunctionIO document to stdout.
+	FunctionTypeExec FunctionType = "Exec"
+)
+
+// A Function transforms the desired state of a composite resource's composed
+// resources.
+type Function struct {
+	// Name of this function. Must be unique within its Composition.
+	Name string `json:"name"`
+
+	// Type of this function.
+	// +kubebuilder:validation:Enum=Exec
+	Type FunctionType `json:"type"`
+
+	// Exec configures an Exec function.
+	// +optional
+	Exec *ExecFunction `json:"exec,omitempty"`
+}
+
+// An ExecFunction is an executable run by Crossplane. It is supplied a
+// FunctionIO document via stdin, and must write an updated FunctionIO
+// document to stdout. Stderr is included in any error returned if the
+// executable exits with a non-zero status.
+type ExecFunction struct {
+	// Command is the absolute path to the executable. Crossplane only runs
+	// executables within the directory it is configured to run functions from.
+	Command string `json:"command"`
+
+	// Args are passed to the executable.
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Timeout after which the executable will be killed. Defaults to 20s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
 // A PatchSet is a set of patches that can be reused from all resources within
 // a Composition.
 type PatchSet struct {
@@ -145,7 +302,8 @@ type ComposedTemplate struct {
 	// the resources array are named entries may added, deleted, and reordered
 	// as long as their names do not change. When entries are not named the
 	// length and order of the resources array should be treated as immutable.
-	// Either all or no entries must be named.
+	// Either all or no entries must be named. Names must not contain '[',
+	// which is reserved for resources expanded by forEach.
 	// +optional
 	Name *string `json:"name,omitempty"`
 
@@ -168,6 +326,114 @@ type ComposedTemplate struct {
 	// default readiness check is to have the "Ready" condition to be "True".
 	// +optional
 	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+
+	// Condition determines whether this template is included when composing
+	// resources. The template is included when the condition, which is
+	// evaluated against the composite resource, is true. If a template is
+	// excluded any composed resource previously created from it is deleted.
+	// Templates are always included if no condition is specified. Only named
+	// templates may specify a condition.
+	// +optional
+	Condition *ComposedTemplateCondition `json:"condition,omitempty"`
+
+	// ForEach expands this template once per element of an array field of the
+	// composite resource. Each element and its index may be used as inputs by
+	// FromForEach patches. Only named templates may specify forEach.
+	// +optional
+	ForEach *ComposedTemplateForEach `json:"forEach,omitempty"`
+
+	// DeletionPolicy specifies what will happen to the composed resource when
+	// its composite resource is deleted. Composed resources with the Orphan
+	// policy are released rather than deleted; their owner references and
+	// Crossplane labels are removed so that they may later be adopted by
+	// another composite resource. Defaults to the composite resource's
+	// spec.deletionPolicy, or to Delete if that is not set either.
+	// +optional
+	DeletionPolicy *xpv1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// DependsOn lists the names of other resource templates in this
+	// Composition. The composed resource is not applied until all of the
+	// composed resources rendered from the resource templates it depends on
+	// are ready. When the composite resource is deleted, composed resources
+	// are deleted in the reverse order. Only named templates may specify
+	// dependsOn, and dependencies must not be cyclic.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Mode determines how Crossplane manages the composed resource. Apply
+	// mode composed resources are created, updated, and deleted by Crossplane.
+	// Observe mode composed resources must already exist. Crossplane resolves
+	// an Observe mode composed resource by the name, or failing that the
+	// labels, that its template renders. It never modifies or deletes an
+	// Observe mode composed resource, but it does patch fields from it to the
+	// composite resource, and checks its readiness and connection details.
+	// +optional
+	// +kubebuilder:validation:Enum=Apply;Observe
+	// +kubebuilder:default=Apply
+	Mode *ComposedTemplateMode `json:"mode,omitempty"`
+}
+
+// A ComposedTemplateMode determines how Crossplane manages a composed resource.
+type ComposedTemplateMode string
+
+// Composed template modes.
+const (
+	// ComposedTemplateModeApply composed resources are created, updated, and
+	// deleted by Crossplane.
+	ComposedTemplateModeApply ComposedTemplateMode = "Apply"
+
+	// ComposedTemplateModeObserve composed resources are observed, but never
+	// modified or deleted, by Crossplane.
+	ComposedTemplateModeObserve ComposedTemplateMode = "Observe"
+)
+
+// A ComposedTemplateForEach expands a composed template once per element of an
+// array field of the composite resource.
+type ComposedTemplateForEach struct {
+	// FromFieldPath is the path of an array field on the composite resource.
+	// The template is expanded once per element of the array. The template is
+	// not expanded at all if the field does not exist.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// KeyFieldPath is the path of a field within each element whose value
+	// uniquely and stably identifies the element. Composed resources are
+	// associated with elements by key, so that only the composed resources of
+	// removed elements are deleted. Elements that are strings, numbers, or
+	// booleans are their own key if no KeyFieldPath is specified. Other
+	// elements are identified by their index.
+	// +optional
+	KeyFieldPath *string `json:"keyFieldPath,omitempty"`
+}
+
+// A ComposedTemplateConditionType is a type of composed template condition.
+type ComposedTemplateConditionType string
+
+// Composed template condition types.
+const (
+	ComposedTemplateConditionTypeMatchTrue   ComposedTemplateConditionType = "MatchTrue"
+	ComposedTemplateConditionTypeMatchFalse  ComposedTemplateConditionType = "MatchFalse"
+	ComposedTemplateConditionTypeMatchString ComposedTemplateConditionType = "MatchString"
+	ComposedTemplateConditionTypeNonEmpty    ComposedTemplateConditionType = "NonEmpty"
+)
+
+// A ComposedTemplateCondition is evaluated against a field of the composite
+// resource. The condition is false if the field does not exist.
+type ComposedTemplateCondition struct {
+	// Type of the condition. MatchTrue and MatchFalse are true if the field is
+	// the corresponding boolean. MatchString is true if the field matches the
+	// supplied string. NonEmpty is true if the field exists.
+	// +optional
+	// +kubebuilder:validation:Enum=MatchTrue;MatchFalse;MatchString;NonEmpty
+	// +kubebuilder:default=MatchTrue
+	Type ComposedTemplateConditionType `json:"type,omitempty"`
+
+	// FromFieldPath is the path of the field on the composite resource whose
+	// value the condition is evaluated against.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// MatchString is the value to match when using the MatchString type.
+	// +optional
+	MatchString string `json:"matchString,omitempty"`
 }
 
 // ReadinessCheckType is used for readiness check types.
@@ -175,17 +441,26 @@ type ReadinessCheckType string
 
 // The possible values for readiness check type.
 const (
-	ReadinessCheckTypeNonEmpty     ReadinessCheckType = "NonEmpty"
-	ReadinessCheckTypeMatchString  ReadinessCheckType = "MatchString"
-	ReadinessCheckTypeMatchInteger ReadinessCheckType = "MatchInteger"
-	ReadinessCheckTypeNone         ReadinessCheckType = "None"
+	ReadinessCheckTypeNonEmpty                       ReadinessCheckType = "NonEmpty"
+	ReadinessCheckTypeMatchString                    ReadinessCheckType = "MatchString"
+	ReadinessCheckTypeMatchInteger                   ReadinessCheckType = "MatchInteger"
+	ReadinessCheckTypeMatchIntegerGreaterThan        ReadinessCheckType = "MatchIntegerGreaterThan"
+	ReadinessCheckTypeMatchIntegerGreaterThanOrEqual ReadinessCheckType = "MatchIntegerGreaterThanOrEqual"
+	ReadinessCheckTypeMatchIntegerLessThan           ReadinessCheckType = "MatchIntegerLessThan"
+	ReadinessCheckTypeMatchIntegerLessThanOrEqual    ReadinessCheckType = "MatchIntegerLessThanOrEqual"
+	ReadinessCheckTypeMatchTrue                      ReadinessCheckType = "MatchTrue"
+	ReadinessCheckTypeMatchFalse                     ReadinessCheckType = "MatchFalse"
+	ReadinessCheckTypeMatchRegex                     ReadinessCheckType = "MatchRegex"
+	ReadinessCheckTypeMatchCondition                 ReadinessCheckType = "MatchCondition"
+	ReadinessCheckTypeNone                           ReadinessCheckType = "None"
 )
 
 // ReadinessCheck is used to indicate how to tell whether a resource is ready
-// for consumption
+// for consumption. A resource is considered not ready if the field at the
+// supplied field path does not exist.
 type ReadinessCheck struct {
 	// Type indicates the type of probe you'd like to use.
-	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"None"
+	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"MatchIntegerGreaterThan";"MatchIntegerGreaterThanOrEqual";"MatchIntegerLessThan";"MatchIntegerLessThanOrEqual";"MatchTrue";"MatchFalse";"MatchRegex";"MatchCondition";"NonEmpty";"None"
 	Type ReadinessCheckType `json:"type"`
 
 	// FieldPath shows the path of the field whose value will be used.
@@ -197,8 +472,40 @@ type ReadinessCheck struct {
 	MatchString string `json:"matchString,omitempty"`
 
 	// MatchInt is the value you'd like to match if you're using "MatchInt" type.
+	// It is also the value compared against when using any of the
+	// "MatchIntegerGreaterThan", "MatchIntegerGreaterThanOrEqual",
+	// "MatchIntegerLessThan", or "MatchIntegerLessThanOrEqual" types.
 	// +optional
 	MatchInteger int64 `json:"matchInteger,omitempty"`
+
+	// MatchIntegerFieldPath is the path of a field whose integer value you'd
+	// like to compare against when using any of the "MatchInteger" types. It
+	// takes precedence over MatchInteger.
+	// +optional
+	MatchIntegerFieldPath *string `json:"matchIntegerFieldPath,omitempty"`
+
+	// MatchRegex is the regular expression you'd like to match if you're using
+	// "MatchRegex" type. See https://github.com/google/re2/wiki/Syntax for the
+	// syntax.
+	// +optional
+	MatchRegex string `json:"matchRegex,omitempty"`
+
+	// MatchCondition is the condition you'd like to match if you're using
+	// "MatchCondition" type.
+	// +optional
+	MatchCondition *MatchConditionReadinessCheck `json:"matchCondition,omitempty"`
+}
+
+// MatchConditionReadinessCheck is used to indicate how to tell whether a
+// resource is ready for consumption using one of its conditions.
+type MatchConditionReadinessCheck struct {
+	// Type indicates the type of condition you'd like to use.
+	Type xpv1.ConditionType `json:"type"`
+
+	// Status is the status of the condition you'd like to match. Defaults to
+	// "True".
+	// +optional
+	Status corev1.ConditionStatus `json:"status,omitempty"`
 }
 
 // A PatchType is a type of patch.
@@ -211,26 +518,41 @@ const (
 	PatchTypeToCompositeFieldPath   PatchType = "ToCompositeFieldPath"
 	PatchTypeCombineFromComposite   PatchType = "CombineFromComposite"
 	PatchTypeCombineToComposite     PatchType = "CombineToComposite"
+	PatchTypeFromComposedFieldPath  PatchType = "FromComposedFieldPath"
+	PatchTypeFromForEach            PatchType = "FromForEach"
 )
 
 // Patch objects are applied between composite and composed resources. Their
 // behaviour depends on the Type selected. The default Type,
 // FromCompositeFieldPath, copies a value from the composite resource to
-// the composed resource, applying any defined transformers.
+// the composed resource, applying any defined transformers. The
+// FromComposedFieldPath type copies a value from another composed resource of
+// the same composite resource. The FromForEach type copies a value from the
+// array element a forEach template was expanded for.
 type Patch struct {
 	// Type sets the patching behaviour to be used. Each patch type may require
 	// its' own fields to be set on the Patch object.
 	// +optional
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromComposedFieldPath;FromForEach
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
 	// FromFieldPath is the path of the field on the resource whose value is
-	// to be used as input. Required when type is FromCompositeFieldPath or
-	// ToCompositeFieldPath.
+	// to be used as input. Required when type is FromCompositeFieldPath,
+	// ToCompositeFieldPath, FromComposedFieldPath, or FromForEach. When type
+	// is FromForEach the path is relative to an object with an 'element' field
+	// containing the array element, and an 'index' field containing its index.
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
+	// FromResourceName is the name of the resource template whose composed
+	// resource's value is to be used as input. Required when type is
+	// FromComposedFieldPath. The composed resource is read as it was observed
+	// when it was last applied, so its template should be applied first; i.e.
+	// it should appear earlier in the resources array.
+	// +optional
+	FromResourceName *string `json:"fromResourceName,omitempty"`
+
 	// Combine is the patch configuration for a CombineFromComposite or
 	// CombineToComposite patch.
 	Combine *Combine `json:"combine,omitempty"`
@@ -273,6 +595,34 @@ type PatchPolicy struct {
 	// +kubebuilder:validation:Enum=Optional;Required
 	// +optional
 	FromFieldPath *FromFieldPathPolicy `json:"fromFieldPath,omitempty"`
+
+	// MergeOptions specifies how to merge the patched value with any value
+	// that already exists at the destination field path. By default the
+	// existing value is replaced. Merge options are only supported by patches
+	// that write to a composed resource; they may not be used by
+	// ToCompositeFieldPath or CombineToComposite patches.
+	// +optional
+	MergeOptions *MergeOptions `json:"mergeOptions,omitempty"`
+}
+
+// MergeOptions specifies how a patched value is merged with an existing
+// value. Objects are always merged key by key when merge options are
+// specified, with patched values taking precedence over existing values.
+type MergeOptions struct {
+	// KeepMapValues specifies that values that already exist in a merged
+	// object should be preserved, rather than overwritten by patched values.
+	// +optional
+	KeepMapValues *bool `json:"keepMapValues,omitempty"`
+
+	// AppendSlice specifies that patched arrays should be appended to
+	// existing arrays, rather than replace them.
+	// +optional
+	AppendSlice *bool `json:"appendSlice,omitempty"`
+
+	// DeduplicateSlice specifies that duplicate elements should be removed
+	// from patched arrays, keeping the first occurrence of each element.
+	// +optional
+	DeduplicateSlice *bool `json:"deduplicateSlice,omitempty"`
 }
 
 // A Combine configures a patch that combines more than
@@ -283,9 +633,13 @@ type Combine struct {
 	// +kubebuilder:validation:MinItems=1
 	Variables []CombineVariable `json:"variables"`
 
-	// Strategy defines the strategy to use to combine the input variable values.
-	// Currently only string is supported.
-	// +kubebuilder:validation:Enum=string
+	// Strategy defines the strategy to use to combine the input variable
+	// values. The string strategy formats the variables as a string. The list
+	// strategy produces a list of the variables, in order. The object strategy
+	// produces an object keyed by the name of each variable. The sum strategy
+	// adds numeric variables together. The coalesce strategy produces the first
+	// variable that is not empty.
+	// +kubebuilder:validation:Enum=string;list;object;sum;coalesce
 	Strategy CombineStrategy `json:"strategy"`
 
 	// String declares that input variables should be combined into a single
@@ -294,36 +648,54 @@ type Combine struct {
 	String *StringCombine `json:"string,omitempty"`
 }
 
-// Combine calls the appropriate combiner.
+// Combine calls the appropriate combiner. Variables that were not found are
+// supplied as nil. All strategies except string omit such variables; the
+// string strategy formats them as-is.
 func (c *Combine) Combine(vars []interface{}) (interface{}, error) {
-	var combiner interface {
-		Combine(vars []interface{}) (interface{}, error)
-	}
+	var out interface{}
+	var err error
 
 	switch c.Strategy {
 	case CombineStrategyString:
-		combiner = c.String
+		if c.String == nil {
+			return nil, errors.Errorf(errFmtCombineConfigMissing, string(c.Strategy))
+		}
+		out, err = c.String.Combine(vars)
+	case CombineStrategyList:
+		out, err = combineList(vars)
+	case CombineStrategyObject:
+		out, err = combineObject(c.Variables, vars)
+	case CombineStrategySum:
+		out, err = combineSum(vars)
+	case CombineStrategyCoalesce:
+		out, err = combineCoalesce(vars)
 	default:
 		return nil, errors.Errorf(errFmtCombineStrategyNotSupported, string(c.Strategy))
 	}
 
-	// Check for nil interface requires reflection.
-	if reflect.ValueOf(combiner).IsNil() {
-		return nil, errors.Errorf(errFmtCombineConfigMissing, string(c.Strategy))
-	}
-	out, err := combiner.Combine(vars)
-	// Note: There are currently no tests or triggers to exercise this error as
-	// our only strategy ("String") uses fmt.Sprintf, which cannot return an error.
 	return out, errors.Wrapf(err, errFmtCombineStrategyFailed, string(c.Strategy))
 }
 
 // A CombineVariable defines the source of a value that is combined with
-// others to form and patch an output value. Currently, this only supports
-// retrieving values from a field path.
+// others to form and patch an output value. Variables may retrieve values from
+// a field path, or from a connection secret key when combining connection
+// details.
 type CombineVariable struct {
+	// Name of the variable. Required when using the object strategy, in which
+	// case it is used as the key of the variable's value.
+	// +optional
+	Name string `json:"name,omitempty"`
+
 	// FromFieldPath is the path of the field on the source whose value is
-	// to be used as input.
-	FromFieldPath string `json:"fromFieldPath"`
+	// to be used as input. Required unless FromConnectionSecretKey is set.
+	// +optional
+	FromFieldPath string `json:"fromFieldPath,omitempty"`
+
+	// FromConnectionSecretKey is the key of the composed resource's
+	// connection secret whose value is to be used as input. Only supported
+	// when combining connection details.
+	// +optional
+	FromConnectionSecretKey string `json:"fromConnectionSecretKey,omitempty"`
 }
 
 // A CombineStrategy determines what strategy will be applied to combine
@@ -332,7 +704,11 @@ type CombineStrategy string
 
 // CombineStrategy strategy definitions.
 const (
-	CombineStrategyString CombineStrategy = "string"
+	CombineStrategyString   CombineStrategy = "string"
+	CombineStrategyList     CombineStrategy = "list"
+	CombineStrategyObject   CombineStrategy = "object"
+	CombineStrategySum      CombineStrategy = "sum"
+	CombineStrategyCoalesce CombineStrategy = "coalesce"
 )
 
 // A StringCombine combines multiple input values into a single string.
@@ -348,6 +724,90 @@ func (s *StringCombine) Combine(vars []interface{}) (interface{}, error) {
 	return fmt.Sprintf(s.Format, vars...), nil
 }
 
+// combineList returns a list of all found variables, in order.
+func combineList(vars []interface{}) (interface{}, error) {
+	out := make([]interface{}, 0, len(vars))
+	for _, v := range vars {
+		if v != nil {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// combineObject returns an object of all found variables, keyed by name.
+func combineObject(cv []CombineVariable, vars []interface{}) (interface{}, error) {
+	out := make(map[string]interface{}, len(vars))
+	for i := range cv {
+		if cv[i].Name == "" {
+			return nil, errors.Errorf(errFmtCombineVariableName, i)
+		}
+		if _, ok := out[cv[i].Name]; ok {
+			return nil, errors.Errorf(errFmtCombineVariableDuplicate, cv[i].Name)
+		}
+		out[cv[i].Name] = vars[i]
+	}
+	for k, v := range out {
+		if v == nil {
+			delete(out, k)
+		}
+	}
+	return out, nil
+}
+
+// combineSum returns the sum of all found variables. The sum is an int64 if
+// all variables are integers, and a float64 otherwise.
+func combineSum(vars []interface{}) (interface{}, error) {
+	var isum int64
+	var fsum float64
+	isFloat := false
+	for i, v := range vars {
+		switch n := v.(type) {
+		case nil:
+			continue
+		case int:
+			isum += int64(n)
+		case int64:
+			isum += n
+		case float64:
+			fsum += n
+			isFloat = true
+		default:
+			return nil, errors.Errorf(errFmtCombineVariableNonNumber, i)
+		}
+	}
+	if isFloat {
+		return fsum + float64(isum), nil
+	}
+	return isum, nil
+}
+
+// combineCoalesce returns the first variable that is not empty. Missing
+// variables, empty strings, empty lists, and empty objects are considered
+// empty. It returns nil if all variables are empty.
+func combineCoalesce(vars []interface{}) (interface{}, error) {
+	for _, v := range vars {
+		switch t := v.(type) {
+		case nil:
+			continue
+		case string:
+			if t == "" {
+				continue
+			}
+		case []interface{}:
+			if len(t) == 0 {
+				continue
+			}
+		case map[string]interface{}:
+			if len(t) == 0 {
+				continue
+			}
+		}
+		return v, nil
+	}
+	return nil, nil
+}
+
 // Apply executes a patching operation between the from and to resources.
 // Applies all patch types unless an 'only' filter is supplied.
 func (c *Patch) Apply(cp, cd runtime.Object, only ...PatchType) error {
@@ -370,6 +830,70 @@ func (c *Patch) Apply(cp, cd runtime.Object, only ...PatchType) error {
 	return errors.Errorf(errFmtInvalidPatchType, c.Type)
 }
 
+// Validate returns an error if the Patch does not specify the fields required
+// by its type.
+func (c *Patch) Validate() error {
+	switch c.Type {
+	case PatchTypeFromCompositeFieldPath, PatchTypeToCompositeFieldPath, PatchTypeFromForEach:
+		if c.FromFieldPath == nil {
+			return errors.Errorf(errFmtRequiredField, "FromFieldPath", c.Type)
+		}
+	case PatchTypeFromComposedFieldPath:
+		if c.FromResourceName == nil {
+			return errors.Errorf(errFmtRequiredField, "FromResourceName", c.Type)
+		}
+		if c.FromFieldPath == nil {
+			return errors.Errorf(errFmtRequiredField, "FromFieldPath", c.Type)
+		}
+	case PatchTypeCombineFromComposite, PatchTypeCombineToComposite:
+		if c.Combine == nil {
+			return errors.Errorf(errFmtRequiredField, "Combine", c.Type)
+		}
+		if c.ToFieldPath == nil {
+			return errors.Errorf(errFmtRequiredField, "ToFieldPath", c.Type)
+		}
+		if len(c.Combine.Variables) == 0 {
+			return errors.New(errCombineRequiresVariables)
+		}
+	case PatchTypePatchSet:
+		if c.PatchSetName == nil {
+			return errors.Errorf(errFmtRequiredField, "PatchSetName", c.Type)
+		}
+	default:
+		return errors.Errorf(errFmtInvalidPatchType, c.Type)
+	}
+	if c.Policy != nil && c.Policy.MergeOptions != nil && c.patchesComposite() {
+		return errors.Errorf(errFmtMergeOptionsNotSupported, c.Type)
+	}
+	return nil
+}
+
+// patchesComposite returns true if the patch writes to the composite resource.
+func (c *Patch) patchesComposite() bool {
+	return c.Type == PatchTypeToCompositeFieldPath || c.Type == PatchTypeCombineToComposite
+}
+
+// ApplyFromComposed executes a FromComposedFieldPath patch, copying a value
+// from one composed resource to another.
+func (c *Patch) ApplyFromComposed(from, to runtime.Object) error {
+	if c.Type != PatchTypeFromComposedFieldPath {
+		return errors.Errorf(errFmtInvalidPatchType, c.Type)
+	}
+	if c.FromResourceName == nil {
+		return errors.Errorf(errFmtRequiredField, "FromResourceName", c.Type)
+	}
+	return c.applyFromFieldPathPatch(from, to)
+}
+
+// ApplyFromForEach executes a FromForEach patch, copying a value from the
+// supplied forEach element object to the supplied composed resource.
+func (c *Patch) ApplyFromForEach(from, to runtime.Object) error {
+	if c.Type != PatchTypeFromForEach {
+		return errors.Errorf(errFmtInvalidPatchType, c.Type)
+	}
+	return c.applyFromFieldPathPatch(from, to)
+}
+
 // filterPatch returns true if patch should be filtered (not applied)
 func (c *Patch) filterPatch(only ...PatchType) bool {
 	// filter does not apply if not set
@@ -398,22 +922,108 @@ func (c *Patch) applyTransforms(input interface{}) (interface{}, error) {
 
 // patchFieldValueToObject, given a path, value and "to" object, will
 // apply the value to the "to" object at the given path, returning
-// any errors as they occur.
-func patchFieldValueToObject(path string, value interface{}, to runtime.Object) error {
+// any errors as they occur. The value is merged with any existing value
+// according to the supplied merge options, if any.
+func patchFieldValueToObject(path string, value interface{}, to runtime.Object, mo *MergeOptions) error {
 	if u, ok := to.(interface{ UnstructuredContent() map[string]interface{} }); ok {
-		return fieldpath.Pave(u.UnstructuredContent()).SetValue(path, value)
+		return setValue(fieldpath.Pave(u.UnstructuredContent()), path, value, mo)
 	}
 
 	toMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(to)
 	if err != nil {
 		return err
 	}
-	if err := fieldpath.Pave(toMap).SetValue(path, value); err != nil {
+	if err := setValue(fieldpath.Pave(toMap), path, value, mo); err != nil {
 		return err
 	}
 	return runtime.DefaultUnstructuredConverter.FromUnstructured(toMap, to)
 }
 
+func setValue(p *fieldpath.Paved, path string, value interface{}, mo *MergeOptions) error {
+	if mo != nil {
+		existing, err := p.GetValue(path)
+		if err != nil && !fieldpath.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			value = mo.Merge(existing, value)
+		}
+	}
+	return p.SetValue(path, value)
+}
+
+// Merge the supplied patched value into the supplied existing value. Objects
+// are merged recursively. Arrays are appended and deduplicated as configured.
+// Any other patched value replaces the existing value.
+func (mo *MergeOptions) Merge(existing, patched interface{}) interface{} {
+	switch p := patched.(type) {
+	case map[string]interface{}:
+		e, ok := existing.(map[string]interface{})
+		if !ok {
+			return patched
+		}
+		out := make(map[string]interface{}, len(e)+len(p))
+		for k, v := range e {
+			out[k] = v
+		}
+		for k, v := range p {
+			ev, ok := out[k]
+			if !ok {
+				out[k] = v
+				continue
+			}
+			_, em := ev.(map[string]interface{})
+			_, pm := v.(map[string]interface{})
+			if mo.KeepMapValues != nil && *mo.KeepMapValues && !(em && pm) {
+				continue
+			}
+			out[k] = mo.Merge(ev, v)
+		}
+		return out
+	case []interface{}:
+		out := p
+		if e, ok := existing.([]interface{}); ok && mo.AppendSlice != nil && *mo.AppendSlice {
+			out = append(append(make([]interface{}, 0, len(e)+len(p)), e...), p...)
+		}
+		if mo.DeduplicateSlice != nil && *mo.DeduplicateSlice {
+			out = deduplicate(out)
+		}
+		return out
+	default:
+		return patched
+	}
+}
+
+// deduplicate returns the supplied array with any duplicate elements removed,
+// keeping the first occurrence of each element.
+func deduplicate(in []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(in))
+	for _, v := range in {
+		dup := false
+		for _, o := range out {
+			if reflect.DeepEqual(v, o) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// mergeOptions returns the merge options of the patch's policy, if any.
+// Patches to the composite resource are applied on every reconcile, so they
+// always replace the existing value; merging would e.g. append the same
+// elements to an array again and again.
+func (c *Patch) mergeOptions() *MergeOptions {
+	if c.Policy == nil || c.patchesComposite() {
+		return nil
+	}
+	return c.Policy.MergeOptions
+}
+
 // applyFromFieldPathPatch patches the "to" resource, using a source field
 // on the "from" resource. Values may be transformed if any are defined on
 // the patch.
@@ -446,7 +1056,7 @@ func (c *Patch) applyFromFieldPathPatch(from, to runtime.Object) error {
 		return err
 	}
 
-	return patchFieldValueToObject(*c.ToFieldPath, out, to)
+	return patchFieldValueToObject(*c.ToFieldPath, out, to, c.mergeOptions())
 }
 
 // applyCombineFromVariablesPatch patches the "to" resource, taking a list of
@@ -476,27 +1086,36 @@ func (c *Patch) applyCombineFromVariablesPatch(from, to runtime.Object) error {
 	}
 
 	in := make([]interface{}, vl)
+	found := 0
 
 	// Get value of each variable
 	// NOTE: This currently assumes all variables define a 'fromFieldPath'
 	// value. If we add new variable types, this may not be the case and
 	// this code may be better served split out into a dedicated function.
 	for i, sp := range c.Combine.Variables {
+		if sp.FromFieldPath == "" {
+			return errors.Errorf(errFmtCombineVariableFieldPath, i)
+		}
 		iv, err := fieldpath.Pave(fromMap).GetValue(sp.FromFieldPath)
 
-		// If any source field is not found, we will not
-		// apply the patch. This is to avoid situations
-		// where a combine patch is expecting a fixed
-		// number of inputs (e.g. a string format
-		// expecting 3 fields '%s-%s-%s' but only
-		// receiving 2 values).
+		// Optional variables that are not found are supplied to the combiner
+		// as nil, which omits them.
 		if IsOptionalFieldPathNotFound(err, c.Policy) {
-			return nil
+			continue
 		}
 		if err != nil {
 			return err
 		}
 		in[i] = iv
+		found++
+	}
+
+	// We don't apply the patch if none of its variables were found. Nor do we
+	// apply a string combine patch if any of its variables were not found.
+	// This is to avoid situations where a string format is expecting a fixed
+	// number of inputs (e.g. '%s-%s-%s' but only receiving 2 values).
+	if found == 0 || (found < vl && c.Combine.Strategy == CombineStrategyString) {
+		return nil
 	}
 
 	// Combine input values
@@ -505,13 +1124,19 @@ func (c *Patch) applyCombineFromVariablesPatch(from, to runtime.Object) error {
 		return err
 	}
 
+	// There is nothing to patch if, for example, all variables were empty
+	// when using the coalesce strategy.
+	if cb == nil {
+		return nil
+	}
+
 	// Apply transform pipeline
 	out, err := c.applyTransforms(cb)
 	if err != nil {
 		return err
 	}
 
-	return patchFieldValueToObject(*c.ToFieldPath, out, to)
+	return patchFieldValueToObject(*c.ToFieldPath, out, to, c.mergeOptions())
 }
 
 // IsOptionalFieldPathNotFound returns true if the supplied error indicates a
@@ -550,7 +1175,7 @@ type Transform struct {
 	Type TransformType `json:"type"`
 
 	// Math is used to transform the input via mathematical operations such as
-	// multiplication.
+	// multiplication, addition, division, clamping, and rounding.
 	// +optional
 	Math *MathTransform `json:"math,omitempty"`
 
@@ -558,6 +1183,11 @@ type Transform struct {
 	// +optional
 	Map *MapTransform `json:"map,omitempty"`
 
+	// MapFallback determines what a map transform returns when its input
+	// does not match any key. It may only be specified when type is map.
+	// +optional
+	MapFallback *MapTransformFallback `json:"mapFallback,omitempty"`
+
 	// String is used to transform the input into a string or a different kind
 	// of string. Note that the input does not necessarily need to be a string.
 	// +optional
@@ -577,7 +1207,11 @@ func (t *Transform) Transform(input interface{}) (interface{}, error) {
 	case TransformTypeMath:
 		transformer = t.Math
 	case TransformTypeMap:
-		transformer = t.Map
+		if t.Map == nil {
+			return nil, errors.Errorf(errFmtTransformConfigMissing, string(t.Type))
+		}
+		out, err := t.Map.ResolveWithFallback(input, t.MapFallback)
+		return out, errors.Wrapf(err, errFmtTransformTypeFailed, string(t.Type))
 	case TransformTypeString:
 		transformer = t.String
 	case TransformTypeConvert:
@@ -595,43 +1229,325 @@ func (t *Transform) Transform(input interface{}) (interface{}, error) {
 	return out, errors.Wrapf(err, errFmtTransformTypeFailed, string(t.Type))
 }
 
+// Validate returns an error if the Transform is not configured correctly.
+func (t *Transform) Validate() error {
+	if t.MapFallback != nil && t.Type != TransformTypeMap {
+		return errors.Errorf(errFmtMapFallbackType, string(t.Type))
+	}
+	switch t.Type {
+	case TransformTypeMath:
+		if t.Math == nil {
+			return errors.Errorf(errFmtTransformConfigMissing, string(t.Type))
+		}
+		return errors.Wrapf(t.Math.Validate(), errFmtTransformTypeInvalid, string(t.Type))
+	case TransformTypeString:
+		if t.String == nil {
+			return errors.Errorf(errFmtTransformConfigMissing, string(t.Type))
+		}
+		return errors.Wrapf(t.String.Validate(), errFmtTransformTypeInvalid, string(t.Type))
+	case TransformTypeMap:
+		if t.Map == nil {
+			return errors.Errorf(errFmtTransformConfigMissing, string(t.Type))
+		}
+		if t.MapFallback == nil {
+			return nil
+		}
+		return errors.Wrapf(t.MapFallback.Validate(), errFmtTransformTypeInvalid, string(t.Type))
+	case TransformTypeConvert:
+		return nil
+	default:
+		return errors.Errorf(errFmtTypeNotSupported, string(t.Type))
+	}
+}
+
 // MathTransform conducts mathematical operations on the input with the given
-// configuration in its properties.
+// configuration in its properties. Exactly one operation must be specified.
+// Unless a result type is specified integer inputs produce integer outputs when
+// the operation's operands are integers, while float inputs or operands
+// produce float outputs.
 type MathTransform struct {
 	// Multiply the value.
 	// +optional
-	Multiply *int64 `json:"multiply,omitempty"`
+	Multiply *MathOperand `json:"multiply,omitempty"`
+
+	// Add to the value.
+	// +optional
+	Add *MathOperand `json:"add,omitempty"`
+
+	// Subtract from the value.
+	// +optional
+	Subtract *MathOperand `json:"subtract,omitempty"`
+
+	// Divide the value. Integer inputs divided by an integer use integer
+	// division, truncating any remainder, unless the result type is Float.
+	// +optional
+	Divide *MathOperand `json:"divide,omitempty"`
+
+	// Clamp the value between a minimum and maximum.
+	// +optional
+	Clamp *MathClamp `json:"clamp,omitempty"`
+
+	// Round the value to the supplied number of decimal places. Integer inputs
+	// are returned unchanged.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Round *int64 `json:"round,omitempty"`
+
+	// ResultType is the type of number the operation produces. Float results
+	// are always calculated using floating point arithmetic. Integer results
+	// have any fractional part truncated. By default the result is an integer
+	// if the input and all operands are integers, and a float otherwise.
+	// +kubebuilder:validation:Enum=Integer;Float
+	// +optional
+	ResultType *MathResultType `json:"resultType,omitempty"`
+}
+
+// A MathResultType is the type of number a math transform produces.
+type MathResultType string
+
+// Math transform result types.
+const (
+	MathResultTypeInteger MathResultType = "Integer"
+	MathResultTypeFloat   MathResultType = "Float"
+)
+
+// A MathClamp clamps a value between a minimum and maximum. At least one of
+// the minimum and maximum must be specified.
+type MathClamp struct {
+	// Min is the smallest value that will be returned.
+	// +optional
+	Min *MathOperand `json:"min,omitempty"`
+
+	// Max is the largest value that will be returned.
+	// +optional
+	Max *MathOperand `json:"max,omitempty"`
+}
+
+// A MathOperand is an integer or floating point number. Operands without a
+// fractional part (e.g. 2 or 2.0) are integers. MathOperand replaces the
+// *int64 that earlier versions of this API used for the Multiply operand; use
+// NewMathOperand to convert an int64.
+// +kubebuilder:validation:Type=number
+type MathOperand string
+
+// NewMathOperand returns a MathOperand of the supplied integer.
+func NewMathOperand(i int64) *MathOperand {
+	o := MathOperand(strconv.FormatInt(i, 10))
+	return &o
+}
+
+// MarshalJSON into a JSON number.
+func (o MathOperand) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseFloat(string(o), 64); err != nil {
+		return nil, errors.Errorf(errFmtMathInvalidOperand, string(o))
+	}
+	return []byte(o), nil
+}
+
+// UnmarshalJSON from a JSON number.
+func (o *MathOperand) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*o = MathOperand(n)
+	return nil
+}
+
+// Int64 returns the operand as an int64, and whether it is an integer.
+func (o MathOperand) Int64() (int64, bool) {
+	if i, err := strconv.ParseInt(string(o), 10, 64); err == nil {
+		return i, true
+	}
+	// JSON doesn't distinguish 2.0 from 2, so neither do we.
+	f, err := strconv.ParseFloat(string(o), 64)
+	if err != nil || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// Float64 returns the operand as a float64.
+func (o MathOperand) Float64() float64 {
+	f, _ := strconv.ParseFloat(string(o), 64)
+	return f
+}
+
+// Validate returns an error if the MathTransform does not specify exactly one
+// valid operation.
+func (m *MathTransform) Validate() error {
+	ops := 0
+	for _, set := range []bool{m.Multiply != nil, m.Add != nil, m.Subtract != nil, m.Divide != nil, m.Clamp != nil, m.Round != nil} {
+		if set {
+			ops++
+		}
+	}
+	switch {
+	case ops == 0:
+		return errors.New(errMathNoOperation)
+	case ops > 1:
+		return errors.New(errMathTooManyOperations)
+	}
+	for _, o := range m.operands() {
+		if _, err := strconv.ParseFloat(string(*o), 64); err != nil {
+			return errors.Errorf(errFmtMathInvalidOperand, string(*o))
+		}
+	}
+	switch {
+	case m.Divide != nil && m.Divide.Float64() == 0:
+		return errors.New(errMathDivideByZero)
+	case m.Clamp != nil && m.Clamp.Min == nil && m.Clamp.Max == nil:
+		return errors.New(errMathClampNoBounds)
+	case m.Clamp != nil && m.Clamp.Min != nil && m.Clamp.Max != nil && m.Clamp.Min.Float64() > m.Clamp.Max.Float64():
+		return errors.New(errMathClampBounds)
+	}
+	if m.ResultType != nil && *m.ResultType != MathResultTypeInteger && *m.ResultType != MathResultTypeFloat {
+		return errors.Errorf(errFmtMathResultType, *m.ResultType)
+	}
+	return nil
+}
+
+// operands returns the operands of the MathTransform's operation.
+func (m *MathTransform) operands() []*MathOperand {
+	out := make([]*MathOperand, 0, 2)
+	for _, o := range []*MathOperand{m.Multiply, m.Add, m.Subtract, m.Divide} {
+		if o != nil {
+			out = append(out, o)
+		}
+	}
+	if m.Clamp != nil {
+		for _, o := range []*MathOperand{m.Clamp.Min, m.Clamp.Max} {
+			if o != nil {
+				out = append(out, o)
+			}
+		}
+	}
+	return out
 }
 
 // Resolve runs the Math transform.
 func (m *MathTransform) Resolve(input interface{}) (interface{}, error) {
-	if m.Multiply == nil {
-		return nil, errors.New(errMathNoMultiplier)
+	if err := m.Validate(); err != nil {
+		return nil, err
 	}
-	switch i := input.(type) {
+	var i int64
+	switch v := input.(type) {
 	case int64:
-		return *m.Multiply * i, nil
+		i = v
 	case int:
-		return *m.Multiply * int64(i), nil
+		i = int64(v)
+	case float64:
+		f := m.resolveFloat(v)
+		if m.ResultType != nil && *m.ResultType == MathResultTypeInteger {
+			return int64(math.Trunc(f)), nil
+		}
+		return f, nil
 	default:
 		return nil, errors.New(errMathInputNonNumber)
 	}
+
+	switch {
+	case m.ResultType == nil:
+		return m.resolveInt(i), nil
+	case *m.ResultType == MathResultTypeFloat:
+		return m.resolveFloat(float64(i)), nil
+	}
+	// The result type is Integer.
+	out := m.resolveInt(i)
+	if f, ok := out.(float64); ok {
+		return int64(math.Trunc(f)), nil
+	}
+	return out, nil
+}
+
+// resolveInt resolves an integer input. The result is an integer unless any
+// of the operands is a float.
+func (m *MathTransform) resolveInt(i int64) interface{} {
+	for _, o := range m.operands() {
+		if _, ok := o.Int64(); !ok {
+			return m.resolveFloat(float64(i))
+		}
+	}
+	operand := func(o *MathOperand) int64 {
+		v, _ := o.Int64()
+		return v
+	}
+	switch {
+	case m.Multiply != nil:
+		return i * operand(m.Multiply)
+	case m.Add != nil:
+		return i + operand(m.Add)
+	case m.Subtract != nil:
+		return i - operand(m.Subtract)
+	case m.Divide != nil:
+		return i / operand(m.Divide)
+	case m.Clamp != nil:
+		if m.Clamp.Min != nil && i < operand(m.Clamp.Min) {
+			return operand(m.Clamp.Min)
+		}
+		if m.Clamp.Max != nil && i > operand(m.Clamp.Max) {
+			return operand(m.Clamp.Max)
+		}
+	}
+	// Rounding an integer is a no-op.
+	return i
 }
 
-// MapTransform returns a value for the input from the given map.
+func (m *MathTransform) resolveFloat(f float64) float64 {
+	switch {
+	case m.Multiply != nil:
+		return f * m.Multiply.Float64()
+	case m.Add != nil:
+		return f + m.Add.Float64()
+	case m.Subtract != nil:
+		return f - m.Subtract.Float64()
+	case m.Divide != nil:
+		return f / m.Divide.Float64()
+	case m.Clamp != nil:
+		if m.Clamp.Min != nil && f < m.Clamp.Min.Float64() {
+			return m.Clamp.Min.Float64()
+		}
+		if m.Clamp.Max != nil && f > m.Clamp.Max.Float64() {
+			return m.Clamp.Max.Float64()
+		}
+	case m.Round != nil:
+		p := math.Pow10(int(*m.Round))
+		return math.Round(f*p) / p
+	}
+	return f
+}
+
+// A MapTransformFallbackTo determines what a map transform returns when its
+// input does not match any of its pairs.
+type MapTransformFallbackTo string
+
+// Accepted MapTransformFallbackTo values.
+const (
+	// MapTransformFallbackToValue returns the fallback value, or an error if
+	// no fallback value was specified.
+	MapTransformFallbackToValue MapTransformFallbackTo = "Value"
+
+	// MapTransformFallbackToInput returns the input unchanged.
+	MapTransformFallbackToInput MapTransformFallbackTo = "Input"
+)
+
+// MapTransform returns a value for the input from the given map. Inputs that
+// are not strings (i.e. booleans and numbers) are matched against the keys of
+// the map using their string representation.
 type MapTransform struct {
 	// TODO(negz): Are Pairs really optional if a MapTransform was specified?
 
-	// Pairs is the map that will be used for transform.
+	// Pairs is the map that will be used for transform. Values may be any
+	// valid JSON value, including objects and arrays.
 	// +optional
-	Pairs map[string]string `json:",inline"`
+	Pairs map[string]extv1.JSON `json:",inline"`
 }
 
 // NOTE(negz): The Kubernetes JSON decoder doesn't seem to like inlining a map
 // into a struct - doing so results in a seemingly successful unmarshal of the
 // data, but an empty map. We must keep the ,inline tag nevertheless in order to
 // trick the CRD generator into thinking MapTransform is an arbitrary map (i.e.
-// generating a validation schema with string additionalProperties), but the
+// generating a validation schema with arbitrary additionalProperties), but the
 // actual marshalling is handled by the marshal methods below.
 
 // UnmarshalJSON into this MapTransform.
@@ -644,30 +1560,381 @@ func (m MapTransform) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.Pairs)
 }
 
+// A MapTransformFallback determines what a map transform returns when its
+// input does not match any of its pairs.
+type MapTransformFallback struct {
+	// Value is returned when no pair matches the input. It may be any valid
+	// JSON value, including objects and arrays.
+	// +optional
+	Value *extv1.JSON `json:"value,omitempty"`
+
+	// To determines what is returned when no pair matches the input. Value
+	// (the default) returns the fallback value, or an error if no fallback
+	// value was specified. Input returns the input unchanged.
+	// +optional
+	// +kubebuilder:validation:Enum=Value;Input
+	To MapTransformFallbackTo `json:"to,omitempty"`
+}
+
+// Validate returns an error if the MapTransformFallback is not configured
+// correctly.
+func (f *MapTransformFallback) Validate() error {
+	switch f.To {
+	case "", MapTransformFallbackToValue:
+		return nil
+	case MapTransformFallbackToInput:
+		if f.Value != nil {
+			return errors.New(errMapFallbackConflict)
+		}
+		return nil
+	default:
+		return errors.Errorf(errFmtMapFallbackToNotSupported, f.To)
+	}
+}
+
 // Resolve runs the Map transform.
 func (m *MapTransform) Resolve(input interface{}) (interface{}, error) {
+	return m.ResolveWithFallback(input, nil)
+}
+
+// ResolveWithFallback runs the Map transform, falling back as specified by the
+// supplied MapTransformFallback if the input does not match any pair.
+func (m *MapTransform) ResolveWithFallback(input interface{}, f *MapTransformFallback) (interface{}, error) {
+	if f == nil {
+		f = &MapTransformFallback{}
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	var key string
 	switch i := input.(type) {
 	case string:
-		val, ok := m.Pairs[i]
-		if !ok {
-			return nil, errors.Errorf(errFmtMapNotFound, i)
-		}
-		return val, nil
+		key = i
+	case bool:
+		key = strconv.FormatBool(i)
+	case int:
+		key = strconv.Itoa(i)
+	case int64:
+		key = strconv.FormatInt(i, 10)
+	case float64:
+		key = strconv.FormatFloat(i, 'f', -1, 64)
 	default:
-		return nil, errors.Errorf(errFmtMapTypeNotSupported, reflect.TypeOf(input).String())
+		return nil, errors.Errorf(errFmtMapTypeNotSupported, reflect.TypeOf(input))
+	}
+
+	val, ok := m.Pairs[key]
+	switch {
+	case ok:
+		return unmarshalJSONValue(val)
+	case f.To == MapTransformFallbackToInput:
+		return input, nil
+	case f.Value != nil:
+		return unmarshalJSONValue(*f.Value)
+	default:
+		return nil, errors.Errorf(errFmtMapNotFound, key)
 	}
 }
 
+// unmarshalJSONValue returns the Go representation of the supplied JSON value.
+// Numbers are represented as int64 where possible, consistent with values read
+// from an unstructured object.
+func unmarshalJSONValue(v extv1.JSON) (interface{}, error) {
+	var out interface{}
+	err := utiljson.Unmarshal(v.Raw, &out)
+	return out, errors.Wrap(err, errMapUnmarshalValue)
+}
+
+// A StringTransformType is a type of string transform.
+type StringTransformType string
+
+// Accepted StringTransformTypes.
+const (
+	StringTransformTypeFormat     StringTransformType = "Format"
+	StringTransformTypeConvert    StringTransformType = "Convert"
+	StringTransformTypeTrimPrefix StringTransformType = "TrimPrefix"
+	StringTransformTypeTrimSuffix StringTransformType = "TrimSuffix"
+	StringTransformTypeRegexp     StringTransformType = "Regexp"
+	StringTransformTypeHash       StringTransformType = "Hash"
+	StringTransformTypeSplit      StringTransformType = "Split"
+	StringTransformTypeJoin       StringTransformType = "Join"
+)
+
+// A StringConversionType is a type of string conversion.
+type StringConversionType string
+
+// Accepted StringConversionTypes.
+const (
+	StringConversionTypeToUpper    StringConversionType = "ToUpper"
+	StringConversionTypeToLower    StringConversionType = "ToLower"
+	StringConversionTypeToBase64   StringConversionType = "ToBase64"
+	StringConversionTypeFromBase64 StringConversionType = "FromBase64"
+)
+
+// A StringHashAlgorithm is a hashing algorithm.
+type StringHashAlgorithm string
+
+// Accepted StringHashAlgorithms.
+const (
+	StringHashAlgorithmSHA1   StringHashAlgorithm = "SHA1"
+	StringHashAlgorithmSHA256 StringHashAlgorithm = "SHA256"
+	StringHashAlgorithmSHA512 StringHashAlgorithm = "SHA512"
+)
+
 // A StringTransform returns a string given the supplied input.
 type StringTransform struct {
+	// Type of the string transform to be run. Defaults to Format.
+	// +optional
+	// +kubebuilder:validation:Enum=Format;Convert;TrimPrefix;TrimSuffix;Regexp;Hash;Split;Join
+	Type StringTransformType `json:"type,omitempty"`
+
 	// Format the input using a Go format string. See
-	// https://golang.org/pkg/fmt/ for details.
-	Format string `json:"fmt"`
+	// https://golang.org/pkg/fmt/ for details. Required when type is Format.
+	// +optional
+	Format string `json:"fmt,omitempty"`
+
+	// Convert the input string. Required when type is Convert.
+	// +optional
+	// +kubebuilder:validation:Enum=ToUpper;ToLower;ToBase64;FromBase64
+	Convert *StringConversionType `json:"convert,omitempty"`
+
+	// Trim the supplied prefix or suffix from the input string. Required when
+	// type is TrimPrefix or TrimSuffix.
+	// +optional
+	Trim *string `json:"trim,omitempty"`
+
+	// Regexp extracts a match from the input string. Required when type is
+	// Regexp.
+	// +optional
+	Regexp *StringTransformRegexp `json:"regexp,omitempty"`
+
+	// Hash the input string. Required when type is Hash.
+	// +optional
+	Hash *StringTransformHash `json:"hash,omitempty"`
+
+	// Split the input string into an array of strings. Required when type is
+	// Split.
+	// +optional
+	Split *StringTransformSplit `json:"split,omitempty"`
+
+	// Join an input array into a string. Required when type is Join.
+	// +optional
+	Join *StringTransformJoin `json:"join,omitempty"`
+}
+
+// A StringTransformRegexp extracts a match from the input string using a
+// regular expression.
+type StringTransformRegexp struct {
+	// Match string. May optionally include submatches, aka capture groups.
+	// See https://pkg.go.dev/regexp/ for details.
+	Match string `json:"match"`
+
+	// Group number to match. 0 (the default) matches the entire expression.
+	// +optional
+	Group *int `json:"group,omitempty"`
+}
+
+// A StringTransformHash hashes the input string, returning the hex encoded
+// digest.
+type StringTransformHash struct {
+	// Algorithm used to hash the input.
+	// +kubebuilder:validation:Enum=SHA1;SHA256;SHA512
+	Algorithm StringHashAlgorithm `json:"algorithm"`
+
+	// Length to which the hex encoded digest will be truncated, for example
+	// to fit the name length limits of an external system. The full digest
+	// is returned if omitted.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Length *int64 `json:"length,omitempty"`
+}
+
+// A StringTransformSplit splits the input string into an array of strings.
+type StringTransformSplit struct {
+	// Separator at which to split the input string.
+	Separator string `json:"separator"`
+}
+
+// A StringTransformJoin joins an array into a string.
+type StringTransformJoin struct {
+	// Separator to place between joined elements.
+	// +optional
+	Separator string `json:"separator,omitempty"`
+}
+
+// Validate returns an error if the StringTransform is not configured
+// correctly.
+func (s *StringTransform) Validate() error {
+	_, err := s.validate()
+	return err
+}
+
+// validate the StringTransform, returning its compiled regular expression if
+// it is a Regexp transform.
+func (s *StringTransform) validate() (*regexp.Regexp, error) {
+	switch s.Type {
+	case StringTransformTypeFormat, "":
+		if s.Format == "" {
+			return nil, errors.Errorf(errFmtStringTransformConfigMissing, StringTransformTypeFormat)
+		}
+	case StringTransformTypeConvert:
+		if s.Convert == nil {
+			return nil, errors.Errorf(errFmtStringTransformConfigMissing, s.Type)
+		}
+		switch *s.Convert {
+		case StringConversionTypeToUpper, StringConversionTypeToLower, StringConversionTypeToBase64, StringConversionTypeFromBase64:
+		default:
+			return nil, errors.Errorf(errFmtStringConversionNotSupported, *s.Convert)
+		}
+	case StringTransformTypeTrimPrefix, StringTransformTypeTrimSuffix:
+		if s.Trim == nil {
+			return nil, errors.Errorf(errFmtStringTransformConfigMissing, s.Type)
+		}
+	case StringTransformTypeRegexp:
+		if s.Regexp == nil {
+			return nil, errors.Errorf(errFmtStringTransformConfigMissing, s.Type)
+		}
+		re, err := compileRegexp(s.Regexp.Match)
+		return re, errors.Wrap(err, errStringRegexpCompile)
+	case StringTransformTypeHash:
+		if s.Hash == nil {
+			return nil, errors.Errorf(errFmtStringTransformConfigMissing, s.Type)
+		}
+		switch s.Hash.Algorithm {
+		case StringHashAlgorithmSHA1, StringHashAlgorithmSHA256, StringHashAlgorithmSHA512:
+		default:
+			return nil, errors.Errorf(errFmtStringHashNotSupported, s.Hash.Algorithm)
+		}
+		if s.Hash.Length != nil && *s.Hash.Length < 1 {
+			return nil, errors.Errorf(errFmtStringHashLength, *s.Hash.Length)
+		}
+	case StringTransformTypeSplit:
+		if s.Split == nil {
+			return nil, errors.Errorf(errFmtStringTransformConfigMissing, s.Type)
+		}
+	case StringTransformTypeJoin:
+		if s.Join == nil {
+			return nil, errors.Errorf(errFmtStringTransformConfigMissing, s.Type)
+		}
+	default:
+		return nil, errors.Errorf(errFmtStringTransformTypeNotSupported, s.Type)
+	}
+	return nil, nil
 }
 
 // Resolve runs the String transform.
 func (s *StringTransform) Resolve(input interface{}) (interface{}, error) {
-	return fmt.Sprintf(s.Format, input), nil
+	re, err := s.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	switch s.Type {
+	case StringTransformTypeFormat, "":
+		return fmt.Sprintf(s.Format, input), nil
+	case StringTransformTypeJoin:
+		return s.join(input)
+	}
+
+	// All other string transforms operate on a string input.
+	str, ok := input.(string)
+	if !ok {
+		return nil, errors.New(errStringInputNonString)
+	}
+
+	switch s.Type {
+	case StringTransformTypeConvert:
+		return s.convert(str)
+	case StringTransformTypeTrimPrefix:
+		return strings.TrimPrefix(str, *s.Trim), nil
+	case StringTransformTypeTrimSuffix:
+		return strings.TrimSuffix(str, *s.Trim), nil
+	case StringTransformTypeRegexp:
+		return s.regexp(re, str)
+	case StringTransformTypeHash:
+		return s.hash(str), nil
+	default: // StringTransformTypeSplit
+		parts := strings.Split(str, s.Split.Separator)
+		out := make([]interface{}, len(parts))
+		for i := range parts {
+			out[i] = parts[i]
+		}
+		return out, nil
+	}
+}
+
+func (s *StringTransform) convert(str string) (interface{}, error) {
+	switch *s.Convert {
+	case StringConversionTypeToUpper:
+		return strings.ToUpper(str), nil
+	case StringConversionTypeToLower:
+		return strings.ToLower(str), nil
+	case StringConversionTypeToBase64:
+		return base64.StdEncoding.EncodeToString([]byte(str)), nil
+	default: // StringConversionTypeFromBase64
+		b, err := base64.StdEncoding.DecodeString(str)
+		return string(b), errors.Wrap(err, errStringDecodeBase64)
+	}
+}
+
+// regexps caches compiled regular expressions by pattern, so that Regexp
+// string transforms don't compile their pattern each time they're resolved. A
+// compiled *regexp.Regexp is safe for concurrent use.
+var regexps sync.Map
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(pattern, re)
+	return re, nil
+}
+
+func (s *StringTransform) regexp(re *regexp.Regexp, str string) (interface{}, error) {
+	groups := re.FindStringSubmatch(str)
+	if groups == nil {
+		return nil, errors.Errorf(errFmtStringRegexpNoMatch, s.Regexp.Match)
+	}
+	g := 0
+	if s.Regexp.Group != nil {
+		g = *s.Regexp.Group
+	}
+	if g < 0 || g >= len(groups) {
+		return nil, errors.Errorf(errFmtStringRegexpNoGroup, s.Regexp.Match, g)
+	}
+	return groups[g], nil
+}
+
+func (s *StringTransform) hash(str string) string {
+	var h string
+	switch s.Hash.Algorithm {
+	case StringHashAlgorithmSHA1:
+		h = fmt.Sprintf("%x", sha1.Sum([]byte(str))) // nolint:gosec // Not used for security purposes.
+	case StringHashAlgorithmSHA256:
+		h = fmt.Sprintf("%x", sha256.Sum256([]byte(str)))
+	default: // StringHashAlgorithmSHA512
+		h = fmt.Sprintf("%x", sha512.Sum512([]byte(str)))
+	}
+	if s.Hash.Length != nil && int(*s.Hash.Length) < len(h) {
+		return h[:*s.Hash.Length]
+	}
+	return h
+}
+
+func (s *StringTransform) join(input interface{}) (interface{}, error) {
+	elems, ok := input.([]interface{})
+	if !ok {
+		return nil, errors.New(errStringInputNonArray)
+	}
+	parts := make([]string, len(elems))
+	for i := range elems {
+		parts[i] = fmt.Sprint(elems[i])
+	}
+	return strings.Join(parts, s.Join.Separator), nil
 }
 
 // The list of supported ConvertTransform input and output types.
@@ -773,6 +2040,7 @@ const (
 	ConnectionDetailTypeFromConnectionSecretKey ConnectionDetailType = "FromConnectionSecretKey"
 	ConnectionDetailTypeFromFieldPath           ConnectionDetailType = "FromFieldPath"
 	ConnectionDetailTypeFromValue               ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeCombine                 ConnectionDetailType = "Combine"
 )
 
 // ConnectionDetail includes the information about the propagation of the connection
@@ -789,7 +2057,7 @@ type ConnectionDetail struct {
 	// ConnectionDetail object. If the type is omitted Crossplane will attempt
 	// to infer it based on which other fields were specified.
 	// +optional
-	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue
+	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue;Combine
 	Type *ConnectionDetailType `json:"type,omitempty"`
 
 	// FromConnectionSecretKey is the key that will be used to fetch the value
@@ -810,11 +2078,29 @@ type ConnectionDetail struct {
 	// FromConnectionSecretKey when set.
 	// +optional
 	Value *string `json:"value,omitempty"`
+
+	// Combine the values of several connection secret keys or field paths of
+	// the composed resource into a single value. Name must be specified if
+	// Combine is specified.
+	// +optional
+	Combine *Combine `json:"combine,omitempty"`
+
+	// Transforms are the list of functions that are used as a FIFO pipe for
+	// the input to be transformed before it is propagated to the connection
+	// secret of the composition instance. Values read from a connection secret
+	// are supplied to the first transform as strings.
+	// +optional
+	Transforms []Transform `json:"transforms,omitempty"`
 }
 
 // CompositionStatus shows the observed state of the composition.
 type CompositionStatus struct {
 	xpv1.ConditionedStatus `json:",inline"`
+
+	// Usage is the number of composite resources that reference this
+	// Composition. A Composition cannot be deleted while it is in use.
+	// +optional
+	Usage int64 `json:"usage,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -824,6 +2110,7 @@ type CompositionStatus struct {
 
 // Composition defines the group of resources to be created when a compatible
 // type is created with reference to the composition.
+// +kubebuilder:printcolumn:name="USAGE",type="integer",JSONPath=".status.usage"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories=crossplane
@@ -17,12 +17,14 @@ limitations under the License.
 package v1
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
@@ -142,9 +144,9 @@ func TestPatchTypeReplacement(t *testing.T) {
 									Transforms: []Transform{{
 										Type: TransformTypeMap,
 										Map: &MapTransform{
-											Pairs: map[string]string{
-												"k-1": "v-1",
-												"k-2": "v-2",
+											Pairs: map[string]extv1.JSON{
+												"k-1": {Raw: []byte(`"v-1"`)},
+												"k-2": {Raw: []byte(`"v-2"`)},
 											},
 										},
 									}},
@@ -195,9 +197,9 @@ func TestPatchTypeReplacement(t *testing.T) {
 								Transforms: []Transform{{
 									Type: TransformTypeMap,
 									Map: &MapTransform{
-										Pairs: map[string]string{
-											"k-1": "v-1",
-											"k-2": "v-2",
+										Pairs: map[string]extv1.JSON{
+											"k-1": {Raw: []byte(`"v-1"`)},
+											"k-2": {Raw: []byte(`"v-2"`)},
 										},
 									},
 								}},
@@ -248,9 +250,20 @@ func TestPatchTypeReplacement(t *testing.T) {
 }
 
 func TestMapResolve(t *testing.T) {
+	pairs := map[string]extv1.JSON{
+		"ola":   {Raw: []byte(`"voila"`)},
+		"true":  {Raw: []byte(`"yes"`)},
+		"5":     {Raw: []byte(`5`)},
+		"2.5":   {Raw: []byte(`2.5`)},
+		"cool":  {Raw: []byte(`{"very":"cool","count":2}`)},
+		"list":  {Raw: []byte(`["a","b"]`)},
+		"false": {Raw: []byte(`false`)},
+	}
+
 	type args struct {
-		m map[string]string
-		i interface{}
+		mt MapTransform
+		f  *MapTransformFallback
+		i  interface{}
 	}
 	type want struct {
 		o   interface{}
@@ -261,12 +274,12 @@ func TestMapResolve(t *testing.T) {
 		args
 		want
 	}{
-		"NonStringInput": {
+		"UnsupportedInput": {
 			args: args{
-				i: 5,
+				i: []interface{}{"ola"},
 			},
 			want: want{
-				err: errors.Errorf(errFmtMapTypeNotSupported, "int"),
+				err: errors.Errorf(errFmtMapTypeNotSupported, "[]interface {}"),
 			},
 		},
 		"KeyNotFound": {
@@ -279,17 +292,128 @@ func TestMapResolve(t *testing.T) {
 		},
 		"Success": {
 			args: args{
-				m: map[string]string{"ola": "voila"},
-				i: "ola",
+				mt: MapTransform{Pairs: pairs},
+				i:  "ola",
 			},
 			want: want{
 				o: "voila",
 			},
 		},
+		"BoolInput": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				i:  true,
+			},
+			want: want{
+				o: "yes",
+			},
+		},
+		"IntInput": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				i:  5,
+			},
+			want: want{
+				o: int64(5),
+			},
+		},
+		"Int64Input": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				i:  int64(5),
+			},
+			want: want{
+				o: int64(5),
+			},
+		},
+		"Float64Input": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				i:  2.5,
+			},
+			want: want{
+				o: 2.5,
+			},
+		},
+		"ObjectValue": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				i:  "cool",
+			},
+			want: want{
+				o: map[string]interface{}{"very": "cool", "count": int64(2)},
+			},
+		},
+		"ListValue": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				i:  "list",
+			},
+			want: want{
+				o: []interface{}{"a", "b"},
+			},
+		},
+		"FalseValue": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				i:  false,
+			},
+			want: want{
+				o: false,
+			},
+		},
+		"FallbackValue": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				f:  &MapTransformFallback{Value: &extv1.JSON{Raw: []byte(`"default"`)}},
+				i:  "nope",
+			},
+			want: want{
+				o: "default",
+			},
+		},
+		"FallbackToValueWithoutValue": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				f:  &MapTransformFallback{To: MapTransformFallbackToValue},
+				i:  int64(42),
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapNotFound, "42"),
+			},
+		},
+		"FallbackToInput": {
+			args: args{
+				mt: MapTransform{Pairs: pairs},
+				f:  &MapTransformFallback{To: MapTransformFallbackToInput},
+				i:  int64(42),
+			},
+			want: want{
+				o: int64(42),
+			},
+		},
+		"FallbackConflict": {
+			args: args{
+				f: &MapTransformFallback{To: MapTransformFallbackToInput, Value: &extv1.JSON{Raw: []byte(`"default"`)}},
+				i: "nope",
+			},
+			want: want{
+				err: errors.New(errMapFallbackConflict),
+			},
+		},
+		"FallbackToUnsupported": {
+			args: args{
+				f: &MapTransformFallback{To: "Nowhere"},
+				i: "nope",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapFallbackToNotSupported, "Nowhere"),
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&MapTransform{Pairs: tc.m}).Resolve(tc.i)
+			got, err := tc.mt.ResolveWithFallback(tc.i, tc.f)
 
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
 				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
@@ -301,12 +425,118 @@ func TestMapResolve(t *testing.T) {
 	}
 }
 
+func TestMapTransformJSON(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		json   string
+		mt     MapTransform
+	}{
+		"Pairs": {
+			reason: "A map of pairs should round trip as a map of pairs.",
+			json:   `{"a":"b","c":{"d":1}}`,
+			mt: MapTransform{Pairs: map[string]extv1.JSON{
+				"a": {Raw: []byte(`"b"`)},
+				"c": {Raw: []byte(`{"d":1}`)},
+			}},
+		},
+		"PairsWithPairsKey": {
+			reason: "A map of pairs with a string value keyed 'pairs' should round trip as a map of pairs.",
+			json:   `{"fallbackTo":"x","pairs":"b"}`,
+			mt: MapTransform{Pairs: map[string]extv1.JSON{
+				"pairs":      {Raw: []byte(`"b"`)},
+				"fallbackTo": {Raw: []byte(`"x"`)},
+			}},
+		},
+		"PairsWithFallbackKeys": {
+			reason: "A map of pairs with keys that resemble fallback configuration should round trip as a map of pairs.",
+			json:   `{"fallbackTo":"Input","fallbackValue":"x","pairs":{"a":"b"}}`,
+			mt: MapTransform{Pairs: map[string]extv1.JSON{
+				"pairs":         {Raw: []byte(`{"a":"b"}`)},
+				"fallbackTo":    {Raw: []byte(`"Input"`)},
+				"fallbackValue": {Raw: []byte(`"x"`)},
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := MapTransform{}
+			if err := json.Unmarshal([]byte(tc.json), &got); err != nil {
+				t.Fatalf("\n%s\njson.Unmarshal(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.mt, got); diff != "" {
+				t.Errorf("\n%s\njson.Unmarshal(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			b, err := json.Marshal(tc.mt)
+			if err != nil {
+				t.Fatalf("\n%s\njson.Marshal(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.json, string(b)); diff != "" {
+				t.Errorf("\n%s\njson.Marshal(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestTransformMapFallback(t *testing.T) {
+	fallback := &MapTransformFallback{To: MapTransformFallbackToInput}
+
+	cases := map[string]struct {
+		reason string
+		t      Transform
+		i      interface{}
+		o      interface{}
+		err    error
+	}{
+		"FallbackOnNonMapTransform": {
+			reason: "A map fallback should be rejected when the transform is not a map transform.",
+			t:      Transform{Type: TransformTypeConvert, Convert: &ConvertTransform{ToType: "string"}, MapFallback: fallback},
+			i:      "a",
+			err:    errors.Errorf(errFmtMapFallbackType, TransformTypeConvert),
+		},
+		"FallbackToInput": {
+			reason: "A map transform should use its map fallback when its input matches no pair.",
+			t:      Transform{Type: TransformTypeMap, Map: &MapTransform{Pairs: map[string]extv1.JSON{"a": {Raw: []byte(`"b"`)}}}, MapFallback: fallback},
+			i:      "c",
+			o:      "c",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.t.Validate()
+			if err == nil {
+				var o interface{}
+				o, err = tc.t.Transform(tc.i)
+				if diff := cmp.Diff(tc.o, o); diff != "" {
+					t.Errorf("\n%s\nTransform(...): -want, +got:\n%s", tc.reason, diff)
+				}
+			}
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nTransform(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestMathResolve(t *testing.T) {
-	m := int64(2)
+	m := MathOperand("2")
+	zero := MathOperand("0")
+	ten := MathOperand("10")
+	half := MathOperand("0.5")
+	invalid := MathOperand("ten")
+	places := int64(2)
+	noPlaces := int64(0)
+	// JSON numbers like 2.0 are read back from the API server as 2.
+	two := MathOperand("2.0")
+	asFloat := MathResultTypeFloat
+	asInteger := MathResultTypeInteger
+	unknown := MathResultType("Complex")
 
 	type args struct {
-		multiplier *int64
-		i          interface{}
+		mt MathTransform
+		i  interface{}
 	}
 	type want struct {
 		o   interface{}
@@ -322,13 +552,58 @@ func TestMathResolve(t *testing.T) {
 				i: 25,
 			},
 			want: want{
-				err: errors.New(errMathNoMultiplier),
+				err: errors.New(errMathNoOperation),
+			},
+		},
+		"TooManyOperations": {
+			args: args{
+				mt: MathTransform{Multiply: &m, Add: &m},
+				i:  25,
+			},
+			want: want{
+				err: errors.New(errMathTooManyOperations),
+			},
+		},
+		"DivideByZero": {
+			args: args{
+				mt: MathTransform{Divide: &zero},
+				i:  25,
+			},
+			want: want{
+				err: errors.New(errMathDivideByZero),
+			},
+		},
+		"ClampNoBounds": {
+			args: args{
+				mt: MathTransform{Clamp: &MathClamp{}},
+				i:  25,
+			},
+			want: want{
+				err: errors.New(errMathClampNoBounds),
+			},
+		},
+		"ClampInvalidBounds": {
+			args: args{
+				mt: MathTransform{Clamp: &MathClamp{Min: &ten, Max: &m}},
+				i:  25,
+			},
+			want: want{
+				err: errors.New(errMathClampBounds),
+			},
+		},
+		"InvalidOperand": {
+			args: args{
+				mt: MathTransform{Add: &invalid},
+				i:  25,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathInvalidOperand, "ten"),
 			},
 		},
 		"NonNumberInput": {
 			args: args{
-				multiplier: &m,
-				i:          "ola",
+				mt: MathTransform{Multiply: &m},
+				i:  "ola",
 			},
 			want: want{
 				err: errors.New(errMathInputNonNumber),
@@ -336,26 +611,197 @@ func TestMathResolve(t *testing.T) {
 		},
 		"Success": {
 			args: args{
-				multiplier: &m,
-				i:          3,
+				mt: MathTransform{Multiply: &m},
+				i:  3,
 			},
 			want: want{
-				o: 3 * m,
+				o: int64(6),
 			},
 		},
 		"SuccessInt64": {
 			args: args{
-				multiplier: &m,
-				i:          int64(3),
+				mt: MathTransform{Multiply: &m},
+				i:  int64(3),
+			},
+			want: want{
+				o: int64(6),
+			},
+		},
+		"MultiplyFloat64": {
+			args: args{
+				mt: MathTransform{Multiply: &m},
+				i:  1.5,
+			},
+			want: want{
+				o: 3.0,
+			},
+		},
+		"Add": {
+			args: args{
+				mt: MathTransform{Add: &ten},
+				i:  int64(8080),
+			},
+			want: want{
+				o: int64(8090),
+			},
+		},
+		"AddFloat64": {
+			args: args{
+				mt: MathTransform{Add: &ten},
+				i:  0.5,
+			},
+			want: want{
+				o: 10.5,
+			},
+		},
+		"AddFloatOperandToInteger": {
+			args: args{
+				mt: MathTransform{Add: &half},
+				i:  int64(1),
+			},
+			want: want{
+				o: 1.5,
+			},
+		},
+		"MultiplyIntegerByFloatOperand": {
+			args: args{
+				mt: MathTransform{Multiply: &half},
+				i:  3,
+			},
+			want: want{
+				o: 1.5,
+			},
+		},
+		"Subtract": {
+			args: args{
+				mt: MathTransform{Subtract: &ten},
+				i:  3,
+			},
+			want: want{
+				o: int64(-7),
+			},
+		},
+		"DivideTruncatesIntegers": {
+			args: args{
+				mt: MathTransform{Divide: &m},
+				i:  int64(7),
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"DivideFloat64": {
+			args: args{
+				mt: MathTransform{Divide: &m},
+				i:  7.0,
+			},
+			want: want{
+				o: 3.5,
+			},
+		},
+		"ClampMin": {
+			args: args{
+				mt: MathTransform{Clamp: &MathClamp{Min: &m, Max: &ten}},
+				i:  int64(1),
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"ClampMax": {
+			args: args{
+				mt: MathTransform{Clamp: &MathClamp{Max: &ten}},
+				i:  42.5,
 			},
 			want: want{
-				o: 3 * m,
+				o: 10.0,
+			},
+		},
+		"ClampWithinBounds": {
+			args: args{
+				mt: MathTransform{Clamp: &MathClamp{Min: &m, Max: &ten}},
+				i:  5,
+			},
+			want: want{
+				o: int64(5),
+			},
+		},
+		"RoundFloat64": {
+			args: args{
+				mt: MathTransform{Round: &noPlaces},
+				i:  2.5,
+			},
+			want: want{
+				o: 3.0,
+			},
+		},
+		"RoundFloat64Precision": {
+			args: args{
+				mt: MathTransform{Round: &places},
+				i:  3.14159,
+			},
+			want: want{
+				o: 3.14,
+			},
+		},
+		"DivideByIntegralFloatOperand": {
+			args: args{
+				mt: MathTransform{Divide: &two},
+				i:  int64(3),
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"DivideResultTypeFloat": {
+			args: args{
+				mt: MathTransform{Divide: &two, ResultType: &asFloat},
+				i:  int64(3),
+			},
+			want: want{
+				o: 1.5,
+			},
+		},
+		"MultiplyResultTypeInteger": {
+			args: args{
+				mt: MathTransform{Multiply: &half, ResultType: &asInteger},
+				i:  int64(3),
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"FloatInputResultTypeInteger": {
+			args: args{
+				mt: MathTransform{Add: &ten, ResultType: &asInteger},
+				i:  2.7,
+			},
+			want: want{
+				o: int64(12),
+			},
+		},
+		"InvalidResultType": {
+			args: args{
+				mt: MathTransform{Add: &ten, ResultType: &unknown},
+				i:  2,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathResultType, unknown),
+			},
+		},
+		"RoundInteger": {
+			args: args{
+				mt: MathTransform{Round: &noPlaces},
+				i:  42,
+			},
+			want: want{
+				o: int64(42),
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&MathTransform{Multiply: tc.multiplier}).Resolve(tc.i)
+			got, err := tc.mt.Resolve(tc.i)
 
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
 				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
@@ -368,10 +814,16 @@ func TestMathResolve(t *testing.T) {
 }
 
 func TestStringResolve(t *testing.T) {
+	upper := StringConversionTypeToUpper
+	lower := StringConversionTypeToLower
+	toBase64 := StringConversionTypeToBase64
+	fromBase64 := StringConversionTypeFromBase64
+	unknown := StringConversionType("ToKlingon")
+	one := 1
 
 	type args struct {
-		fmts string
-		i    interface{}
+		st StringTransform
+		i  interface{}
 	}
 	type want struct {
 		o   interface{}
@@ -384,26 +836,365 @@ func TestStringResolve(t *testing.T) {
 	}{
 		"FmtString": {
 			args: args{
-				fmts: "verycool%s",
-				i:    "thing",
+				st: StringTransform{Format: "verycool%s"},
+				i:  "thing",
+			},
+			want: want{
+				o: "verycoolthing",
+			},
+		},
+		"FmtInteger": {
+			args: args{
+				st: StringTransform{Format: "the largest %d"},
+				i:  8,
+			},
+			want: want{
+				o: "the largest 8",
+			},
+		},
+		"FmtExplicitType": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeFormat, Format: "%s-suffix"},
+				i:  "prefix",
+			},
+			want: want{
+				o: "prefix-suffix",
+			},
+		},
+		"FmtMissing": {
+			args: args{
+				st: StringTransform{},
+				i:  "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringTransformConfigMissing, StringTransformTypeFormat),
+			},
+		},
+		"UnknownType": {
+			args: args{
+				st: StringTransform{Type: "Klingon"},
+				i:  "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringTransformTypeNotSupported, "Klingon"),
+			},
+		},
+		"ConvertMissing": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert},
+				i:  "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringTransformConfigMissing, StringTransformTypeConvert),
+			},
+		},
+		"ConvertUnknown": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &unknown},
+				i:  "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringConversionNotSupported, unknown),
+			},
+		},
+		"ConvertNonString": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &upper},
+				i:  8,
+			},
+			want: want{
+				err: errors.New(errStringInputNonString),
+			},
+		},
+		"ConvertToUpper": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &upper},
+				i:  "Cool",
+			},
+			want: want{
+				o: "COOL",
+			},
+		},
+		"ConvertToLower": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &lower},
+				i:  "Cool",
+			},
+			want: want{
+				o: "cool",
+			},
+		},
+		"ConvertToBase64": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &toBase64},
+				i:  "cool",
+			},
+			want: want{
+				o: "Y29vbA==",
+			},
+		},
+		"ConvertFromBase64": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &fromBase64},
+				i:  "Y29vbA==",
+			},
+			want: want{
+				o: "cool",
+			},
+		},
+		"ConvertFromInvalidBase64": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &fromBase64},
+				i:  "%%%",
+			},
+			want: want{
+				o:   "",
+				err: errors.Wrap(errors.New("illegal base64 data at input byte 0"), errStringDecodeBase64),
+			},
+		},
+		"TrimPrefix": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeTrimPrefix, Trim: pointer.StringPtr("xp-")},
+				i:  "xp-cool",
+			},
+			want: want{
+				o: "cool",
+			},
+		},
+		"TrimSuffix": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeTrimSuffix, Trim: pointer.StringPtr("-xp")},
+				i:  "cool-xp",
+			},
+			want: want{
+				o: "cool",
+			},
+		},
+		"TrimMissing": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeTrimSuffix},
+				i:  "cool-xp",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringTransformConfigMissing, StringTransformTypeTrimSuffix),
+			},
+		},
+		"RegexpInvalid": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeRegexp, Regexp: &StringTransformRegexp{Match: "("}},
+				i:  "cool",
+			},
+			want: want{
+				err: errors.Wrap(errors.New("error parsing regexp: missing closing ): `(`"), errStringRegexpCompile),
+			},
+		},
+		"RegexpNoMatch": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeRegexp, Regexp: &StringTransformRegexp{Match: "^[0-9]+$"}},
+				i:  "cool",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringRegexpNoMatch, "^[0-9]+$"),
+			},
+		},
+		"RegexpNoGroup": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeRegexp, Regexp: &StringTransformRegexp{Match: "cool", Group: &one}},
+				i:  "cool",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringRegexpNoGroup, "cool", 1),
+			},
+		},
+		"RegexpMatch": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeRegexp, Regexp: &StringTransformRegexp{Match: "[a-z]+-[0-9]+"}},
+				i:  "arn:cool-42:thing",
+			},
+			want: want{
+				o: "cool-42",
+			},
+		},
+		"RegexpGroup": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeRegexp, Regexp: &StringTransformRegexp{Match: "[a-z]+-([0-9]+)", Group: &one}},
+				i:  "arn:cool-42:thing",
+			},
+			want: want{
+				o: "42",
+			},
+		},
+		"HashUnknown": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeHash, Hash: &StringTransformHash{Algorithm: "MD5"}},
+				i:  "cool",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringHashNotSupported, "MD5"),
+			},
+		},
+		"HashNegativeLength": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeHash, Hash: &StringTransformHash{Algorithm: StringHashAlgorithmSHA256, Length: pointer.Int64Ptr(-1)}},
+				i:  "cool",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringHashLength, -1),
+			},
+		},
+		"HashSHA1": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeHash, Hash: &StringTransformHash{Algorithm: StringHashAlgorithmSHA1}},
+				i:  "cool",
+			},
+			want: want{
+				o: "85d8d76ba15bde3ef1602f477f32fd64e32fea5a",
+			},
+		},
+		"HashSHA256Truncated": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeHash, Hash: &StringTransformHash{Algorithm: StringHashAlgorithmSHA256, Length: pointer.Int64Ptr(8)}},
+				i:  "cool",
+			},
+			want: want{
+				o: "c34045c1",
+			},
+		},
+		"HashSHA512": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeHash, Hash: &StringTransformHash{Algorithm: StringHashAlgorithmSHA512, Length: pointer.Int64Ptr(16)}},
+				i:  "cool",
+			},
+			want: want{
+				o: "03a144604e0a3ce0",
+			},
+		},
+		"Split": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeSplit, Split: &StringTransformSplit{Separator: ","}},
+				i:  "a,b,c",
+			},
+			want: want{
+				o: []interface{}{"a", "b", "c"},
+			},
+		},
+		"JoinNonArray": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeJoin, Join: &StringTransformJoin{Separator: ","}},
+				i:  "a,b,c",
+			},
+			want: want{
+				err: errors.New(errStringInputNonArray),
+			},
+		},
+		"Join": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeJoin, Join: &StringTransformJoin{Separator: "-"}},
+				i:  []interface{}{"a", int64(1), true},
+			},
+			want: want{
+				o: "a-1-true",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.st.Resolve(tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompileRegexp(t *testing.T) {
+	first, err := compileRegexp("^cool-(.+)$")
+	if err != nil {
+		t.Fatalf("compileRegexp(...): %v", err)
+	}
+	second, err := compileRegexp("^cool-(.+)$")
+	if err != nil {
+		t.Fatalf("compileRegexp(...): %v", err)
+	}
+	if first != second {
+		t.Errorf("compileRegexp(...): want the cached regexp, got a newly compiled regexp")
+	}
+
+	if _, err := compileRegexp("("); err == nil {
+		t.Errorf("compileRegexp(...): want an error compiling an invalid pattern, got nil")
+	}
+	if _, ok := regexps.Load("("); ok {
+		t.Errorf("compileRegexp(...): want invalid patterns not to be cached")
+	}
+}
+
+func TestConvertResolve(t *testing.T) {
+	type args struct {
+		ot string
+		i  interface{}
+	}
+	type want struct {
+		o   interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"StringToBool": {
+			args: args{
+				i:  "true",
+				ot: ConvertTransformTypeBool,
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"SameTypeNoOp": {
+			args: args{
+				i:  true,
+				ot: ConvertTransformTypeBool,
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"IntAliasToInt64": {
+			args: args{
+				i:  int64(1),
+				ot: ConvertTransformTypeInt,
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"InputTypeNotSupported": {
+			args: args{
+				i:  []int{64},
+				ot: ConvertTransformTypeString,
 			},
 			want: want{
-				o: "verycoolthing",
+				err: errors.Errorf(errFmtConvertInputTypeNotSupported, reflect.TypeOf([]int{}).Kind().String()),
 			},
 		},
-		"FmtInteger": {
+		"ConversionPairNotSupported": {
 			args: args{
-				fmts: "the largest %d",
-				i:    8,
+				i:  "[64]",
+				ot: "[]int",
 			},
 			want: want{
-				o: "the largest 8",
+				err: errors.Errorf(errFmtConversionPairNotSupported, "string", "[]int"),
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&StringTransform{Format: tc.fmts}).Resolve(tc.i)
+			got, err := (&ConvertTransform{ToType: tc.args.ot}).Resolve(tc.i)
 
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
 				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
@@ -415,10 +1206,10 @@ func TestStringResolve(t *testing.T) {
 	}
 }
 
-func TestConvertResolve(t *testing.T) {
+func TestCombine(t *testing.T) {
 	type args struct {
-		ot string
-		i  interface{}
+		c    Combine
+		vars []interface{}
 	}
 	type want struct {
 		o   interface{}
@@ -426,64 +1217,138 @@ func TestConvertResolve(t *testing.T) {
 	}
 
 	cases := map[string]struct {
+		reason string
 		args
 		want
 	}{
-		"StringToBool": {
+		"UnknownStrategy": {
+			reason: "We should return an error for an unknown strategy.",
 			args: args{
-				i:  "true",
-				ot: ConvertTransformTypeBool,
+				c: Combine{Strategy: "nope"},
 			},
 			want: want{
-				o: true,
+				err: errors.Errorf(errFmtCombineStrategyNotSupported, "nope"),
 			},
 		},
-		"SameTypeNoOp": {
+		"String": {
+			reason: "The string strategy should format its variables.",
 			args: args{
-				i:  true,
-				ot: ConvertTransformTypeBool,
+				c:    Combine{Strategy: CombineStrategyString, String: &StringCombine{Format: "%s-%d"}},
+				vars: []interface{}{"a", int64(1)},
 			},
 			want: want{
-				o: true,
+				o: "a-1",
 			},
 		},
-		"IntAliasToInt64": {
+		"List": {
+			reason: "The list strategy should produce a list of found variables, in order.",
 			args: args{
-				i:  int64(1),
-				ot: ConvertTransformTypeInt,
+				c:    Combine{Strategy: CombineStrategyList},
+				vars: []interface{}{"a", nil, int64(1)},
 			},
 			want: want{
-				o: int64(1),
+				o: []interface{}{"a", int64(1)},
 			},
 		},
-		"InputTypeNotSupported": {
+		"Object": {
+			reason: "The object strategy should produce an object of found variables, keyed by name.",
 			args: args{
-				i:  []int{64},
-				ot: ConvertTransformTypeString,
+				c: Combine{
+					Strategy:  CombineStrategyObject,
+					Variables: []CombineVariable{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+				},
+				vars: []interface{}{"a", nil, int64(1)},
 			},
 			want: want{
-				err: errors.Errorf(errFmtConvertInputTypeNotSupported, reflect.TypeOf([]int{}).Kind().String()),
+				o: map[string]interface{}{"a": "a", "c": int64(1)},
 			},
 		},
-		"ConversionPairNotSupported": {
+		"ObjectMissingName": {
+			reason: "The object strategy should return an error if a variable has no name.",
 			args: args{
-				i:  "[64]",
-				ot: "[]int",
+				c: Combine{
+					Strategy:  CombineStrategyObject,
+					Variables: []CombineVariable{{Name: "a"}, {}},
+				},
+				vars: []interface{}{"a", "b"},
 			},
 			want: want{
-				err: errors.Errorf(errFmtConversionPairNotSupported, "string", "[]int"),
+				err: errors.Wrapf(errors.Errorf(errFmtCombineVariableName, 1), errFmtCombineStrategyFailed, CombineStrategyObject),
+			},
+		},
+		"ObjectDuplicateName": {
+			reason: "The object strategy should return an error if variable names are not unique.",
+			args: args{
+				c: Combine{
+					Strategy:  CombineStrategyObject,
+					Variables: []CombineVariable{{Name: "a"}, {Name: "a"}},
+				},
+				vars: []interface{}{"a", "b"},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtCombineVariableDuplicate, "a"), errFmtCombineStrategyFailed, CombineStrategyObject),
+			},
+		},
+		"SumInt": {
+			reason: "The sum strategy should produce an integer if all variables are integers.",
+			args: args{
+				c:    Combine{Strategy: CombineStrategySum},
+				vars: []interface{}{int64(2), nil, int64(3)},
+			},
+			want: want{
+				o: int64(5),
+			},
+		},
+		"SumFloat": {
+			reason: "The sum strategy should produce a float if any variable is a float.",
+			args: args{
+				c:    Combine{Strategy: CombineStrategySum},
+				vars: []interface{}{int64(2), 0.5},
+			},
+			want: want{
+				o: 2.5,
+			},
+		},
+		"SumNonNumber": {
+			reason: "The sum strategy should return an error if any variable is not a number.",
+			args: args{
+				c:    Combine{Strategy: CombineStrategySum},
+				vars: []interface{}{int64(2), "3"},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtCombineVariableNonNumber, 1), errFmtCombineStrategyFailed, CombineStrategySum),
+			},
+		},
+		"Coalesce": {
+			reason: "The coalesce strategy should produce the first variable that is not empty.",
+			args: args{
+				c:    Combine{Strategy: CombineStrategyCoalesce},
+				vars: []interface{}{nil, "", []interface{}{}, "a", "b"},
+			},
+			want: want{
+				o: "a",
+			},
+		},
+		"CoalesceAllEmpty": {
+			reason: "The coalesce strategy should produce nil if all variables are empty.",
+			args: args{
+				c:    Combine{Strategy: CombineStrategyCoalesce},
+				vars: []interface{}{nil, ""},
+			},
+			want: want{
+				o: nil,
 			},
 		},
 	}
+
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&ConvertTransform{ToType: tc.args.ot}).Resolve(tc.i)
-
+			got, err := tc.args.c.Combine(tc.args.vars)
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+				t.Errorf("\n%s\nCombine(...): -want, +got:\n%s", tc.reason, diff)
 			}
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+				t.Errorf("\n%s\nCombine(...): -want error, +got error:\n%s", tc.reason, diff)
 			}
 		})
 	}
@@ -999,6 +1864,78 @@ func TestPatchApply(t *testing.T) {
 				err: nil,
 			},
 		},
+		"OptionalVariableMissingCoalesce": {
+			reason: "Should omit missing optional variables rather than abort the patch when using a strategy other than string",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source2"},
+							{FromFieldPath: "objectMeta.labels.source1"},
+						},
+						Strategy: CombineStrategyCoalesce,
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"source1": "foo"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"destination": "foo"},
+					},
+				},
+			},
+		},
+		"AllOptionalVariablesMissing": {
+			reason: "Should return no error and not apply patch if all optional variables are missing",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+						},
+						Strategy: CombineStrategyList,
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+		},
 		"ValidCombineFromComposite": {
 			reason: "Should correctly apply a CombineFromComposite patch with valid settings",
 			args: args{
@@ -1055,6 +1992,75 @@ func TestPatchApply(t *testing.T) {
 				err: nil,
 			},
 		},
+		"MergeCompositeFieldPathPatch": {
+			reason: "Should merge a patched object with the existing object when merge options are specified",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					Policy:        &PatchPolicy{MergeOptions: &MergeOptions{}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"Test": "blah"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"Default": "yes", "Test": "default"},
+					},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"Default": "yes", "Test": "blah"},
+					},
+				},
+			},
+		},
+		"ToCompositeFieldPathIgnoresMergeOptions": {
+			reason: "Should replace rather than merge the composite's existing value when patching to the composite",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeToCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					Policy:        &PatchPolicy{MergeOptions: &MergeOptions{}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"Default": "yes", "Test": "blah"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"Test": "default"},
+					},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"Test": "default"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"Test": "default"},
+					},
+				},
+			},
+		},
 		"ValidCombineToComposite": {
 			reason: "Should correctly apply a CombineToComposite patch with valid settings",
 			args: args{
@@ -1134,6 +2140,154 @@ func TestPatchApply(t *testing.T) {
 	}
 }
 
+func TestPatchValidate(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		patch  Patch
+		want   error
+	}{
+		"FromCompositeFieldPath": {
+			reason: "A FromCompositeFieldPath patch that specifies a FromFieldPath should be valid.",
+			patch:  Patch{Type: PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.StringPtr("spec")},
+		},
+		"FromCompositeFieldPathMissingFromFieldPath": {
+			reason: "A FromCompositeFieldPath patch must specify a FromFieldPath.",
+			patch:  Patch{Type: PatchTypeFromCompositeFieldPath},
+			want:   errors.Errorf(errFmtRequiredField, "FromFieldPath", PatchTypeFromCompositeFieldPath),
+		},
+		"FromComposedFieldPathMissingFromResourceName": {
+			reason: "A FromComposedFieldPath patch must specify a FromResourceName.",
+			patch:  Patch{Type: PatchTypeFromComposedFieldPath, FromFieldPath: pointer.StringPtr("spec")},
+			want:   errors.Errorf(errFmtRequiredField, "FromResourceName", PatchTypeFromComposedFieldPath),
+		},
+		"CombineMissingToFieldPath": {
+			reason: "A Combine patch must specify a ToFieldPath.",
+			patch:  Patch{Type: PatchTypeCombineFromComposite, Combine: &Combine{}},
+			want:   errors.Errorf(errFmtRequiredField, "ToFieldPath", PatchTypeCombineFromComposite),
+		},
+		"CombineMissingVariables": {
+			reason: "A Combine patch must specify at least one variable.",
+			patch:  Patch{Type: PatchTypeCombineToComposite, Combine: &Combine{}, ToFieldPath: pointer.StringPtr("spec")},
+			want:   errors.New(errCombineRequiresVariables),
+		},
+		"ToCompositeFieldPathMergeOptions": {
+			reason: "A ToCompositeFieldPath patch must not specify merge options.",
+			patch: Patch{
+				Type:          PatchTypeToCompositeFieldPath,
+				FromFieldPath: pointer.StringPtr("spec"),
+				Policy:        &PatchPolicy{MergeOptions: &MergeOptions{}},
+			},
+			want: errors.Errorf(errFmtMergeOptionsNotSupported, PatchTypeToCompositeFieldPath),
+		},
+		"CombineToCompositeMergeOptions": {
+			reason: "A CombineToComposite patch must not specify merge options.",
+			patch: Patch{
+				Type:        PatchTypeCombineToComposite,
+				Combine:     &Combine{Variables: []CombineVariable{{FromFieldPath: "spec"}}},
+				ToFieldPath: pointer.StringPtr("spec"),
+				Policy:      &PatchPolicy{MergeOptions: &MergeOptions{}},
+			},
+			want: errors.Errorf(errFmtMergeOptionsNotSupported, PatchTypeCombineToComposite),
+		},
+		"PatchSetMissingName": {
+			reason: "A PatchSet patch must specify a PatchSetName.",
+			patch:  Patch{Type: PatchTypePatchSet},
+			want:   errors.Errorf(errFmtRequiredField, "PatchSetName", PatchTypePatchSet),
+		},
+		"UnknownType": {
+			reason: "Patches must be of a supported type.",
+			patch:  Patch{Type: "Wat"},
+			want:   errors.Errorf(errFmtInvalidPatchType, "Wat"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.patch.Validate()
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMergeOptionsMerge(t *testing.T) {
+	yes := true
+
+	type args struct {
+		mo       MergeOptions
+		existing interface{}
+		patched  interface{}
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want interface{}
+	}{
+		"Scalar": {
+			reason: "A patched scalar should replace the existing value.",
+			args: args{
+				existing: "a",
+				patched:  "b",
+			},
+			want: "b",
+		},
+		"MergeMaps": {
+			reason: "Patched objects should be merged recursively, with patched values taking precedence.",
+			args: args{
+				existing: map[string]interface{}{"a": "a", "b": "b", "nested": map[string]interface{}{"c": "c"}},
+				patched:  map[string]interface{}{"b": "B", "nested": map[string]interface{}{"d": "D"}},
+			},
+			want: map[string]interface{}{"a": "a", "b": "B", "nested": map[string]interface{}{"c": "c", "d": "D"}},
+		},
+		"KeepMapValues": {
+			reason: "Existing values should be preserved when KeepMapValues is true.",
+			args: args{
+				mo:       MergeOptions{KeepMapValues: &yes},
+				existing: map[string]interface{}{"a": "a", "b": "b"},
+				patched:  map[string]interface{}{"b": "B", "c": "C"},
+			},
+			want: map[string]interface{}{"a": "a", "b": "b", "c": "C"},
+		},
+		"ReplaceSlice": {
+			reason: "Patched arrays should replace existing arrays by default.",
+			args: args{
+				existing: []interface{}{"a"},
+				patched:  []interface{}{"b"},
+			},
+			want: []interface{}{"b"},
+		},
+		"AppendSlice": {
+			reason: "Patched arrays should be appended to existing arrays when AppendSlice is true.",
+			args: args{
+				mo:       MergeOptions{AppendSlice: &yes},
+				existing: []interface{}{"a", "b"},
+				patched:  []interface{}{"b", "c"},
+			},
+			want: []interface{}{"a", "b", "b", "c"},
+		},
+		"AppendAndDeduplicateSlice": {
+			reason: "Duplicate elements should be removed when DeduplicateSlice is true.",
+			args: args{
+				mo:       MergeOptions{AppendSlice: &yes, DeduplicateSlice: &yes},
+				existing: []interface{}{"a", "b"},
+				patched:  []interface{}{"b", "c", map[string]interface{}{"d": "d"}, map[string]interface{}{"d": "d"}},
+			},
+			want: []interface{}{"a", "b", "c", map[string]interface{}{"d": "d"}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.args.mo.Merge(tc.args.existing, tc.args.patched)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nMerge(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestOptionalFieldPathNotFound(t *testing.T) {
 	errBoom := errors.New("boom")
 	errNotFound := func() error {
@@ -1211,3 +2365,48 @@ func TestOptionalFieldPathNotFound(t *testing.T) {
 		})
 	}
 }
+
+func TestMathOperandJSON(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		json   string
+		want   MathTransform
+		err    error
+	}{
+		"Integer": {
+			reason: "Integer operands should be unmarshalled from JSON numbers.",
+			json:   `{"multiply":2}`,
+			want:   MathTransform{Multiply: NewMathOperand(2)},
+		},
+		"Float": {
+			reason: "Float operands should be unmarshalled from JSON numbers.",
+			json:   `{"clamp":{"min":0.5,"max":1.5}}`,
+			want: MathTransform{Clamp: &MathClamp{
+				Min: func() *MathOperand { o := MathOperand("0.5"); return &o }(),
+				Max: func() *MathOperand { o := MathOperand("1.5"); return &o }(),
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := MathTransform{}
+			err := json.Unmarshal([]byte(tc.json), &got)
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\njson.Unmarshal(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\njson.Unmarshal(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			// Operands should round trip to the same JSON numbers.
+			j, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("json.Marshal(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.json, string(j)); diff != "" {
+				t.Errorf("\n%s\njson.Marshal(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
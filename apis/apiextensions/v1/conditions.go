@@ -32,6 +32,11 @@ const (
 	// A TypeOffered XRD has created the CRD for its composite resource claim
 	// and started a controller to reconcile instances of said claim.
 	TypeOffered xpv1.ConditionType = "Offered"
+
+	// A TypeFieldPathsValid Composition's patches, connection details, and
+	// readiness checks refer only to field paths that exist in the schemas of
+	// their composite and composed resources.
+	TypeFieldPathsValid xpv1.ConditionType = "FieldPathsValid"
 )
 
 // Reasons a resource is or is not established or offered.
@@ -43,6 +48,13 @@ const (
 	ReasonTerminatingClaim     xpv1.ConditionReason = "TerminatingCompositeResourceClaim"
 )
 
+// Reasons a Composition's field paths are or are not valid.
+const (
+	ReasonValidFieldPaths   xpv1.ConditionReason = "ValidFieldPaths"
+	ReasonInvalidFieldPaths xpv1.ConditionReason = "InvalidFieldPaths"
+	ReasonSchemaUnknown     xpv1.ConditionReason = "SchemaUnknown"
+)
+
 // WatchingComposite indicates that Crossplane has defined and is watching for a
 // new kind of composite resource.
 func WatchingComposite() xpv1.Condition {
@@ -86,3 +98,39 @@ func TerminatingClaim() xpv1.Condition {
 		Reason:             ReasonTerminatingClaim,
 	}
 }
+
+// ValidFieldPaths indicates that all of a Composition's field paths that could
+// be validated exist in the schemas of the resources they refer to.
+func ValidFieldPaths() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeFieldPathsValid,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonValidFieldPaths,
+	}
+}
+
+// InvalidFieldPaths indicates that some of a Composition's field paths do not
+// exist in the schemas of the resources they refer to.
+func InvalidFieldPaths(err error) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeFieldPathsValid,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonInvalidFieldPaths,
+		Message:            err.Error(),
+	}
+}
+
+// SchemaUnknown indicates that some of a Composition's field paths could not
+// be validated, because the schemas of the resources they refer to are not
+// (yet) known.
+func SchemaUnknown(err error) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeFieldPathsValid,
+		Status:             corev1.ConditionUnknown,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonSchemaUnknown,
+		Message:            err.Error(),
+	}
+}
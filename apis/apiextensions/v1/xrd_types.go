@@ -64,6 +64,18 @@ type CompositeResourceDefinitionSpec struct {
 	// +immutable
 	EnforcedCompositionRef *xpv1.Reference `json:"enforcedCompositionRef,omitempty"`
 
+	// CompositionSelectionPolicy determines how a Composition is selected when
+	// several Compositions match a composite resource's compositionSelector.
+	// Random selects one of them at random. Deterministic selects the
+	// Composition with the highest priority, per its
+	// apiextensions.crossplane.io/composition-priority annotation, breaking
+	// ties by name. Weighted selects one at random, weighted by its
+	// apiextensions.crossplane.io/composition-weight annotation.
+	// +optional
+	// +kubebuilder:validation:Enum=Random;Deterministic;Weighted
+	// +kubebuilder:default=Random
+	CompositionSelectionPolicy *CompositionSelectionPolicy `json:"compositionSelectionPolicy,omitempty"`
+
 	// Versions is the list of all API versions of the defined composite
 	// resource. Version names are used to compute the order in which served
 	// versions are listed in API discovery. If the version string is
@@ -80,6 +92,31 @@ type CompositeResourceDefinitionSpec struct {
 	Versions []CompositeResourceDefinitionVersion `json:"versions"`
 }
 
+// A CompositionSelectionPolicy determines how a Composition is selected when
+// several Compositions match a composite resource's compositionSelector.
+type CompositionSelectionPolicy string
+
+// Composition selection policies.
+const (
+	CompositionSelectionPolicyRandom        CompositionSelectionPolicy = "Random"
+	CompositionSelectionPolicyDeterministic CompositionSelectionPolicy = "Deterministic"
+	CompositionSelectionPolicyWeighted      CompositionSelectionPolicy = "Weighted"
+)
+
+// Composition selection annotations.
+const (
+	// AnnotationCompositionPriority is the priority of a Composition when
+	// using the Deterministic selection policy. Compositions with a higher
+	// priority are selected first. The default priority is 0.
+	AnnotationCompositionPriority = "apiextensions.crossplane.io/composition-priority"
+
+	// AnnotationCompositionWeight is the weight of a Composition when using
+	// the Weighted selection policy. A Composition with a weight of 2 is twice
+	// as likely to be selected as one with a weight of 1. The default weight
+	// is 1, and the greatest allowed weight is 2147483647.
+	AnnotationCompositionWeight = "apiextensions.crossplane.io/composition-weight"
+)
+
 // CompositeResourceDefinitionVersion describes a version of an XR.
 type CompositeResourceDefinitionVersion struct {
 	// Name of this version, e.g. “v1”, “v2beta1”, etc. Composite resources are
@@ -223,3 +260,12 @@ func (in CompositeResourceDefinition) GetClaimGroupVersionKind() schema.GroupVer
 func (in *CompositeResourceDefinition) GetConnectionSecretKeys() []string {
 	return in.Spec.ConnectionSecretKeys
 }
+
+// GetCompositionSelectionPolicy returns the policy used to select one of
+// several Compositions that match a composite resource's compositionSelector.
+func (in *CompositeResourceDefinition) GetCompositionSelectionPolicy() CompositionSelectionPolicy {
+	if in.Spec.CompositionSelectionPolicy == nil {
+		return CompositionSelectionPolicyRandom
+	}
+	return *in.Spec.CompositionSelectionPolicy
+}
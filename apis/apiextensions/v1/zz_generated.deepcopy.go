@@ -23,6 +23,7 @@ package v1
 import (
 	commonv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -92,8 +93,35 @@ func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 	if in.ReadinessChecks != nil {
 		in, out := &in.ReadinessChecks, &out.ReadinessChecks
 		*out = make([]ReadinessCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Condition != nil {
+		in, out := &in.Condition, &out.Condition
+		*out = new(ComposedTemplateCondition)
+		**out = **in
+	}
+	if in.ForEach != nil {
+		in, out := &in.ForEach, &out.ForEach
+		*out = new(ComposedTemplateForEach)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeletionPolicy != nil {
+		in, out := &in.DeletionPolicy, &out.DeletionPolicy
+		*out = new(commonv1.DeletionPolicy)
+		**out = **in
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Mode != nil {
+		in, out := &in.Mode, &out.Mode
+		*out = new(ComposedTemplateMode)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedTemplate.
@@ -106,6 +134,41 @@ func (in *ComposedTemplate) DeepCopy() *ComposedTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComposedTemplateCondition) DeepCopyInto(out *ComposedTemplateCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedTemplateCondition.
+func (in *ComposedTemplateCondition) DeepCopy() *ComposedTemplateCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ComposedTemplateCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComposedTemplateForEach) DeepCopyInto(out *ComposedTemplateForEach) {
+	*out = *in
+	if in.KeyFieldPath != nil {
+		in, out := &in.KeyFieldPath, &out.KeyFieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedTemplateForEach.
+func (in *ComposedTemplateForEach) DeepCopy() *ComposedTemplateForEach {
+	if in == nil {
+		return nil
+	}
+	out := new(ComposedTemplateForEach)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CompositeResourceDefinition) DeepCopyInto(out *CompositeResourceDefinition) {
 	*out = *in
@@ -206,6 +269,11 @@ func (in *CompositeResourceDefinitionSpec) DeepCopyInto(out *CompositeResourceDe
 		*out = new(commonv1.Reference)
 		**out = **in
 	}
+	if in.CompositionSelectionPolicy != nil {
+		in, out := &in.CompositionSelectionPolicy, &out.CompositionSelectionPolicy
+		*out = new(CompositionSelectionPolicy)
+		**out = **in
+	}
 	if in.Versions != nil {
 		in, out := &in.Versions, &out.Versions
 		*out = make([]CompositeResourceDefinitionVersion, len(*in))
@@ -342,6 +410,97 @@ func (in *CompositionList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositionRevision) DeepCopyInto(out *CompositionRevision) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionRevision.
+func (in *CompositionRevision) DeepCopy() *CompositionRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositionRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CompositionRevision) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositionRevisionList) DeepCopyInto(out *CompositionRevisionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CompositionRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionRevisionList.
+func (in *CompositionRevisionList) DeepCopy() *CompositionRevisionList {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositionRevisionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CompositionRevisionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositionRevisionSpec) DeepCopyInto(out *CompositionRevisionSpec) {
+	*out = *in
+	in.CompositionSpec.DeepCopyInto(&out.CompositionSpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionRevisionSpec.
+func (in *CompositionRevisionSpec) DeepCopy() *CompositionRevisionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositionRevisionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositionRevisionStatus) DeepCopyInto(out *CompositionRevisionStatus) {
+	*out = *in
+	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionRevisionStatus.
+func (in *CompositionRevisionStatus) DeepCopy() *CompositionRevisionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositionRevisionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CompositionSpec) DeepCopyInto(out *CompositionSpec) {
 	*out = *in
@@ -353,6 +512,11 @@ func (in *CompositionSpec) DeepCopyInto(out *CompositionSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Mode != nil {
+		in, out := &in.Mode, &out.Mode
+		*out = new(CompositionMode)
+		**out = **in
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = make([]ComposedTemplate, len(*in))
@@ -360,6 +524,18 @@ func (in *CompositionSpec) DeepCopyInto(out *CompositionSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.GoTemplate != nil {
+		in, out := &in.GoTemplate, &out.GoTemplate
+		*out = new(GoTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Functions != nil {
+		in, out := &in.Functions, &out.Functions
+		*out = make([]Function, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.WriteConnectionSecretsToNamespace != nil {
 		in, out := &in.WriteConnectionSecretsToNamespace, &out.WriteConnectionSecretsToNamespace
 		*out = new(string)
@@ -421,6 +597,18 @@ func (in *ConnectionDetail) DeepCopyInto(out *ConnectionDetail) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Combine != nil {
+		in, out := &in.Combine, &out.Combine
+		*out = new(Combine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = make([]Transform, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetail.
@@ -448,14 +636,81 @@ func (in *ConvertTransform) DeepCopy() *ConvertTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecFunction) DeepCopyInto(out *ExecFunction) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecFunction.
+func (in *ExecFunction) DeepCopy() *ExecFunction {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecFunction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Function) DeepCopyInto(out *Function) {
+	*out = *in
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(ExecFunction)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Function.
+func (in *Function) DeepCopy() *Function {
+	if in == nil {
+		return nil
+	}
+	out := new(Function)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GoTemplate) DeepCopyInto(out *GoTemplate) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]TemplatedResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoTemplate.
+func (in *GoTemplate) DeepCopy() *GoTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(GoTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MapTransform) DeepCopyInto(out *MapTransform) {
 	*out = *in
 	if in.Pairs != nil {
 		in, out := &in.Pairs, &out.Pairs
-		*out = make(map[string]string, len(*in))
+		*out = make(map[string]apiextensionsv1.JSON, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 }
@@ -470,14 +725,104 @@ func (in *MapTransform) DeepCopy() *MapTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MapTransformFallback) DeepCopyInto(out *MapTransformFallback) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MapTransformFallback.
+func (in *MapTransformFallback) DeepCopy() *MapTransformFallback {
+	if in == nil {
+		return nil
+	}
+	out := new(MapTransformFallback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchConditionReadinessCheck) DeepCopyInto(out *MatchConditionReadinessCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchConditionReadinessCheck.
+func (in *MatchConditionReadinessCheck) DeepCopy() *MatchConditionReadinessCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchConditionReadinessCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MathClamp) DeepCopyInto(out *MathClamp) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(MathOperand)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MathClamp.
+func (in *MathClamp) DeepCopy() *MathClamp {
+	if in == nil {
+		return nil
+	}
+	out := new(MathClamp)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MathTransform) DeepCopyInto(out *MathTransform) {
 	*out = *in
 	if in.Multiply != nil {
 		in, out := &in.Multiply, &out.Multiply
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.Subtract != nil {
+		in, out := &in.Subtract, &out.Subtract
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.Divide != nil {
+		in, out := &in.Divide, &out.Divide
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.Clamp != nil {
+		in, out := &in.Clamp, &out.Clamp
+		*out = new(MathClamp)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Round != nil {
+		in, out := &in.Round, &out.Round
 		*out = new(int64)
 		**out = **in
 	}
+	if in.ResultType != nil {
+		in, out := &in.ResultType, &out.ResultType
+		*out = new(MathResultType)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MathTransform.
@@ -490,6 +835,36 @@ func (in *MathTransform) DeepCopy() *MathTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeOptions) DeepCopyInto(out *MergeOptions) {
+	*out = *in
+	if in.KeepMapValues != nil {
+		in, out := &in.KeepMapValues, &out.KeepMapValues
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AppendSlice != nil {
+		in, out := &in.AppendSlice, &out.AppendSlice
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DeduplicateSlice != nil {
+		in, out := &in.DeduplicateSlice, &out.DeduplicateSlice
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeOptions.
+func (in *MergeOptions) DeepCopy() *MergeOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Patch) DeepCopyInto(out *Patch) {
 	*out = *in
@@ -498,6 +873,11 @@ func (in *Patch) DeepCopyInto(out *Patch) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FromResourceName != nil {
+		in, out := &in.FromResourceName, &out.FromResourceName
+		*out = new(string)
+		**out = **in
+	}
 	if in.Combine != nil {
 		in, out := &in.Combine, &out.Combine
 		*out = new(Combine)
@@ -545,6 +925,11 @@ func (in *PatchPolicy) DeepCopyInto(out *PatchPolicy) {
 		*out = new(FromFieldPathPolicy)
 		**out = **in
 	}
+	if in.MergeOptions != nil {
+		in, out := &in.MergeOptions, &out.MergeOptions
+		*out = new(MergeOptions)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchPolicy.
@@ -582,6 +967,16 @@ func (in *PatchSet) DeepCopy() *PatchSet {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 	*out = *in
+	if in.MatchIntegerFieldPath != nil {
+		in, out := &in.MatchIntegerFieldPath, &out.MatchIntegerFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.MatchCondition != nil {
+		in, out := &in.MatchCondition, &out.MatchCondition
+		*out = new(MatchConditionReadinessCheck)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessCheck.
@@ -612,6 +1007,36 @@ func (in *StringCombine) DeepCopy() *StringCombine {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StringTransform) DeepCopyInto(out *StringTransform) {
 	*out = *in
+	if in.Convert != nil {
+		in, out := &in.Convert, &out.Convert
+		*out = new(StringConversionType)
+		**out = **in
+	}
+	if in.Trim != nil {
+		in, out := &in.Trim, &out.Trim
+		*out = new(string)
+		**out = **in
+	}
+	if in.Regexp != nil {
+		in, out := &in.Regexp, &out.Regexp
+		*out = new(StringTransformRegexp)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hash != nil {
+		in, out := &in.Hash, &out.Hash
+		*out = new(StringTransformHash)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Split != nil {
+		in, out := &in.Split, &out.Split
+		*out = new(StringTransformSplit)
+		**out = **in
+	}
+	if in.Join != nil {
+		in, out := &in.Join, &out.Join
+		*out = new(StringTransformJoin)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransform.
@@ -624,6 +1049,105 @@ func (in *StringTransform) DeepCopy() *StringTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformHash) DeepCopyInto(out *StringTransformHash) {
+	*out = *in
+	if in.Length != nil {
+		in, out := &in.Length, &out.Length
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformHash.
+func (in *StringTransformHash) DeepCopy() *StringTransformHash {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformHash)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformJoin) DeepCopyInto(out *StringTransformJoin) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformJoin.
+func (in *StringTransformJoin) DeepCopy() *StringTransformJoin {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformJoin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformRegexp) DeepCopyInto(out *StringTransformRegexp) {
+	*out = *in
+	if in.Group != nil {
+		in, out := &in.Group, &out.Group
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformRegexp.
+func (in *StringTransformRegexp) DeepCopy() *StringTransformRegexp {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformRegexp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformSplit) DeepCopyInto(out *StringTransformSplit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformSplit.
+func (in *StringTransformSplit) DeepCopy() *StringTransformSplit {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformSplit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplatedResource) DeepCopyInto(out *TemplatedResource) {
+	*out = *in
+	if in.ConnectionDetails != nil {
+		in, out := &in.ConnectionDetails, &out.ConnectionDetails
+		*out = make([]ConnectionDetail, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReadinessChecks != nil {
+		in, out := &in.ReadinessChecks, &out.ReadinessChecks
+		*out = make([]ReadinessCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplatedResource.
+func (in *TemplatedResource) DeepCopy() *TemplatedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplatedResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Transform) DeepCopyInto(out *Transform) {
 	*out = *in
@@ -637,10 +1161,15 @@ func (in *Transform) DeepCopyInto(out *Transform) {
 		*out = new(MapTransform)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MapFallback != nil {
+		in, out := &in.MapFallback, &out.MapFallback
+		*out = new(MapTransformFallback)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.String != nil {
 		in, out := &in.String, &out.String
 		*out = new(StringTransform)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Convert != nil {
 		in, out := &in.Convert, &out.Convert
@@ -17,6 +17,12 @@ limitations under the License.
 package v1beta1
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -37,9 +43,33 @@ type CompositionSpec struct {
 	// +optional
 	PatchSets []PatchSet `json:"patchSets,omitempty"`
 
+	// Mode determines how this Composition composes resources. Resources mode
+	// Compositions render a composed resource from each of their resource
+	// templates. GoTemplate mode Compositions render composed resources using
+	// a Go template.
+	// +optional
+	// +kubebuilder:validation:Enum=Resources;GoTemplate
+	// +kubebuilder:default=Resources
+	Mode *CompositionMode `json:"mode,omitempty"`
+
 	// Resources is the list of resource templates that will be used when a
-	// composite resource referring to this composition is created.
-	Resources []ComposedTemplate `json:"resources"`
+	// composite resource referring to this composition is created. Resources
+	// must not be specified by GoTemplate mode Compositions.
+	// +optional
+	Resources []ComposedTemplate `json:"resources,omitempty"`
+
+	// GoTemplate renders the composed resources of a GoTemplate mode
+	// Composition.
+	// +optional
+	GoTemplate *GoTemplate `json:"goTemplate,omitempty"`
+
+	// Functions is an optional pipeline of composition functions. Functions
+	// are run in order after the resource templates have been rendered. Each
+	// function is supplied the observed and desired state of the composite
+	// resource and its composed resources, and returns an updated desired
+	// state. All resource templates must be named in order to use functions.
+	// +optional
+	Functions []Function `json:"functions,omitempty"`
 
 	// WriteConnectionSecretsToNamespace specifies the namespace in which the
 	// connection secrets of composite resource dynamically provisioned using
@@ -48,6 +78,100 @@ type CompositionSpec struct {
 	WriteConnectionSecretsToNamespace *string `json:"writeConnectionSecretsToNamespace,omitempty"`
 }
 
+// A CompositionMode determines how a Composition composes resources.
+type CompositionMode string
+
+// Composition modes.
+const (
+	// CompositionModeResources Compositions render a composed resource from
+	// each of their resource templates.
+	CompositionModeResources CompositionMode = "Resources"
+
+	// CompositionModeGoTemplate Compositions render composed resources using a
+	// Go template.
+	CompositionModeGoTemplate CompositionMode = "GoTemplate"
+)
+
+// A GoTemplate renders composed resources using a Go text/template.
+type GoTemplate struct {
+	// Source of the Go template. The template must render a YAML stream of
+	// composed resources. Each composed resource must be named using the
+	// crossplane.io/composition-resource-name annotation. The composite
+	// resource is available as .Composite, and the observed composed
+	// resources as .Observed, keyed by name.
+	Source string `json:"source"`
+
+	// Resources configures the connection details and readiness checks of the
+	// composed resources rendered by the template.
+	// +optional
+	Resources []TemplatedResource `json:"resources,omitempty"`
+}
+
+// A TemplatedResource configures a composed resource rendered by a Go
+// template.
+type TemplatedResource struct {
+	// Name of the composed resource, as specified by its
+	// crossplane.io/composition-resource-name annotation.
+	Name string `json:"name"`
+
+	// ConnectionDetails lists the propagation secret keys from this composed
+	// resource to the composition instance connection secret.
+	// +optional
+	ConnectionDetails []ConnectionDetail `json:"connectionDetails,omitempty"`
+
+	// ReadinessChecks allows users to define custom readiness checks. All
+	// checks have to return true in order for resource to be considered
+	// ready. The default readiness check is to have the "Ready" condition to
+	// be "True". Well-known Kubernetes kinds that don't have a "Ready"
+	// condition, like Deployments and Jobs, are checked according to their
+	// status by default.
+	// +optional
+	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+}
+
+// A FunctionType is a type of composition function.
+type FunctionType string
+
+// FunctionType types.
+const (
+	// FunctionTypeExec functions are executables that read a FunctionIO
+	// document from stdin and write an updated FunctionIO document to stdout.
+	FunctionTypeExec FunctionType = "Exec"
+)
+
+// A Function transforms the desired state of a composite resource's composed
+// resources.
+type Function struct {
+	// Name of this function. Must be unique within its Composition.
+	Name string `json:"name"`
+
+	// Type of this function.
+	// +kubebuilder:validation:Enum=Exec
+	Type FunctionType `json:"type"`
+
+	// Exec configures an Exec function.
+	// +optional
+	Exec *ExecFunction `json:"exec,omitempty"`
+}
+
+// An ExecFunction is an executable run by Crossplane. It is supplied a
+// FunctionIO document via stdin, and must write an updated FunctionIO
+// document to stdout. Stderr is included in any error returned if the
+// executable exits with a non-zero status.
+type ExecFunction struct {
+	// Command is the absolute path to the executable. Crossplane only runs
+	// executables within the directory it is configured to run functions from.
+	Command string `json:"command"`
+
+	// Args are passed to the executable.
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Timeout after which the executable will be killed. Defaults to 20s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
 // A PatchSet is a set of patches that can be reused from all resources within
 // a Composition.
 type PatchSet struct {
@@ -80,7 +204,8 @@ type ComposedTemplate struct {
 	// the resources array are named entries may added, deleted, and reordered
 	// as long as their names do not change. When entries are not named the
 	// length and order of the resources array should be treated as immutable.
-	// Either all or no entries must be named.
+	// Either all or no entries must be named. Names must not contain '[',
+	// which is reserved for resources expanded by forEach.
 	// +optional
 	Name *string `json:"name,omitempty"`
 
@@ -103,6 +228,114 @@ type ComposedTemplate struct {
 	// default readiness check is to have the "Ready" condition to be "True".
 	// +optional
 	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+
+	// Condition determines whether this template is included when composing
+	// resources. The template is included when the condition, which is
+	// evaluated against the composite resource, is true. If a template is
+	// excluded any composed resource previously created from it is deleted.
+	// Templates are always included if no condition is specified. Only named
+	// templates may specify a condition.
+	// +optional
+	Condition *ComposedTemplateCondition `json:"condition,omitempty"`
+
+	// ForEach expands this template once per element of an array field of the
+	// composite resource. Each element and its index may be used as inputs by
+	// FromForEach patches. Only named templates may specify forEach.
+	// +optional
+	ForEach *ComposedTemplateForEach `json:"forEach,omitempty"`
+
+	// DeletionPolicy specifies what will happen to the composed resource when
+	// its composite resource is deleted. Composed resources with the Orphan
+	// policy are released rather than deleted; their owner references and
+	// Crossplane labels are removed so that they may later be adopted by
+	// another composite resource. Defaults to the composite resource's
+	// spec.deletionPolicy, or to Delete if that is not set either.
+	// +optional
+	DeletionPolicy *xpv1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// DependsOn lists the names of other resource templates in this
+	// Composition. The composed resource is not applied until all of the
+	// composed resources rendered from the resource templates it depends on
+	// are ready. When the composite resource is deleted, composed resources
+	// are deleted in the reverse order. Only named templates may specify
+	// dependsOn, and dependencies must not be cyclic.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Mode determines how Crossplane manages the composed resource. Apply
+	// mode composed resources are created, updated, and deleted by Crossplane.
+	// Observe mode composed resources must already exist. Crossplane resolves
+	// an Observe mode composed resource by the name, or failing that the
+	// labels, that its template renders. It never modifies or deletes an
+	// Observe mode composed resource, but it does patch fields from it to the
+	// composite resource, and checks its readiness and connection details.
+	// +optional
+	// +kubebuilder:validation:Enum=Apply;Observe
+	// +kubebuilder:default=Apply
+	Mode *ComposedTemplateMode `json:"mode,omitempty"`
+}
+
+// A ComposedTemplateMode determines how Crossplane manages a composed resource.
+type ComposedTemplateMode string
+
+// Composed template modes.
+const (
+	// ComposedTemplateModeApply composed resources are created, updated, and
+	// deleted by Crossplane.
+	ComposedTemplateModeApply ComposedTemplateMode = "Apply"
+
+	// ComposedTemplateModeObserve composed resources are observed, but never
+	// modified or deleted, by Crossplane.
+	ComposedTemplateModeObserve ComposedTemplateMode = "Observe"
+)
+
+// A ComposedTemplateForEach expands a composed template once per element of an
+// array field of the composite resource.
+type ComposedTemplateForEach struct {
+	// FromFieldPath is the path of an array field on the composite resource.
+	// The template is expanded once per element of the array. The template is
+	// not expanded at all if the field does not exist.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// KeyFieldPath is the path of a field within each element whose value
+	// uniquely and stably identifies the element. Composed resources are
+	// associated with elements by key, so that only the composed resources of
+	// removed elements are deleted. Elements that are strings, numbers, or
+	// booleans are their own key if no KeyFieldPath is specified. Other
+	// elements are identified by their index.
+	// +optional
+	KeyFieldPath *string `json:"keyFieldPath,omitempty"`
+}
+
+// A ComposedTemplateConditionType is a type of composed template condition.
+type ComposedTemplateConditionType string
+
+// Composed template condition types.
+const (
+	ComposedTemplateConditionTypeMatchTrue   ComposedTemplateConditionType = "MatchTrue"
+	ComposedTemplateConditionTypeMatchFalse  ComposedTemplateConditionType = "MatchFalse"
+	ComposedTemplateConditionTypeMatchString ComposedTemplateConditionType = "MatchString"
+	ComposedTemplateConditionTypeNonEmpty    ComposedTemplateConditionType = "NonEmpty"
+)
+
+// A ComposedTemplateCondition is evaluated against a field of the composite
+// resource. The condition is false if the field does not exist.
+type ComposedTemplateCondition struct {
+	// Type of the condition. MatchTrue and MatchFalse are true if the field is
+	// the corresponding boolean. MatchString is true if the field matches the
+	// supplied string. NonEmpty is true if the field exists.
+	// +optional
+	// +kubebuilder:validation:Enum=MatchTrue;MatchFalse;MatchString;NonEmpty
+	// +kubebuilder:default=MatchTrue
+	Type ComposedTemplateConditionType `json:"type,omitempty"`
+
+	// FromFieldPath is the path of the field on the composite resource whose
+	// value the condition is evaluated against.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// MatchString is the value to match when using the MatchString type.
+	// +optional
+	MatchString string `json:"matchString,omitempty"`
 }
 
 // ReadinessCheckType is used for readiness check types.
@@ -110,17 +343,26 @@ type ReadinessCheckType string
 
 // The possible values for readiness check type.
 const (
-	ReadinessCheckTypeNonEmpty     ReadinessCheckType = "NonEmpty"
-	ReadinessCheckTypeMatchString  ReadinessCheckType = "MatchString"
-	ReadinessCheckTypeMatchInteger ReadinessCheckType = "MatchInteger"
-	ReadinessCheckTypeNone         ReadinessCheckType = "None"
+	ReadinessCheckTypeNonEmpty                       ReadinessCheckType = "NonEmpty"
+	ReadinessCheckTypeMatchString                    ReadinessCheckType = "MatchString"
+	ReadinessCheckTypeMatchInteger                   ReadinessCheckType = "MatchInteger"
+	ReadinessCheckTypeMatchIntegerGreaterThan        ReadinessCheckType = "MatchIntegerGreaterThan"
+	ReadinessCheckTypeMatchIntegerGreaterThanOrEqual ReadinessCheckType = "MatchIntegerGreaterThanOrEqual"
+	ReadinessCheckTypeMatchIntegerLessThan           ReadinessCheckType = "MatchIntegerLessThan"
+	ReadinessCheckTypeMatchIntegerLessThanOrEqual    ReadinessCheckType = "MatchIntegerLessThanOrEqual"
+	ReadinessCheckTypeMatchTrue                      ReadinessCheckType = "MatchTrue"
+	ReadinessCheckTypeMatchFalse                     ReadinessCheckType = "MatchFalse"
+	ReadinessCheckTypeMatchRegex                     ReadinessCheckType = "MatchRegex"
+	ReadinessCheckTypeMatchCondition                 ReadinessCheckType = "MatchCondition"
+	ReadinessCheckTypeNone                           ReadinessCheckType = "None"
 )
 
 // ReadinessCheck is used to indicate how to tell whether a resource is ready
-// for consumption
+// for consumption. A resource is considered not ready if the field at the
+// supplied field path does not exist.
 type ReadinessCheck struct {
 	// Type indicates the type of probe you'd like to use.
-	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"None"
+	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"MatchIntegerGreaterThan";"MatchIntegerGreaterThanOrEqual";"MatchIntegerLessThan";"MatchIntegerLessThanOrEqual";"MatchTrue";"MatchFalse";"MatchRegex";"MatchCondition";"NonEmpty";"None"
 	Type ReadinessCheckType `json:"type"`
 
 	// FieldPath shows the path of the field whose value will be used.
@@ -132,8 +374,40 @@ type ReadinessCheck struct {
 	MatchString string `json:"matchString,omitempty"`
 
 	// MatchInt is the value you'd like to match if you're using "MatchInt" type.
+	// It is also the value compared against when using any of the
+	// "MatchIntegerGreaterThan", "MatchIntegerGreaterThanOrEqual",
+	// "MatchIntegerLessThan", or "MatchIntegerLessThanOrEqual" types.
 	// +optional
 	MatchInteger int64 `json:"matchInteger,omitempty"`
+
+	// MatchIntegerFieldPath is the path of a field whose integer value you'd
+	// like to compare against when using any of the "MatchInteger" types. It
+	// takes precedence over MatchInteger.
+	// +optional
+	MatchIntegerFieldPath *string `json:"matchIntegerFieldPath,omitempty"`
+
+	// MatchRegex is the regular expression you'd like to match if you're using
+	// "MatchRegex" type. See https://github.com/google/re2/wiki/Syntax for the
+	// syntax.
+	// +optional
+	MatchRegex string `json:"matchRegex,omitempty"`
+
+	// MatchCondition is the condition you'd like to match if you're using
+	// "MatchCondition" type.
+	// +optional
+	MatchCondition *MatchConditionReadinessCheck `json:"matchCondition,omitempty"`
+}
+
+// MatchConditionReadinessCheck is used to indicate how to tell whether a
+// resource is ready for consumption using one of its conditions.
+type MatchConditionReadinessCheck struct {
+	// Type indicates the type of condition you'd like to use.
+	Type xpv1.ConditionType `json:"type"`
+
+	// Status is the status of the condition you'd like to match. Defaults to
+	// "True".
+	// +optional
+	Status corev1.ConditionStatus `json:"status,omitempty"`
 }
 
 // A PatchType is a type of patch.
@@ -146,26 +420,41 @@ const (
 	PatchTypeToCompositeFieldPath   PatchType = "ToCompositeFieldPath"
 	PatchTypeCombineFromComposite   PatchType = "CombineFromComposite"
 	PatchTypeCombineToComposite     PatchType = "CombineToComposite"
+	PatchTypeFromComposedFieldPath  PatchType = "FromComposedFieldPath"
+	PatchTypeFromForEach            PatchType = "FromForEach"
 )
 
 // Patch objects are applied between composite and composed resources. Their
 // behaviour depends on the Type selected. The default Type,
 // FromCompositeFieldPath, copies a value from the composite resource to
-// the composed resource, applying any defined transformers.
+// the composed resource, applying any defined transformers. The
+// FromComposedFieldPath type copies a value from another composed resource of
+// the same composite resource. The FromForEach type copies a value from the
+// array element a forEach template was expanded for.
 type Patch struct {
 	// Type sets the patching behaviour to be used. Each patch type may require
 	// its' own fields to be set on the Patch object.
 	// +optional
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromComposedFieldPath;FromForEach
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
 	// FromFieldPath is the path of the field on the resource whose value is
-	// to be used as input. Required when type is FromCompositeFieldPath or
-	// ToCompositeFieldPath.
+	// to be used as input. Required when type is FromCompositeFieldPath,
+	// ToCompositeFieldPath, FromComposedFieldPath, or FromForEach. When type
+	// is FromForEach the path is relative to an object with an 'element' field
+	// containing the array element, and an 'index' field containing its index.
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
+	// FromResourceName is the name of the resource template whose composed
+	// resource's value is to be used as input. Required when type is
+	// FromComposedFieldPath. The composed resource is read as it was observed
+	// when it was last applied, so its template should be applied first; i.e.
+	// it should appear earlier in the resources array.
+	// +optional
+	FromResourceName *string `json:"fromResourceName,omitempty"`
+
 	// Combine is the patch configuration for a CombineFromComposite or
 	// CombineToComposite patch.
 	Combine *Combine `json:"combine,omitempty"`
@@ -208,6 +497,34 @@ type PatchPolicy struct {
 	// +kubebuilder:validation:Enum=Optional;Required
 	// +optional
 	FromFieldPath *FromFieldPathPolicy `json:"fromFieldPath,omitempty"`
+
+	// MergeOptions specifies how to merge the patched value with any value
+	// that already exists at the destination field path. By default the
+	// existing value is replaced. Merge options are only supported by patches
+	// that write to a composed resource; they may not be used by
+	// ToCompositeFieldPath or CombineToComposite patches.
+	// +optional
+	MergeOptions *MergeOptions `json:"mergeOptions,omitempty"`
+}
+
+// MergeOptions specifies how a patched value is merged with an existing
+// value. Objects are always merged key by key when merge options are
+// specified, with patched values taking precedence over existing values.
+type MergeOptions struct {
+	// KeepMapValues specifies that values that already exist in a merged
+	// object should be preserved, rather than overwritten by patched values.
+	// +optional
+	KeepMapValues *bool `json:"keepMapValues,omitempty"`
+
+	// AppendSlice specifies that patched arrays should be appended to
+	// existing arrays, rather than replace them.
+	// +optional
+	AppendSlice *bool `json:"appendSlice,omitempty"`
+
+	// DeduplicateSlice specifies that duplicate elements should be removed
+	// from patched arrays, keeping the first occurrence of each element.
+	// +optional
+	DeduplicateSlice *bool `json:"deduplicateSlice,omitempty"`
 }
 
 // A Combine configures a patch that combines more than
@@ -218,9 +535,13 @@ type Combine struct {
 	// +kubebuilder:validation:MinItems=1
 	Variables []CombineVariable `json:"variables"`
 
-	// Strategy defines the strategy to use to combine the input variable values.
-	// Currently only string is supported.
-	// +kubebuilder:validation:Enum=string
+	// Strategy defines the strategy to use to combine the input variable
+	// values. The string strategy formats the variables as a string. The list
+	// strategy produces a list of the variables, in order. The object strategy
+	// produces an object keyed by the name of each variable. The sum strategy
+	// adds numeric variables together. The coalesce strategy produces the first
+	// variable that is not empty.
+	// +kubebuilder:validation:Enum=string;list;object;sum;coalesce
 	Strategy CombineStrategy `json:"strategy"`
 
 	// String declares that input variables should be combined into a single
@@ -230,12 +551,25 @@ type Combine struct {
 }
 
 // A CombineVariable defines the source of a value that is combined with
-// others to form and patch an output value. Currently, this only supports
-// retrieving values from a field path.
+// others to form and patch an output value. Variables may retrieve values from
+// a field path, or from a connection secret key when combining connection
+// details.
 type CombineVariable struct {
+	// Name of the variable. Required when using the object strategy, in which
+	// case it is used as the key of the variable's value.
+	// +optional
+	Name string `json:"name,omitempty"`
+
 	// FromFieldPath is the path of the field on the source whose value is
-	// to be used as input.
-	FromFieldPath string `json:"fromFieldPath"`
+	// to be used as input. Required unless FromConnectionSecretKey is set.
+	// +optional
+	FromFieldPath string `json:"fromFieldPath,omitempty"`
+
+	// FromConnectionSecretKey is the key of the composed resource's
+	// connection secret whose value is to be used as input. Only supported
+	// when combining connection details.
+	// +optional
+	FromConnectionSecretKey string `json:"fromConnectionSecretKey,omitempty"`
 }
 
 // A CombineStrategy determines what strategy will be applied to combine
@@ -244,7 +578,11 @@ type CombineStrategy string
 
 // CombineStrategy strategy definitions.
 const (
-	CombineStrategyString CombineStrategy = "string"
+	CombineStrategyString   CombineStrategy = "string"
+	CombineStrategyList     CombineStrategy = "list"
+	CombineStrategyObject   CombineStrategy = "object"
+	CombineStrategySum      CombineStrategy = "sum"
+	CombineStrategyCoalesce CombineStrategy = "coalesce"
 )
 
 // A StringCombine combines multiple input values into a single string.
@@ -274,7 +612,7 @@ type Transform struct {
 	Type TransformType `json:"type"`
 
 	// Math is used to transform the input via mathematical operations such as
-	// multiplication.
+	// multiplication, addition, division, clamping, and rounding.
 	// +optional
 	Math *MathTransform `json:"math,omitempty"`
 
@@ -282,6 +620,11 @@ type Transform struct {
 	// +optional
 	Map *MapTransform `json:"map,omitempty"`
 
+	// MapFallback determines what a map transform returns when its input
+	// does not match any key. It may only be specified when type is map.
+	// +optional
+	MapFallback *MapTransformFallback `json:"mapFallback,omitempty"`
+
 	// String is used to transform the input into a string or a different kind
 	// of string. Note that the input does not necessarily need to be a string.
 	// +optional
@@ -293,27 +636,256 @@ type Transform struct {
 }
 
 // MathTransform conducts mathematical operations on the input with the given
-// configuration in its properties.
+// configuration in its properties. Exactly one operation must be specified.
+// Unless a result type is specified integer inputs produce integer outputs when
+// the operation's operands are integers, while float inputs or operands
+// produce float outputs.
 type MathTransform struct {
 	// Multiply the value.
 	// +optional
-	Multiply *int64 `json:"multiply,omitempty"`
+	Multiply *MathOperand `json:"multiply,omitempty"`
+
+	// Add to the value.
+	// +optional
+	Add *MathOperand `json:"add,omitempty"`
+
+	// Subtract from the value.
+	// +optional
+	Subtract *MathOperand `json:"subtract,omitempty"`
+
+	// Divide the value. Integer inputs divided by an integer use integer
+	// division, truncating any remainder, unless the result type is Float.
+	// +optional
+	Divide *MathOperand `json:"divide,omitempty"`
+
+	// Clamp the value between a minimum and maximum.
+	// +optional
+	Clamp *MathClamp `json:"clamp,omitempty"`
+
+	// Round the value to the supplied number of decimal places. Integer inputs
+	// are returned unchanged.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Round *int64 `json:"round,omitempty"`
+
+	// ResultType is the type of number the operation produces. Float results
+	// are always calculated using floating point arithmetic. Integer results
+	// have any fractional part truncated. By default the result is an integer
+	// if the input and all operands are integers, and a float otherwise.
+	// +kubebuilder:validation:Enum=Integer;Float
+	// +optional
+	ResultType *MathResultType `json:"resultType,omitempty"`
 }
 
-// MapTransform returns a value for the input from the given map.
+// A MathResultType is the type of number a math transform produces.
+type MathResultType string
+
+// Math transform result types.
+const (
+	MathResultTypeInteger MathResultType = "Integer"
+	MathResultTypeFloat   MathResultType = "Float"
+)
+
+// A MathClamp clamps a value between a minimum and maximum. At least one of
+// the minimum and maximum must be specified.
+type MathClamp struct {
+	// Min is the smallest value that will be returned.
+	// +optional
+	Min *MathOperand `json:"min,omitempty"`
+
+	// Max is the largest value that will be returned.
+	// +optional
+	Max *MathOperand `json:"max,omitempty"`
+}
+
+// A MathOperand is an integer or floating point number. Operands without a
+// fractional part (e.g. 2 or 2.0) are integers. MathOperand replaces the
+// *int64 that earlier versions of this API used for the Multiply operand; use
+// NewMathOperand to convert an int64.
+// +kubebuilder:validation:Type=number
+type MathOperand string
+
+// NewMathOperand returns a MathOperand of the supplied integer.
+func NewMathOperand(i int64) *MathOperand {
+	o := MathOperand(strconv.FormatInt(i, 10))
+	return &o
+}
+
+// MarshalJSON into a JSON number.
+func (o MathOperand) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseFloat(string(o), 64); err != nil {
+		return nil, fmt.Errorf("%q is not a valid math operand", string(o))
+	}
+	return []byte(o), nil
+}
+
+// UnmarshalJSON from a JSON number.
+func (o *MathOperand) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*o = MathOperand(n)
+	return nil
+}
+
+// A MapTransformFallbackTo determines what a map transform returns when its
+// input does not match any of its pairs.
+type MapTransformFallbackTo string
+
+// Accepted MapTransformFallbackTo values.
+const (
+	// MapTransformFallbackToValue returns the fallback value, or an error if
+	// no fallback value was specified.
+	MapTransformFallbackToValue MapTransformFallbackTo = "Value"
+
+	// MapTransformFallbackToInput returns the input unchanged.
+	MapTransformFallbackToInput MapTransformFallbackTo = "Input"
+)
+
+// MapTransform returns a value for the input from the given map. Inputs that
+// are not strings (i.e. booleans and numbers) are matched against the keys of
+// the map using their string representation.
 type MapTransform struct {
 	// TODO(negz): Are Pairs really optional if a MapTransform was specified?
 
-	// Pairs is the map that will be used for transform.
+	// Pairs is the map that will be used for transform. Values may be any
+	// valid JSON value, including objects and arrays.
 	// +optional
-	Pairs map[string]string `json:",inline"`
+	Pairs map[string]extv1.JSON `json:",inline"`
 }
 
+// A MapTransformFallback determines what a map transform returns when its
+// input does not match any of its pairs.
+type MapTransformFallback struct {
+	// Value is returned when no pair matches the input. It may be any valid
+	// JSON value, including objects and arrays.
+	// +optional
+	Value *extv1.JSON `json:"value,omitempty"`
+
+	// To determines what is returned when no pair matches the input. Value
+	// (the default) returns the fallback value, or an error if no fallback
+	// value was specified. Input returns the input unchanged.
+	// +optional
+	// +kubebuilder:validation:Enum=Value;Input
+	To MapTransformFallbackTo `json:"to,omitempty"`
+}
+
+// A StringTransformType is a type of string transform.
+type StringTransformType string
+
+// Accepted StringTransformTypes.
+const (
+	StringTransformTypeFormat     StringTransformType = "Format"
+	StringTransformTypeConvert    StringTransformType = "Convert"
+	StringTransformTypeTrimPrefix StringTransformType = "TrimPrefix"
+	StringTransformTypeTrimSuffix StringTransformType = "TrimSuffix"
+	StringTransformTypeRegexp     StringTransformType = "Regexp"
+	StringTransformTypeHash       StringTransformType = "Hash"
+	StringTransformTypeSplit      StringTransformType = "Split"
+	StringTransformTypeJoin       StringTransformType = "Join"
+)
+
+// A StringConversionType is a type of string conversion.
+type StringConversionType string
+
+// Accepted StringConversionTypes.
+const (
+	StringConversionTypeToUpper    StringConversionType = "ToUpper"
+	StringConversionTypeToLower    StringConversionType = "ToLower"
+	StringConversionTypeToBase64   StringConversionType = "ToBase64"
+	StringConversionTypeFromBase64 StringConversionType = "FromBase64"
+)
+
+// A StringHashAlgorithm is a hashing algorithm.
+type StringHashAlgorithm string
+
+// Accepted StringHashAlgorithms.
+const (
+	StringHashAlgorithmSHA1   StringHashAlgorithm = "SHA1"
+	StringHashAlgorithmSHA256 StringHashAlgorithm = "SHA256"
+	StringHashAlgorithmSHA512 StringHashAlgorithm = "SHA512"
+)
+
 // A StringTransform returns a string given the supplied input.
 type StringTransform struct {
+	// Type of the string transform to be run. Defaults to Format.
+	// +optional
+	// +kubebuilder:validation:Enum=Format;Convert;TrimPrefix;TrimSuffix;Regexp;Hash;Split;Join
+	Type StringTransformType `json:"type,omitempty"`
+
 	// Format the input using a Go format string. See
-	// https://golang.org/pkg/fmt/ for details.
-	Format string `json:"fmt"`
+	// https://golang.org/pkg/fmt/ for details. Required when type is Format.
+	// +optional
+	Format string `json:"fmt,omitempty"`
+
+	// Convert the input string. Required when type is Convert.
+	// +optional
+	// +kubebuilder:validation:Enum=ToUpper;ToLower;ToBase64;FromBase64
+	Convert *StringConversionType `json:"convert,omitempty"`
+
+	// Trim the supplied prefix or suffix from the input string. Required when
+	// type is TrimPrefix or TrimSuffix.
+	// +optional
+	Trim *string `json:"trim,omitempty"`
+
+	// Regexp extracts a match from the input string. Required when type is
+	// Regexp.
+	// +optional
+	Regexp *StringTransformRegexp `json:"regexp,omitempty"`
+
+	// Hash the input string. Required when type is Hash.
+	// +optional
+	Hash *StringTransformHash `json:"hash,omitempty"`
+
+	// Split the input string into an array of strings. Required when type is
+	// Split.
+	// +optional
+	Split *StringTransformSplit `json:"split,omitempty"`
+
+	// Join an input array into a string. Required when type is Join.
+	// +optional
+	Join *StringTransformJoin `json:"join,omitempty"`
+}
+
+// A StringTransformRegexp extracts a match from the input string using a
+// regular expression.
+type StringTransformRegexp struct {
+	// Match string. May optionally include submatches, aka capture groups.
+	// See https://pkg.go.dev/regexp/ for details.
+	Match string `json:"match"`
+
+	// Group number to match. 0 (the default) matches the entire expression.
+	// +optional
+	Group *int `json:"group,omitempty"`
+}
+
+// A StringTransformHash hashes the input string, returning the hex encoded
+// digest.
+type StringTransformHash struct {
+	// Algorithm used to hash the input.
+	// +kubebuilder:validation:Enum=SHA1;SHA256;SHA512
+	Algorithm StringHashAlgorithm `json:"algorithm"`
+
+	// Length to which the hex encoded digest will be truncated, for example
+	// to fit the name length limits of an external system. The full digest
+	// is returned if omitted.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Length *int64 `json:"length,omitempty"`
+}
+
+// A StringTransformSplit splits the input string into an array of strings.
+type StringTransformSplit struct {
+	// Separator at which to split the input string.
+	Separator string `json:"separator"`
+}
+
+// A StringTransformJoin joins an array into a string.
+type StringTransformJoin struct {
+	// Separator to place between joined elements.
+	// +optional
+	Separator string `json:"separator,omitempty"`
 }
 
 // A ConvertTransform converts the input into a new object whose type is supplied.
@@ -332,6 +904,7 @@ const (
 	ConnectionDetailTypeFromConnectionSecretKey ConnectionDetailType = "FromConnectionSecretKey" // Default
 	ConnectionDetailTypeFromFieldPath           ConnectionDetailType = "FromFieldPath"
 	ConnectionDetailTypeFromValue               ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeCombine                 ConnectionDetailType = "Combine"
 )
 
 // ConnectionDetail includes the information about the propagation of the connection
@@ -369,11 +942,29 @@ type ConnectionDetail struct {
 	// FromConnectionSecretKey when set.
 	// +optional
 	Value *string `json:"value,omitempty"`
+
+	// Combine the values of several connection secret keys or field paths of
+	// the composed resource into a single value. Name must be specified if
+	// Combine is specified.
+	// +optional
+	Combine *Combine `json:"combine,omitempty"`
+
+	// Transforms are the list of functions that are used as a FIFO pipe for
+	// the input to be transformed before it is propagated to the connection
+	// secret of the composition instance. Values read from a connection secret
+	// are supplied to the first transform as strings.
+	// +optional
+	Transforms []Transform `json:"transforms,omitempty"`
 }
 
 // CompositionStatus shows the observed state of the composition.
 type CompositionStatus struct {
 	xpv1.ConditionedStatus `json:",inline"`
+
+	// Usage is the number of composite resources that reference this
+	// Composition. A Composition cannot be deleted while it is in use.
+	// +optional
+	Usage int64 `json:"usage,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -384,6 +975,7 @@ type CompositionStatus struct {
 // type is created with reference to the composition.
 // [DEPRECATED]: Please use the identical v1 API instead. The v1beta1 API is
 // scheduled to be removed in Crossplane v1.6.
+// +kubebuilder:printcolumn:name="USAGE",type="integer",JSONPath=".status.usage"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories=crossplane
@@ -64,6 +64,18 @@ type CompositeResourceDefinitionSpec struct {
 	// +immutable
 	EnforcedCompositionRef *xpv1.Reference `json:"enforcedCompositionRef,omitempty"`
 
+	// CompositionSelectionPolicy determines how a Composition is selected when
+	// several Compositions match a composite resource's compositionSelector.
+	// Random selects one of them at random. Deterministic selects the
+	// Composition with the highest priority, per its
+	// apiextensions.crossplane.io/composition-priority annotation, breaking
+	// ties by name. Weighted selects one at random, weighted by its
+	// apiextensions.crossplane.io/composition-weight annotation.
+	// +optional
+	// +kubebuilder:validation:Enum=Random;Deterministic;Weighted
+	// +kubebuilder:default=Random
+	CompositionSelectionPolicy *CompositionSelectionPolicy `json:"compositionSelectionPolicy,omitempty"`
+
 	// Versions is the list of all API versions of the defined composite
 	// resource. Version names are used to compute the order in which served
 	// versions are listed in API discovery. If the version string is
@@ -80,6 +92,17 @@ type CompositeResourceDefinitionSpec struct {
 	Versions []CompositeResourceDefinitionVersion `json:"versions"`
 }
 
+// A CompositionSelectionPolicy determines how a Composition is selected when
+// several Compositions match a composite resource's compositionSelector.
+type CompositionSelectionPolicy string
+
+// Composition selection policies.
+const (
+	CompositionSelectionPolicyRandom        CompositionSelectionPolicy = "Random"
+	CompositionSelectionPolicyDeterministic CompositionSelectionPolicy = "Deterministic"
+	CompositionSelectionPolicyWeighted      CompositionSelectionPolicy = "Weighted"
+)
+
 // CompositeResourceDefinitionVersion describes a version of an XR.
 type CompositeResourceDefinitionVersion struct {
 	// Name of this version, e.g. “v1”, “v2beta1”, etc. Composite resources are
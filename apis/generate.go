@@ -25,6 +25,9 @@ limitations under the License.
 // Generate deepcopy methodsets and CRD manifests
 //go:generate go run -tags generate sigs.k8s.io/controller-tools/cmd/controller-gen object:headerFile=../hack/boilerplate.go.txt paths=./pkg/v1alpha1;./pkg/v1beta1;./pkg/v1;./apiextensions/... crd:crdVersions=v1 output:artifacts:config=../cluster/crds
 
+// Add validation that controller-gen cannot generate to CRD manifests
+//go:generate go run -tags generate ../hack/patch-crds ../cluster/crds
+
 // NOTE(hasheddan): we generate the meta.pkg.crossplane.io types separately as
 // the generated CRDs are never installed, only used for API documentation.
 //go:generate go run -tags generate sigs.k8s.io/controller-tools/cmd/controller-gen object:headerFile=../hack/boilerplate.go.txt paths=./pkg/meta/... crd:crdVersions=v1 output:artifacts:config=../docs/api-docs/crds
@@ -28,7 +28,9 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 
 	"github.com/crossplane/crossplane/internal/controller/apiextensions"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
 	"github.com/crossplane/crossplane/internal/controller/pkg"
+	"github.com/crossplane/crossplane/internal/webhook"
 	"github.com/crossplane/crossplane/internal/xpkg"
 )
 
@@ -39,6 +41,14 @@ type Command struct {
 	CacheDir       string
 	LeaderElection bool
 	Sync           time.Duration
+
+	WebhookTLSCertDir string
+	WebhookPort       int
+
+	EnableServerSideApply bool
+
+	EnableCompositionFunctions bool
+	CompositionFunctionsDir    string
 }
 
 // FromKingpin produces the core Crossplane command from a Kingpin command.
@@ -49,6 +59,11 @@ func FromKingpin(cmd *kingpin.CmdClause) (*Command, *InitCommand) {
 	cmd.Flag("cache-dir", "Directory used for caching package images.").Short('c').Default("/cache").OverrideDefaultFromEnvar("CACHE_DIR").StringVar(&c.CacheDir)
 	cmd.Flag("sync", "Controller manager sync period duration such as 300ms, 1.5h or 2h45m").Short('s').Default("1h").DurationVar(&c.Sync)
 	cmd.Flag("leader-election", "Use leader election for the conroller manager.").Short('l').Default("false").OverrideDefaultFromEnvar("LEADER_ELECTION").BoolVar(&c.LeaderElection)
+	startCmd.Flag("webhook-tls-cert-dir", "Directory containing the tls.crt and tls.key used to serve validating webhooks. Webhooks are disabled if unset.").OverrideDefaultFromEnvar("WEBHOOK_TLS_CERT_DIR").StringVar(&c.WebhookTLSCertDir)
+	startCmd.Flag("webhook-port", "Port used to serve validating webhooks.").Default("9443").OverrideDefaultFromEnvar("WEBHOOK_PORT").IntVar(&c.WebhookPort)
+	startCmd.Flag("enable-server-side-apply", "Use server-side apply to apply composite and composed resources.").Default("false").OverrideDefaultFromEnvar("ENABLE_SERVER_SIDE_APPLY").BoolVar(&c.EnableServerSideApply)
+	startCmd.Flag("enable-composition-functions", "Enable support for the alpha composition functions feature.").Default("false").OverrideDefaultFromEnvar("ENABLE_COMPOSITION_FUNCTIONS").BoolVar(&c.EnableCompositionFunctions)
+	startCmd.Flag("composition-functions-dir", "Directory containing the executables Exec composition functions may run. Exec functions may not run any executable if unset.").OverrideDefaultFromEnvar("COMPOSITION_FUNCTIONS_DIR").StringVar(&c.CompositionFunctionsDir)
 	initCmd := cmd.Command("init", "Make cluster ready for Crossplane controllers.")
 	init := &InitCommand{Name: initCmd.FullCommand()}
 	initCmd.Flag("provider", "Pre-install a Provider by giving its image URI. This argument can be repeated.").StringsVar(&init.Providers)
@@ -69,15 +84,29 @@ func (c *Command) Run(s *runtime.Scheme, log logging.Logger) error {
 		LeaderElection:   c.LeaderElection,
 		LeaderElectionID: "crossplane-leader-election-core",
 		SyncPeriod:       &c.Sync,
+		CertDir:          c.WebhookTLSCertDir,
+		Port:             c.WebhookPort,
 	})
 	if err != nil {
 		return errors.Wrap(err, "Cannot create manager")
 	}
 
-	if err := apiextensions.Setup(mgr, log); err != nil {
+	fo := composite.FunctionOptions{Enabled: c.EnableCompositionFunctions, ExecDir: c.CompositionFunctionsDir}
+	if c.EnableCompositionFunctions {
+		log.Info("Alpha feature enabled", "flag", "enable-composition-functions")
+	}
+
+	if err := apiextensions.Setup(mgr, log, apiextensions.Options{ServerSideApply: c.EnableServerSideApply, Functions: fo}); err != nil {
 		return errors.Wrap(err, "Cannot setup API extension controllers")
 	}
 
+	// The webhook server is only started if webhooks are registered.
+	if c.WebhookTLSCertDir != "" {
+		if err := webhook.Setup(mgr, log, webhook.Options{Functions: fo}); err != nil {
+			return errors.Wrap(err, "Cannot setup webhooks")
+		}
+	}
+
 	pkgCache := xpkg.NewImageCache(c.CacheDir, afero.NewOsFs())
 
 	if err := pkg.Setup(mgr, log, pkgCache, c.Namespace); err != nil {
@@ -0,0 +1,134 @@
+// +build generate
+
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// patch-crds adds OpenAPI validation to generated CRDs that controller-gen
+// cannot generate.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// patchedKinds are the kinds of CRD that we patch.
+var patchedKinds = map[string]bool{
+	"compositions.apiextensions.crossplane.io":         true,
+	"compositionrevisions.apiextensions.crossplane.io": true,
+}
+
+// mathPath is the path to the schema of a MathTransform, relative to the schema
+// of an array of transforms.
+var mathPath = []string{"items", "properties", "math"}
+
+// mathOperations are the properties of a MathTransform of which exactly one
+// must be set. controller-gen does not support the oneOf keyword.
+var mathOperations = []string{"multiply", "add", "subtract", "divide", "clamp", "round"}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s <crd-directory>\n", os.Args[0])
+		os.Exit(1)
+	}
+	files, err := filepath.Glob(filepath.Join(os.Args[1], "*.yaml"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range files {
+		if err := patch(f); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot patch %s: %s\n", f, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func patch(file string) error {
+	in, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return err
+	}
+
+	// controller-gen prefixes each CRD with a document separator.
+	prefix := []byte("\n---\n")
+	body := bytes.TrimPrefix(in, prefix)
+
+	crd := map[string]interface{}{}
+	if err := yaml.Unmarshal(body, &crd); err != nil {
+		return err
+	}
+	md, _ := crd["metadata"].(map[string]interface{})
+	if name, _ := md["name"].(string); !patchedKinds[name] {
+		return nil
+	}
+	if !walk(crd) {
+		return nil
+	}
+	out, err := yaml.Marshal(crd)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(in, prefix) {
+		out = append(prefix, out...)
+	}
+	return ioutil.WriteFile(file, out, 0600)
+}
+
+// walk the supplied value, adding validation to the schema of any
+// MathTransform within an array of transforms. It returns true if any schema
+// was patched.
+func walk(v interface{}) bool {
+	patched := false
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if props, ok := t["properties"].(map[string]interface{}); ok {
+			if s, ok := lookup(props["transforms"], mathPath...).(map[string]interface{}); ok {
+				oneOf := make([]interface{}, len(mathOperations))
+				for i, op := range mathOperations {
+					oneOf[i] = map[string]interface{}{"required": []interface{}{op}}
+				}
+				s["oneOf"] = oneOf
+				patched = true
+			}
+		}
+		for _, e := range t {
+			patched = walk(e) || patched
+		}
+	case []interface{}:
+		for _, e := range t {
+			patched = walk(e) || patched
+		}
+	}
+	return patched
+}
+
+// lookup returns the value at the supplied path of nested maps, or nil.
+func lookup(v interface{}, path ...string) interface{} {
+	for _, k := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
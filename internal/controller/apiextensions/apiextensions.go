@@ -21,15 +21,38 @@ import (
 
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composition"
 	"github.com/crossplane/crossplane/internal/controller/apiextensions/definition"
 	"github.com/crossplane/crossplane/internal/controller/apiextensions/offered"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/usage"
 )
 
+// Options configures the API extensions controllers.
+type Options struct {
+	// ServerSideApply specifies that composite resources and their composed
+	// resources should be applied using server-side apply.
+	ServerSideApply bool
+
+	// Functions configures whether and how composite resources may run
+	// composition functions.
+	Functions composite.FunctionOptions
+}
+
 // Setup API extensions controllers.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o Options) error {
+	dopts := []definition.ReconcilerOption{definition.WithFunctionOptions(o.Functions)}
+	var oopts []offered.ReconcilerOption
+	if o.ServerSideApply {
+		dopts = append(dopts, definition.WithServerSideApply())
+		oopts = append(oopts, offered.WithServerSideApply())
+	}
+
 	for _, setup := range []func(ctrl.Manager, logging.Logger) error{
-		definition.Setup,
-		offered.Setup,
+		composition.Setup,
+		func(mgr ctrl.Manager, l logging.Logger) error { return definition.Setup(mgr, l, dopts...) },
+		func(mgr ctrl.Manager, l logging.Logger) error { return offered.Setup(mgr, l, oopts...) },
+		usage.Setup,
 	} {
 		if err := setup(mgr, l); err != nil {
 			return err
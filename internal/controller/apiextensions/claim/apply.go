@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package claim
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Error strings.
+const (
+	errCreateComposite        = "cannot create composite resource"
+	errApplyComposite         = "cannot server-side apply composite resource"
+	errUnsupportedApplyObject = "cannot server-side apply an object that is not unstructured"
+)
+
+// FieldOwnerXR is the field manager Crossplane uses when it server-side applies
+// a claim's composite resource.
+const FieldOwnerXR = "apiextensions.crossplane.io/claim"
+
+// Composite resource spec fields that are set by the composite resource's
+// controller, or when a claim binds to a composite resource, rather than
+// configured by the claim.
+var compositeManagedSpecFields = []string{"resourceRefs", "claimRef", "writeConnectionSecretToRef"}
+
+// The composite resource's controller also manages its Composition revision
+// reference, unless its Composition update policy is Manual.
+const (
+	fieldCompositionRevisionRef  = "compositionRevisionRef"
+	fieldCompositionUpdatePolicy = "compositionUpdatePolicy"
+)
+
+// An APIServerSideApplicator applies composite resources using server-side
+// apply. Only the fields a claim configures - the composite resource's labels,
+// annotations, and spec - are applied, so that the claim does not take
+// ownership of fields that are set by the composite resource's controller.
+type APIServerSideApplicator struct {
+	client client.Client
+}
+
+// NewAPIServerSideApplicator returns an Applicator that applies composite
+// resources using server-side apply.
+func NewAPIServerSideApplicator(c client.Client) *APIServerSideApplicator {
+	return &APIServerSideApplicator{client: c}
+}
+
+// Apply the supplied composite resource. Composite resources that are yet to be
+// named are created, because a server-side apply cannot generate a name. A
+// claim is the source of truth for its composite resource's spec, so the claim
+// takes ownership of any fields it applies that are owned by other field
+// managers. Spec fields that are managed by the composite resource's controller
+// are never applied, so that the claim never overwrites them with stale values.
+// ApplyOptions are not supported and are ignored.
+func (a *APIServerSideApplicator) Apply(ctx context.Context, o client.Object, _ ...resource.ApplyOption) error {
+	if o.GetName() == "" && o.GetGenerateName() != "" {
+		return errors.Wrap(a.client.Create(ctx, o), errCreateComposite)
+	}
+
+	u, ok := o.(runtime.Unstructured)
+	if !ok {
+		return errors.New(errUnsupportedApplyObject)
+	}
+
+	desired := &kunstructured.Unstructured{Object: map[string]interface{}{}}
+	desired.SetGroupVersionKind(o.GetObjectKind().GroupVersionKind())
+	desired.SetName(o.GetName())
+	desired.SetNamespace(o.GetNamespace())
+	desired.SetLabels(o.GetLabels())
+	desired.SetAnnotations(o.GetAnnotations())
+	if spec, ok := u.UnstructuredContent()["spec"].(map[string]interface{}); ok {
+		managed := compositeManagedSpecFields
+		if spec[fieldCompositionUpdatePolicy] != string(v1.UpdateManual) {
+			managed = append([]string{fieldCompositionRevisionRef}, managed...)
+		}
+		desired.Object["spec"] = filter(spec, managed...)
+	}
+
+	if err := a.client.Patch(ctx, desired, client.Apply, client.FieldOwner(FieldOwnerXR), client.ForceOwnership); err != nil {
+		return errors.Wrap(err, errApplyComposite)
+	}
+
+	u.SetUnstructuredContent(desired.Object)
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package claim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestAPIServerSideApplicator(t *testing.T) {
+	errBoom := errors.New("boom")
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XR"}
+
+	unnamed := composite.New()
+	unnamed.SetGenerateName("cool-")
+
+	// xr returns a composite resource with fields that were configured by its
+	// claim, and fields that were not.
+	xr := func() *composite.Unstructured {
+		cp := composite.New(composite.WithGroupVersionKind(gvk))
+		cp.SetName("cool-xr")
+		cp.SetResourceVersion("42")
+		cp.SetLabels(map[string]string{"cool": "label"})
+		cp.SetFinalizers([]string{"cool-finalizer"})
+		cp.Object["spec"] = map[string]interface{}{"coolness": "high"}
+		cp.Object["status"] = map[string]interface{}{"ready": true}
+		return cp
+	}
+
+	type args struct {
+		client client.Client
+		o      client.Object
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"CreateError": {
+			reason: "We should return any error encountered creating an unnamed composite resource.",
+			args: args{
+				client: &test.MockClient{MockCreate: test.NewMockCreateFn(errBoom)},
+				o:      unnamed,
+			},
+			want: errors.Wrap(errBoom, errCreateComposite),
+		},
+		"NotUnstructured": {
+			reason: "We should return an error if the composite resource is not unstructured.",
+			args: args{
+				o: &fake.Composite{},
+			},
+			want: errors.New(errUnsupportedApplyObject),
+		},
+		"PatchError": {
+			reason: "We should return any error encountered applying the composite resource.",
+			args: args{
+				client: &test.MockClient{MockPatch: test.NewMockPatchFn(errBoom)},
+				o:      xr(),
+			},
+			want: errors.Wrap(errBoom, errApplyComposite),
+		},
+		"Success": {
+			reason: "We should apply only the composite resource's labels, annotations, and spec.",
+			args: args{
+				client: &test.MockClient{
+					MockPatch: func(_ context.Context, obj client.Object, p client.Patch, opts ...client.PatchOption) error {
+						if diff := cmp.Diff(types.ApplyPatchType, p.Type()); diff != "" {
+							t.Errorf("Patch(...): -want patch type, +got patch type:\n%s", diff)
+						}
+						po := &client.PatchOptions{}
+						po.ApplyOptions(opts)
+						if diff := cmp.Diff(FieldOwnerXR, po.FieldManager); diff != "" {
+							t.Errorf("Patch(...): -want field manager, +got field manager:\n%s", diff)
+						}
+						want := composite.New(composite.WithGroupVersionKind(gvk))
+						want.SetName("cool-xr")
+						want.SetLabels(map[string]string{"cool": "label"})
+						want.Object["spec"] = map[string]interface{}{"coolness": "high"}
+						if diff := cmp.Diff(want.GetUnstructured(), obj); diff != "" {
+							t.Errorf("Patch(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					},
+				},
+				o: xr(),
+			},
+		},
+		"CompositeManagedFields": {
+			reason: "We should not apply spec fields that are managed by the composite resource's controller.",
+			args: args{
+				client: &test.MockClient{
+					MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+						want := map[string]interface{}{
+							"coolness":       "high",
+							"compositionRef": map[string]interface{}{"name": "cool-composition"},
+						}
+						got := obj.(*kunstructured.Unstructured).Object["spec"]
+						if diff := cmp.Diff(want, got); diff != "" {
+							t.Errorf("Patch(...): -want spec, +got spec:\n%s", diff)
+						}
+						return nil
+					},
+				},
+				o: func() *composite.Unstructured {
+					cp := xr()
+					cp.Object["spec"] = map[string]interface{}{
+						"coolness":                   "high",
+						"compositionRef":             map[string]interface{}{"name": "cool-composition"},
+						"resourceRefs":               []interface{}{map[string]interface{}{"name": "stale"}},
+						"claimRef":                   map[string]interface{}{"name": "cool-claim"},
+						"writeConnectionSecretToRef": map[string]interface{}{"name": "cool-secret"},
+					}
+					return cp
+				}(),
+			},
+		},
+		"AutomaticRevision": {
+			reason: "We should not apply the Composition revision reference when the composite resource's controller manages it.",
+			args: args{
+				client: &test.MockClient{
+					MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+						want := map[string]interface{}{
+							"compositionRef": map[string]interface{}{"name": "cool-composition"},
+						}
+						got := obj.(*kunstructured.Unstructured).Object["spec"]
+						if diff := cmp.Diff(want, got); diff != "" {
+							t.Errorf("Patch(...): -want spec, +got spec:\n%s", diff)
+						}
+						return nil
+					},
+				},
+				o: func() *composite.Unstructured {
+					cp := xr()
+					cp.Object["spec"] = map[string]interface{}{
+						"compositionRef":         map[string]interface{}{"name": "cool-composition"},
+						"compositionRevisionRef": map[string]interface{}{"name": "cool-composition-stale"},
+					}
+					return cp
+				}(),
+			},
+		},
+		"ManualRevision": {
+			reason: "We should apply the Composition revision reference when the composite resource's update policy is Manual.",
+			args: args{
+				client: &test.MockClient{
+					MockPatch: func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+						want := map[string]interface{}{
+							"compositionRevisionRef":  map[string]interface{}{"name": "cool-composition-pinned"},
+							"compositionUpdatePolicy": "Manual",
+						}
+						got := obj.(*kunstructured.Unstructured).Object["spec"]
+						if diff := cmp.Diff(want, got); diff != "" {
+							t.Errorf("Patch(...): -want spec, +got spec:\n%s", diff)
+						}
+						return nil
+					},
+				},
+				o: func() *composite.Unstructured {
+					cp := xr()
+					cp.Object["spec"] = map[string]interface{}{
+						"compositionRevisionRef":  map[string]interface{}{"name": "cool-composition-pinned"},
+						"compositionUpdatePolicy": "Manual",
+					}
+					return cp
+				}(),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewAPIServerSideApplicator(tc.args.client)
+			err := a.Apply(context.Background(), tc.args.o)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\na.Apply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/claim"
@@ -36,8 +37,13 @@ const (
 	errUnsupportedDstObject = "destination object was not valid object"
 	errUnsupportedSrcObject = "source object was not valid object"
 
-	errMergeClaimSpec   = "unable to merge claim spec"
-	errMergeClaimStatus = "unable to merge claim status"
+	errMergeClaimSpec            = "unable to merge claim spec"
+	errMergeClaimStatus          = "unable to merge claim status"
+	errPropagateResourceStatuses = "unable to propagate composed resource statuses to claim"
+)
+
+const (
+	fieldResourceStatuses = "status.resourceStatuses"
 )
 
 // ConfigureComposite configures the supplied composite resource. The composite resource name
@@ -141,6 +147,15 @@ func (c *APIClaimConfigurator) Configure(ctx context.Context, cr resource.Compos
 		return errors.Wrap(err, errMergeClaimStatus)
 	}
 
+	// The statuses of composed resources are filtered out of the merge above
+	// along with the other status fields Crossplane manages, but claims should
+	// report them too.
+	if rs, err := fieldpath.Pave(ucp.Object).GetValue(fieldResourceStatuses); err == nil {
+		if err := fieldpath.Pave(ucr.Object).SetValue(fieldResourceStatuses, rs); err != nil {
+			return errors.Wrap(err, errPropagateResourceStatuses)
+		}
+	}
+
 	if err := c.client.Status().Update(ctx, cr); err != nil {
 		return errors.Wrap(err, errUpdateClaimStatus)
 	}
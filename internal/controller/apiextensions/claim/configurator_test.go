@@ -530,6 +530,56 @@ func TestClaimConfigure(t *testing.T) {
 				},
 			},
 		},
+		"PropagateResourceStatuses": {
+			reason: "The statuses of composed resources should be propagated from the composite",
+			args: args{
+				client: test.NewMockClient(),
+				cm: &claim.Unstructured{
+					Unstructured: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"spec":   map[string]interface{}{},
+							"status": map[string]interface{}{},
+						},
+					},
+				},
+				cp: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"spec": map[string]interface{}{},
+							"status": map[string]interface{}{
+								"resourceStatuses": []interface{}{
+									map[string]interface{}{
+										"templateName": "cluster",
+										"ready":        false,
+										"synced":       false,
+										"message":      "boom",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				cm: &claim.Unstructured{
+					Unstructured: unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"spec": map[string]interface{}{},
+							"status": map[string]interface{}{
+								"resourceStatuses": []interface{}{
+									map[string]interface{}{
+										"templateName": "cluster",
+										"ready":        false,
+										"synced":       false,
+										"message":      "boom",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		"ConfigureStatus": {
 			reason: "Status of claim should be overwritten by the composite",
 			args: args{
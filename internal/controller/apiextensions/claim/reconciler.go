@@ -407,8 +407,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		record.Event(cm, event.Normal(reasonBind, "Composite resource is not yet ready"))
 
 		// We should be watching the composite resource and will have a request
-		// queued if it changes.
-		cm.SetConditions(Waiting())
+		// queued if it changes. The composite resource's Ready condition tells
+		// us which of its composed resources are not yet ready.
+		cm.SetConditions(Waiting().WithMessage(cp.GetCondition(xpv1.TypeReady).Message))
 		return reconcile.Result{}, errors.Wrap(r.client.Status().Update(ctx, cm), errUpdateClaimStatus)
 	}
 
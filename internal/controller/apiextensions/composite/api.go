@@ -18,21 +18,30 @@ package composite
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	"github.com/crossplane/crossplane/internal/xcrd"
@@ -44,11 +53,32 @@ const (
 
 	errNoCompatibleComposition  = "no compatible composition has been found"
 	errListCompositions         = "cannot list compositions"
+	errInvalidSelector          = "invalid composition selector"
 	errUpdateComposite          = "cannot update composite resource"
 	errCompositionNotCompatible = "referenced composition is not compatible with this composite resource"
 	errGetXRD                   = "cannot get composite resource definition"
+	errGetComposition           = "cannot get Composition"
+	errSelectComposition        = "cannot select composition"
+	errUpdateCompositeStatus    = "cannot update composite resource status"
+
+	errZeroWeight                = "all compatible compositions have a weight of zero"
+	errFmtNegativeWeight         = "composition %q has a negative weight"
+	errFmtWeightTooLarge         = "composition %q has a weight greater than %d"
+	errFmtParseAnnotation        = "cannot parse %s annotation of composition %q"
+	errFmtUnknownSelectionPolicy = "unknown composition selection policy %q"
 )
 
+// Composition selection messages.
+const (
+	fmtSelectedRandom        = "Selected composition %q at random from %d compatible compositions"
+	fmtSelectedDeterministic = "Selected composition %q with the highest priority (%d) of %d compatible compositions"
+	fmtSelectedWeighted      = "Selected composition %q at random with weight %d of a total weight of %d"
+)
+
+// maxCompositionWeight is the greatest weight a Composition may have when using
+// the Weighted selection policy.
+const maxCompositionWeight = math.MaxInt32
+
 // Event reasons.
 const (
 	reasonCompositionSelection event.Reason = "CompositionSelection"
@@ -113,6 +143,25 @@ func (a *APIFilteredSecretPublisher) UnpublishConnection(_ context.Context, _ re
 	return nil
 }
 
+// An APICompositionFetcher fetches the Composition referenced by a composite
+// resource from the API server.
+type APICompositionFetcher struct {
+	client client.Client
+}
+
+// NewAPICompositionFetcher returns a CompositionFetcher that fetches the
+// Composition referenced by a composite resource.
+func NewAPICompositionFetcher(c client.Client) *APICompositionFetcher {
+	return &APICompositionFetcher{client: c}
+}
+
+// Fetch the Composition referenced by the supplied composite resource.
+func (f *APICompositionFetcher) Fetch(ctx context.Context, cr resource.Composite) (*v1.Composition, error) {
+	comp := &v1.Composition{}
+	err := f.client.Get(ctx, meta.NamespacedNameOf(cr.GetCompositionReference()), comp)
+	return comp, errors.Wrap(err, errGetComposition)
+}
+
 // NewCompositionSelectorChain returns a new CompositionSelectorChain.
 func NewCompositionSelectorChain(list ...CompositionSelector) *CompositionSelectorChain {
 	return &CompositionSelectorChain{list: list}
@@ -134,44 +183,85 @@ func (r *CompositionSelectorChain) SelectComposition(ctx context.Context, cp res
 	return nil
 }
 
+// An APILabelSelectorResolverOption configures an APILabelSelectorResolver.
+type APILabelSelectorResolverOption func(*APILabelSelectorResolver)
+
+// WithSelectionPolicyFrom configures an APILabelSelectorResolver to select
+// among several compatible Compositions using the selection policy of the
+// supplied CompositeResourceDefinition. Compositions are selected at random by
+// default.
+func WithSelectionPolicyFrom(xrd corev1.ObjectReference) APILabelSelectorResolverOption {
+	return func(r *APILabelSelectorResolver) {
+		r.xrd = &xrd
+	}
+}
+
+// WithSelectionRecorder specifies how an APILabelSelectorResolver should
+// record events.
+func WithSelectionRecorder(er event.Recorder) APILabelSelectorResolverOption {
+	return func(r *APILabelSelectorResolver) {
+		r.recorder = er
+	}
+}
+
 // NewAPILabelSelectorResolver returns a SelectorResolver for composite resource.
-func NewAPILabelSelectorResolver(c client.Client) *APILabelSelectorResolver {
-	return &APILabelSelectorResolver{client: c}
+func NewAPILabelSelectorResolver(c client.Client, o ...APILabelSelectorResolverOption) *APILabelSelectorResolver {
+	r := &APILabelSelectorResolver{
+		client:   c,
+		recorder: event.NewNopRecorder(),
+		// We don't need this choice to be cryptographically random.
+		random: rand.New(rand.NewSource(time.Now().UnixNano())), // nolint:gosec
+	}
+	for _, fn := range o {
+		fn(r)
+	}
+	return r
 }
 
 // APILabelSelectorResolver is used to resolve the composition selector on the instance
 // to composition reference.
 type APILabelSelectorResolver struct {
-	client client.Client
+	client   client.Client
+	xrd      *corev1.ObjectReference
+	recorder event.Recorder
+
+	mu     sync.Mutex
+	random *rand.Rand
 }
 
 // SelectComposition resolves selector to a reference if it doesn't exist.
 func (r *APILabelSelectorResolver) SelectComposition(ctx context.Context, cp resource.Composite) error {
-	// TODO(muvaf): need to block the deletion of composition via finalizer once
-	// it's selected since it's integral to this resource.
 	// TODO(muvaf): We don't rely on UID in practice. It should not be there
 	// because it will make confusion if the resource is backed up and restored
 	// to another cluster
 	if cp.GetCompositionReference() != nil {
 		return nil
 	}
-	labels := map[string]string{}
-	sel := cp.GetCompositionSelector()
-	if sel != nil {
-		labels = sel.MatchLabels
+	sel := labels.Everything()
+	if ls := cp.GetCompositionSelector(); ls != nil {
+		s, err := metav1.LabelSelectorAsSelector(ls)
+		if err != nil {
+			return errors.Wrap(err, errInvalidSelector)
+		}
+		sel = s
 	}
 	list := &v1.CompositionList{}
-	if err := r.client.List(ctx, list, client.MatchingLabels(labels)); err != nil {
+	if err := r.client.List(ctx, list, client.MatchingLabelsSelector{Selector: sel}); err != nil {
 		return errors.Wrap(err, errListCompositions)
 	}
 
-	candidates := make([]string, 0, len(list.Items))
+	candidates := make([]v1.Composition, 0, len(list.Items))
 	v, k := cp.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
 
 	for _, comp := range list.Items {
+		// A Composition that is being deleted, for example one whose deletion
+		// is blocked while it is in use, must not gain new users.
+		if meta.WasDeleted(&comp) {
+			continue
+		}
 		if comp.Spec.CompositeTypeRef.APIVersion == v && comp.Spec.CompositeTypeRef.Kind == k {
 			// This composition is compatible with our composite resource.
-			candidates = append(candidates, comp.Name)
+			candidates = append(candidates, comp)
 		}
 	}
 
@@ -179,11 +269,116 @@ func (r *APILabelSelectorResolver) SelectComposition(ctx context.Context, cp res
 		return errors.New(errNoCompatibleComposition)
 	}
 
-	// We don't need this choice to be cryptographically random.
-	random := rand.New(rand.NewSource(time.Now().UnixNano())) // nolint:gosec
-	selected := candidates[random.Intn(len(candidates))]
+	policy := v1.CompositionSelectionPolicyRandom
+	if r.xrd != nil {
+		def := &v1.CompositeResourceDefinition{}
+		if err := r.client.Get(ctx, meta.NamespacedNameOf(r.xrd), def); err != nil {
+			return errors.Wrap(err, errGetXRD)
+		}
+		policy = def.GetCompositionSelectionPolicy()
+	}
+
+	r.mu.Lock()
+	selected, msg, err := SelectCompositionByPolicy(policy, candidates, r.random)
+	r.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, errSelectComposition)
+	}
+
 	cp.SetCompositionReference(&corev1.ObjectReference{Name: selected})
-	return errors.Wrap(r.client.Update(ctx, cp), errUpdateComposite)
+	if err := r.client.Update(ctx, cp); err != nil {
+		return errors.Wrap(err, errUpdateComposite)
+	}
+	r.recorder.Event(cp, event.Normal(reasonCompositionSelection, msg, "policy", string(policy)))
+
+	// We can only record how the Composition was selected in the status of
+	// an unstructured composite resource.
+	ucp, ok := cp.(*composite.Unstructured)
+	if !ok {
+		return nil
+	}
+	_ = fieldpath.Pave(ucp.Object).SetValue("status.compositionSelection", map[string]interface{}{
+		"policy":  string(policy),
+		"message": msg,
+	})
+	return errors.Wrap(r.client.Status().Update(ctx, cp), errUpdateCompositeStatus)
+}
+
+// SelectCompositionByPolicy selects one of the supplied candidate Compositions
+// per the supplied policy. It returns the name of the selected Composition and
+// a message explaining why it was selected.
+func SelectCompositionByPolicy(p v1.CompositionSelectionPolicy, candidates []v1.Composition, random *rand.Rand) (string, string, error) {
+	if len(candidates) == 0 {
+		return "", "", errors.New(errNoCompatibleComposition)
+	}
+
+	switch p {
+	case v1.CompositionSelectionPolicyRandom:
+		selected := candidates[random.Intn(len(candidates))].GetName()
+		return selected, fmt.Sprintf(fmtSelectedRandom, selected, len(candidates)), nil
+
+	case v1.CompositionSelectionPolicyDeterministic:
+		priorities := make(map[string]int64, len(candidates))
+		for _, c := range candidates {
+			p, err := annotationInt(c, v1.AnnotationCompositionPriority, 0)
+			if err != nil {
+				return "", "", err
+			}
+			priorities[c.GetName()] = p
+		}
+		sorted := make([]v1.Composition, len(candidates))
+		copy(sorted, candidates)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			pi, pj := priorities[sorted[i].GetName()], priorities[sorted[j].GetName()]
+			if pi != pj {
+				return pi > pj
+			}
+			return sorted[i].GetName() < sorted[j].GetName()
+		})
+		selected := sorted[0].GetName()
+		return selected, fmt.Sprintf(fmtSelectedDeterministic, selected, priorities[selected], len(candidates)), nil
+
+	case v1.CompositionSelectionPolicyWeighted:
+		total := int64(0)
+		weights := make([]int64, len(candidates))
+		for i, c := range candidates {
+			w, err := annotationInt(c, v1.AnnotationCompositionWeight, 1)
+			if err != nil {
+				return "", "", err
+			}
+			if w < 0 {
+				return "", "", errors.Errorf(errFmtNegativeWeight, c.GetName())
+			}
+			// Bounding each weight ensures their sum can't overflow.
+			if w > maxCompositionWeight {
+				return "", "", errors.Errorf(errFmtWeightTooLarge, c.GetName(), maxCompositionWeight)
+			}
+			weights[i] = w
+			total += w
+		}
+		if total == 0 {
+			return "", "", errors.New(errZeroWeight)
+		}
+		n := random.Int63n(total)
+		for i, w := range weights {
+			if n < w {
+				selected := candidates[i].GetName()
+				return selected, fmt.Sprintf(fmtSelectedWeighted, selected, w, total), nil
+			}
+			n -= w
+		}
+	}
+
+	return "", "", errors.Errorf(errFmtUnknownSelectionPolicy, p)
+}
+
+func annotationInt(comp v1.Composition, key string, def int64) (int64, error) {
+	v, ok := comp.GetAnnotations()[key]
+	if !ok {
+		return def, nil
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	return i, errors.Wrapf(err, errFmtParseAnnotation, key, comp.GetName())
 }
 
 // NewAPIDefaultCompositionSelector returns a APIDefaultCompositionSelector.
@@ -17,6 +17,9 @@ package composite
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -33,6 +36,7 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
@@ -251,9 +255,34 @@ func TestSelectorResolver(t *testing.T) {
 		},
 	}
 	sel := &metav1.LabelSelector{MatchLabels: map[string]string{"select": "me"}}
+	expr := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"provider": "aws"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "deprecated", Operator: metav1.LabelSelectorOpDoesNotExist},
+		},
+	}
+	invalid := &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "deprecated", Operator: "Bogus"},
+		},
+	}
+
+	prioritized := comp.DeepCopy()
+	prioritized.SetName("bar")
+	prioritized.SetAnnotations(map[string]string{v1.AnnotationCompositionPriority: "10"})
+	deterministic := v1.CompositionSelectionPolicyDeterministic
+	selected := composite.New()
+	selected.SetCompositionReference(&corev1.ObjectReference{Name: prioritized.GetName()})
+	selected.Object["status"] = map[string]interface{}{
+		"compositionSelection": map[string]interface{}{
+			"policy":  string(deterministic),
+			"message": fmt.Sprintf(fmtSelectedDeterministic, prioritized.GetName(), 10, 2),
+		},
+	}
 
 	type args struct {
 		kube client.Client
+		opts []APILabelSelectorResolverOption
 		cp   resource.Composite
 	}
 	type want struct {
@@ -290,6 +319,23 @@ func TestSelectorResolver(t *testing.T) {
 				err: errors.Wrap(errBoom, errListCompositions),
 			},
 		},
+		"OnlyDeleted": {
+			reason: "Should fail if the only compatible Composition is being deleted",
+			args: args{
+				kube: &test.MockClient{MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+					deleted := comp.DeepCopy()
+					now := metav1.Now()
+					deleted.SetDeletionTimestamp(&now)
+					obj.(*v1.CompositionList).Items = []v1.Composition{*deleted}
+					return nil
+				})},
+				cp: &fake.Composite{},
+			},
+			want: want{
+				cp:  &fake.Composite{},
+				err: errors.New(errNoCompatibleComposition),
+			},
+		},
 		"NoneCompatible": {
 			reason: "Should fail if it cannot find a compatible Composition",
 			args: args{
@@ -305,6 +351,85 @@ func TestSelectorResolver(t *testing.T) {
 				err: errors.New(errNoCompatibleComposition),
 			},
 		},
+		"InvalidSelector": {
+			reason: "Should fail if the composition selector is invalid",
+			args: args{
+				cp: &fake.Composite{
+					CompositionSelector: fake.CompositionSelector{Sel: invalid},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					CompositionSelector: fake.CompositionSelector{Sel: invalid},
+				},
+				err: errors.Wrap(errors.New(`"Bogus" is not a valid pod selector operator`), errInvalidSelector),
+			},
+		},
+		"MatchExpressions": {
+			reason: "Should list Compositions using both the match labels and match expressions of the selector",
+			args: args{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+					MockList: func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+						lo := &client.ListOptions{}
+						lo.ApplyOptions(opts)
+						if diff := cmp.Diff("!deprecated,provider=aws", lo.LabelSelector.String()); diff != "" {
+							t.Errorf("List(...): -want selector, +got selector:\n%s", diff)
+						}
+						obj.(*v1.CompositionList).Items = []v1.Composition{*comp}
+						return nil
+					}},
+				cp: &fake.Composite{
+					CompositionSelector: fake.CompositionSelector{Sel: expr},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					CompositionReferencer: fake.CompositionReferencer{Ref: &corev1.ObjectReference{Name: comp.Name}},
+					CompositionSelector:   fake.CompositionSelector{Sel: expr},
+				},
+			},
+		},
+		"GetXRDError": {
+			reason: "Should fail if the XRD that configures the selection policy cannot be fetched",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(errBoom),
+					MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+						obj.(*v1.CompositionList).Items = []v1.Composition{*comp}
+						return nil
+					},
+				},
+				opts: []APILabelSelectorResolverOption{WithSelectionPolicyFrom(corev1.ObjectReference{Name: "xrd"})},
+				cp:   &fake.Composite{},
+			},
+			want: want{
+				cp:  &fake.Composite{},
+				err: errors.Wrap(errBoom, errGetXRD),
+			},
+		},
+		"SelectedByPolicy": {
+			reason: "Should select a Composition using the XRD's selection policy, and record how it was selected",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						obj.(*v1.CompositeResourceDefinition).Spec.CompositionSelectionPolicy = &deterministic
+						return nil
+					}),
+					MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+						obj.(*v1.CompositionList).Items = []v1.Composition{*comp, *prioritized}
+						return nil
+					},
+					MockUpdate:       test.NewMockUpdateFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+				},
+				opts: []APILabelSelectorResolverOption{WithSelectionPolicyFrom(corev1.ObjectReference{Name: "xrd"})},
+				cp:   composite.New(),
+			},
+			want: want{
+				cp: selected,
+			},
+		},
 		"SelectedTheCompatibleOne": {
 			reason: "Should select the one that is compatible",
 			args: args{
@@ -342,7 +467,7 @@ func TestSelectorResolver(t *testing.T) {
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			c := NewAPILabelSelectorResolver(tc.args.kube)
+			c := NewAPILabelSelectorResolver(tc.args.kube, tc.args.opts...)
 			err := c.SelectComposition(context.Background(), tc.args.cp)
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
 				t.Errorf("\n%s\nSelectComposition(...): -want, +got:\n%s", tc.reason, diff)
@@ -354,6 +479,172 @@ func TestSelectorResolver(t *testing.T) {
 	}
 }
 
+func TestSelectCompositionByPolicy(t *testing.T) {
+	withAnnotations := func(name string, a map[string]string) v1.Composition {
+		c := v1.Composition{}
+		c.SetName(name)
+		c.SetAnnotations(a)
+		return c
+	}
+
+	type args struct {
+		p          v1.CompositionSelectionPolicy
+		candidates []v1.Composition
+	}
+	type want struct {
+		selected string
+		msg      string
+		err      error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Random": {
+			reason: "We should select a Composition at random.",
+			args: args{
+				p:          v1.CompositionSelectionPolicyRandom,
+				candidates: []v1.Composition{withAnnotations("a", nil)},
+			},
+			want: want{
+				selected: "a",
+				msg:      fmt.Sprintf(fmtSelectedRandom, "a", 1),
+			},
+		},
+		"DeterministicByPriority": {
+			reason: "We should select the Composition with the highest priority.",
+			args: args{
+				p: v1.CompositionSelectionPolicyDeterministic,
+				candidates: []v1.Composition{
+					withAnnotations("a", nil),
+					withAnnotations("b", map[string]string{v1.AnnotationCompositionPriority: "2"}),
+					withAnnotations("c", map[string]string{v1.AnnotationCompositionPriority: "1"}),
+				},
+			},
+			want: want{
+				selected: "b",
+				msg:      fmt.Sprintf(fmtSelectedDeterministic, "b", 2, 3),
+			},
+		},
+		"DeterministicByName": {
+			reason: "We should select the Composition whose name sorts first if priorities are equal.",
+			args: args{
+				p: v1.CompositionSelectionPolicyDeterministic,
+				candidates: []v1.Composition{
+					withAnnotations("c", nil),
+					withAnnotations("a", map[string]string{v1.AnnotationCompositionPriority: "0"}),
+					withAnnotations("b", nil),
+				},
+			},
+			want: want{
+				selected: "a",
+				msg:      fmt.Sprintf(fmtSelectedDeterministic, "a", 0, 3),
+			},
+		},
+		"InvalidPriority": {
+			reason: "We should return an error if a priority is not an integer.",
+			args: args{
+				p:          v1.CompositionSelectionPolicyDeterministic,
+				candidates: []v1.Composition{withAnnotations("a", map[string]string{v1.AnnotationCompositionPriority: "high"})},
+			},
+			want: want{
+				err: errors.Wrapf(&strconv.NumError{Func: "ParseInt", Num: "high", Err: strconv.ErrSyntax}, errFmtParseAnnotation, v1.AnnotationCompositionPriority, "a"),
+			},
+		},
+		"Weighted": {
+			reason: "We should never select a Composition with a weight of zero.",
+			args: args{
+				p: v1.CompositionSelectionPolicyWeighted,
+				candidates: []v1.Composition{
+					withAnnotations("a", map[string]string{v1.AnnotationCompositionWeight: "0"}),
+					withAnnotations("b", nil),
+					withAnnotations("c", map[string]string{v1.AnnotationCompositionWeight: "0"}),
+				},
+			},
+			want: want{
+				selected: "b",
+				msg:      fmt.Sprintf(fmtSelectedWeighted, "b", 1, 1),
+			},
+		},
+		"NegativeWeight": {
+			reason: "We should return an error if a weight is negative.",
+			args: args{
+				p:          v1.CompositionSelectionPolicyWeighted,
+				candidates: []v1.Composition{withAnnotations("a", map[string]string{v1.AnnotationCompositionWeight: "-1"})},
+			},
+			want: want{
+				err: errors.Errorf(errFmtNegativeWeight, "a"),
+			},
+		},
+		"WeightTooLarge": {
+			reason: "We should return an error rather than overflow if a weight is too large.",
+			args: args{
+				p: v1.CompositionSelectionPolicyWeighted,
+				candidates: []v1.Composition{
+					withAnnotations("a", map[string]string{v1.AnnotationCompositionWeight: "9223372036854775807"}),
+					withAnnotations("b", map[string]string{v1.AnnotationCompositionWeight: "9223372036854775807"}),
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtWeightTooLarge, "a", maxCompositionWeight),
+			},
+		},
+		"MaxWeight": {
+			reason: "We should accept many Compositions with the greatest allowed weight.",
+			args: args{
+				p: v1.CompositionSelectionPolicyWeighted,
+				candidates: []v1.Composition{
+					withAnnotations("a", map[string]string{v1.AnnotationCompositionWeight: "2147483647"}),
+					withAnnotations("b", map[string]string{v1.AnnotationCompositionWeight: "0"}),
+					withAnnotations("c", map[string]string{v1.AnnotationCompositionWeight: "0"}),
+				},
+			},
+			want: want{
+				selected: "a",
+				msg:      fmt.Sprintf(fmtSelectedWeighted, "a", 2147483647, 2147483647),
+			},
+		},
+		"ZeroWeight": {
+			reason: "We should return an error if all weights are zero.",
+			args: args{
+				p:          v1.CompositionSelectionPolicyWeighted,
+				candidates: []v1.Composition{withAnnotations("a", map[string]string{v1.AnnotationCompositionWeight: "0"})},
+			},
+			want: want{
+				err: errors.New(errZeroWeight),
+			},
+		},
+		"UnknownPolicy": {
+			reason: "We should return an error if the policy is unknown.",
+			args: args{
+				p:          v1.CompositionSelectionPolicy("Cool"),
+				candidates: []v1.Composition{withAnnotations("a", nil)},
+			},
+			want: want{
+				err: errors.Errorf(errFmtUnknownSelectionPolicy, "Cool"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			random := rand.New(rand.NewSource(0)) // nolint:gosec
+			selected, msg, err := SelectCompositionByPolicy(tc.args.p, tc.args.candidates, random)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nSelectCompositionByPolicy(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.selected, selected); diff != "" {
+				t.Errorf("\n%s\nSelectCompositionByPolicy(...): -want selected, +got selected:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.msg, msg); diff != "" {
+				t.Errorf("\n%s\nSelectCompositionByPolicy(...): -want message, +got message:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestAPIDefaultCompositionSelector(t *testing.T) {
 	a, k := schema.EmptyObjectKind.GroupVersionKind().ToAPIVersionAndKind()
 	tref := v1.TypeReference{APIVersion: a, Kind: k}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// Error strings.
+const (
+	errCreateComposed = "cannot create composed resource"
+	errApplyComposed  = "cannot server-side apply composed resource"
+
+	errFmtApplyConflict = "cannot apply composed resource %q because another field manager owns one or more of its rendered fields"
+)
+
+// FieldOwnerComposed is the prefix of the field manager Crossplane uses when it
+// server-side applies composed resources.
+const FieldOwnerComposed = "apiextensions.crossplane.io/composed"
+
+// FieldOwnerFor returns the field manager Crossplane uses to server-side apply
+// the supplied composed resource. Each named resource template gets its own
+// stable field manager, so that fields that are no longer rendered by a
+// template are removed when it is next applied.
+func FieldOwnerFor(cd client.Object) string {
+	name := GetCompositionResourceName(cd)
+	if name == "" {
+		return FieldOwnerComposed
+	}
+	return FieldOwnerComposed + "/" + name
+}
+
+// An APIServerSideApplicator applies composed resources using server-side
+// apply. Crossplane owns only the fields of a composed resource that it
+// renders, so it does not fight with other controllers (or humans) that set
+// other fields. The supplied object must contain only the desired state of the
+// composed resource.
+type APIServerSideApplicator struct {
+	client client.Client
+}
+
+// NewAPIServerSideApplicator returns an Applicator that applies composed
+// resources using server-side apply.
+func NewAPIServerSideApplicator(c client.Client) *APIServerSideApplicator {
+	return &APIServerSideApplicator{client: c}
+}
+
+// Apply the supplied composed resource. Composed resources that are yet to be
+// named are created, because a server-side apply cannot generate a name. The
+// supplied ApplyOptions are called if the composed resource exists. Conflicts
+// with other field managers are returned as errors that satisfy
+// IsApplyConflict.
+func (a *APIServerSideApplicator) Apply(ctx context.Context, o client.Object, ao ...resource.ApplyOption) error {
+	if o.GetName() == "" && o.GetGenerateName() != "" {
+		return errors.Wrap(a.client.Create(ctx, o), errCreateComposed)
+	}
+
+	if len(ao) > 0 {
+		current := o.DeepCopyObject().(client.Object)
+		err := a.client.Get(ctx, types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}, current)
+		if resource.IgnoreNotFound(err) != nil {
+			return errors.Wrap(err, errGetComposed)
+		}
+		for i := 0; err == nil && i < len(ao); i++ {
+			if err := ao[i](ctx, current, o); err != nil {
+				return err
+			}
+		}
+	}
+
+	// A server-side apply may not include managed fields, and we want to apply
+	// our desired state regardless of the resource version.
+	o.SetManagedFields(nil)
+	o.SetResourceVersion("")
+
+	err := a.client.Patch(ctx, o, client.Apply, client.FieldOwner(FieldOwnerFor(o)))
+	if IsApplyConflict(err) {
+		return errors.Wrapf(err, errFmtApplyConflict, o.GetName())
+	}
+	return errors.Wrap(err, errApplyComposed)
+}
+
+// IsApplyConflict returns true if the supplied error indicates that a
+// server-side apply failed because other field managers own some of the
+// applied fields. Other conflicts, such as an update of a stale resource
+// version, are not apply conflicts.
+func IsApplyConflict(err error) bool {
+	var s kerrors.APIStatus
+	if !errors.As(err, &s) {
+		return false
+	}
+	st := s.Status()
+	if st.Reason != metav1.StatusReasonConflict || st.Details == nil {
+		return false
+	}
+	for _, c := range st.Details.Causes {
+		if c.Type == metav1.CauseTypeFieldManagerConflict {
+			return true
+		}
+	}
+	return false
+}
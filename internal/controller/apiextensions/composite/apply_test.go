@@ -0,0 +1,183 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestFieldOwnerFor(t *testing.T) {
+	named := composed.New()
+	SetCompositionResourceName(named, "cool-template")
+
+	cases := map[string]struct {
+		reason string
+		cd     client.Object
+		want   string
+	}{
+		"Anonymous": {
+			reason: "Composed resources rendered from anonymous templates should share a field manager.",
+			cd:     composed.New(),
+			want:   FieldOwnerComposed,
+		},
+		"Named": {
+			reason: "Composed resources rendered from named templates should have a field manager per template.",
+			cd:     named,
+			want:   FieldOwnerComposed + "/cool-template",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := FieldOwnerFor(tc.cd)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nFieldOwnerFor(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPIServerSideApplicator(t *testing.T) {
+	errBoom := errors.New("boom")
+	errConflict := kerrors.NewApplyConflict([]metav1.StatusCause{{Type: metav1.CauseTypeFieldManagerConflict}}, "boom")
+	errLockConflict := kerrors.NewConflict(schema.GroupResource{}, "cool-cd", errBoom)
+
+	cd := func() *composed.Unstructured {
+		u := composed.New()
+		u.SetName("cool-cd")
+		u.SetResourceVersion("42")
+		SetCompositionResourceName(u, "cool-template")
+		return u
+	}
+	unnamed := composed.New()
+	unnamed.SetGenerateName("cool-")
+
+	type args struct {
+		client client.Client
+		o      client.Object
+		ao     []resource.ApplyOption
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"CreateError": {
+			reason: "We should return any error encountered creating an unnamed composed resource.",
+			args: args{
+				client: &test.MockClient{MockCreate: test.NewMockCreateFn(errBoom)},
+				o:      unnamed,
+			},
+			want: errors.Wrap(errBoom, errCreateComposed),
+		},
+		"GetError": {
+			reason: "We should return any error encountered getting a composed resource in order to call our ApplyOptions.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				o:      cd(),
+				ao:     []resource.ApplyOption{resource.MustBeControllableBy(types.UID("cool-xr"))},
+			},
+			want: errors.Wrap(errBoom, errGetComposed),
+		},
+		"ApplyOptionError": {
+			reason: "We should return any error returned by our ApplyOptions.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				o:      cd(),
+				ao: []resource.ApplyOption{func(_ context.Context, _, _ runtime.Object) error {
+					return errBoom
+				}},
+			},
+			want: errBoom,
+		},
+		"Conflict": {
+			reason: "We should return conflicts with other field managers as conflict errors.",
+			args: args{
+				client: &test.MockClient{MockPatch: test.NewMockPatchFn(errConflict)},
+				o:      cd(),
+			},
+			want: errors.Wrapf(errConflict, errFmtApplyConflict, "cool-cd"),
+		},
+		"OptimisticLockConflict": {
+			reason: "We should not return conflicts that weren't caused by other field managers as apply conflicts.",
+			args: args{
+				client: &test.MockClient{MockPatch: test.NewMockPatchFn(errLockConflict)},
+				o:      cd(),
+			},
+			want: errors.Wrap(errLockConflict, errApplyComposed),
+		},
+		"PatchError": {
+			reason: "We should return any error encountered applying a composed resource.",
+			args: args{
+				client: &test.MockClient{MockPatch: test.NewMockPatchFn(errBoom)},
+				o:      cd(),
+			},
+			want: errors.Wrap(errBoom, errApplyComposed),
+		},
+		"Success": {
+			reason: "We should server-side apply the composed resource using its template's field manager.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "cool-cd")),
+					MockPatch: func(_ context.Context, obj client.Object, p client.Patch, opts ...client.PatchOption) error {
+						if diff := cmp.Diff(types.ApplyPatchType, p.Type()); diff != "" {
+							t.Errorf("Patch(...): -want patch type, +got patch type:\n%s", diff)
+						}
+						po := &client.PatchOptions{}
+						po.ApplyOptions(opts)
+						if diff := cmp.Diff(FieldOwnerComposed+"/cool-template", po.FieldManager); diff != "" {
+							t.Errorf("Patch(...): -want field manager, +got field manager:\n%s", diff)
+						}
+						if diff := cmp.Diff("", obj.GetResourceVersion()); diff != "" {
+							t.Errorf("Patch(...): -want resource version, +got resource version:\n%s", diff)
+						}
+						return nil
+					},
+				},
+				o:  cd(),
+				ao: []resource.ApplyOption{resource.MustBeControllableBy(types.UID("cool-xr"))},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewAPIServerSideApplicator(tc.args.client)
+			err := a.Apply(context.Background(), tc.args.o, tc.args.ao...)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\na.Apply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(IsApplyConflict(tc.want), IsApplyConflict(err)); diff != "" {
+				t.Errorf("\n%s\nIsApplyConflict(a.Apply(...)): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package composite
 import (
 	"context"
 	"fmt"
+	"regexp"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -29,7 +30,6 @@ import (
 	"k8s.io/apimachinery/pkg/util/json"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
@@ -41,23 +41,38 @@ import (
 
 // Error strings
 const (
-	errMixed       = "cannot mix named and anonymous resource templates"
-	errDuplicate   = "resource template names must be unique within their Composition"
-	errGetComposed = "cannot get composed resource"
-	errGCComposed  = "cannot garbage collect composed resource"
-	errApply       = "cannot apply composed resource"
-	errFetchSecret = "cannot fetch connection secret"
-	errReadiness   = "cannot check whether composed resource is ready"
-	errUnmarshal   = "cannot unmarshal base template"
-	errGetSecret   = "cannot get connection secret of composed resource"
-	errNamePrefix  = "name prefix is not found in labels"
-	errKindChanged = "cannot change the kind of an existing composed resource"
-	errName        = "cannot use dry-run create to name composed resource"
-
-	errFmtPatch          = "cannot apply the patch at index %d"
-	errFmtConnDetailKey  = "connection detail of type %q key is not set"
-	errFmtConnDetailVal  = "connection detail of type %q value is not set"
-	errFmtConnDetailPath = "connection detail of type %q fromFieldPath is not set"
+	errMixed        = "cannot mix named and anonymous resource templates"
+	errDuplicate    = "resource template names must be unique within their Composition"
+	errGetComposed  = "cannot get composed resource"
+	errGCComposed   = "cannot garbage collect composed resource"
+	errApply        = "cannot apply composed resource"
+	errFetchSecret  = "cannot fetch connection secret"
+	errReadiness    = "cannot check whether composed resource is ready"
+	errUnmarshal    = "cannot unmarshal base template"
+	errGetSecret    = "cannot get connection secret of composed resource"
+	errNamePrefix   = "name prefix is not found in labels"
+	errKindChanged  = "cannot change the kind of an existing composed resource"
+	errName         = "cannot use dry-run create to name composed resource"
+	errCompileRegex = "cannot compile regular expression"
+
+	errFmtAnonymousCondition   = "resource template at index %d must be named to specify a condition"
+	errFmtCondition            = "cannot evaluate condition of resource template %q"
+	errFmtConditionType        = "condition type %s is not supported"
+	errFmtPatch                = "cannot apply the patch at index %d"
+	errFmtTransform            = "invalid transform at index %d"
+	errFmtPatchSetPatch        = "invalid patch at index %d of PatchSet %q"
+	errFmtResourceConnDetail   = "invalid connection detail at index %d of resource template at index %d"
+	errFmtResourcePatch        = "invalid patch at index %d of resource template at index %d"
+	errFmtConnDetailKey        = "connection detail of type %q key is not set"
+	errFmtConnDetailVal        = "connection detail of type %q value is not set"
+	errFmtConnDetailPath       = "connection detail of type %q fromFieldPath is not set"
+	errFmtSiblingMissing       = "cannot find composed resource for resource template %q"
+	errFmtConnDetailCombine    = "connection detail of type %q combine is not set"
+	errFmtConnDetailCombined   = "cannot combine connection detail %q"
+	errFmtConnDetailTransform  = "cannot transform connection detail %q"
+	errFmtConnSecretKeyMissing = "connection secret key %q is not set"
+	errFmtTransformAtIdx       = "transform at index %d returned error"
+	errFmtReadinessConfig      = "readiness check at index %d: type requires configuration"
 )
 
 // Annotation keys.
@@ -103,6 +118,25 @@ func (vs ValidationChain) Validate(comp *v1.Composition) error {
 	return nil
 }
 
+// DefaultValidationChain returns the validators used to validate Compositions
+// before they are used to compose resources. Compositions may only use the
+// functions permitted by the supplied options.
+func DefaultValidationChain(o FunctionOptions) ValidationChain {
+	return ValidationChain{
+		CompositionValidatorFn(RejectMixedTemplates),
+		CompositionValidatorFn(RejectDuplicateNames),
+		CompositionValidatorFn(RejectInvalidPatches),
+		CompositionValidatorFn(RejectUndefinedPatchSets),
+		CompositionValidatorFn(RejectInvalidTransforms),
+		CompositionValidatorFn(RejectAnonymousConditionalTemplates),
+		CompositionValidatorFn(RejectAnonymousForEachTemplates),
+		CompositionValidatorFn(RejectReservedTemplateNames),
+		CompositionValidatorFn(RejectInvalidDependencies),
+		RejectInvalidFunctions(o),
+		CompositionValidatorFn(RejectInvalidGoTemplate),
+	}
+}
+
 // RejectMixedTemplates validates that the supplied Composition does not attempt
 // to mix named and anonymous templates. If some but not all templates are named
 // it's safest to refuse to operate. We don't have enough information to use the
@@ -149,6 +183,112 @@ func RejectDuplicateNames(comp *v1.Composition) error {
 	return nil
 }
 
+// RejectAnonymousConditionalTemplates validates that only named templates
+// within the supplied Composition specify a condition. Excluding an anonymous
+// template would change the order of the resources array, which anonymous
+// templates rely on to associate templates with composed resources.
+func RejectAnonymousConditionalTemplates(comp *v1.Composition) error {
+	for i, tmpl := range comp.Spec.Resources {
+		if tmpl.Name == nil && tmpl.Condition != nil {
+			return errors.Errorf(errFmtAnonymousCondition, i)
+		}
+	}
+	return nil
+}
+
+// IsIncluded returns true if the supplied template should be included when
+// composing resources for the supplied composite resource; i.e. if it has no
+// condition, or its condition is true.
+func IsIncluded(cp resource.Composite, t v1.ComposedTemplate) (bool, error) {
+	c := t.Condition
+	if c == nil {
+		return true, nil
+	}
+
+	v, err := fieldPathValue(cp, c.FromFieldPath)
+	if fieldpath.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch c.Type {
+	case v1.ComposedTemplateConditionTypeMatchTrue, "":
+		return v == true, nil
+	case v1.ComposedTemplateConditionTypeMatchFalse:
+		return v == false, nil
+	case v1.ComposedTemplateConditionTypeMatchString:
+		return v == c.MatchString, nil
+	case v1.ComposedTemplateConditionTypeNonEmpty:
+		return true, nil
+	default:
+		return false, errors.Errorf(errFmtConditionType, c.Type)
+	}
+}
+
+// RejectInvalidTransforms validates that all transforms within the supplied
+// Composition are configured correctly. Transforms that would always fail, for
+// example a math transform that specifies more than one operation, are better
+// surfaced before we attempt to compose any resources.
+func RejectInvalidTransforms(comp *v1.Composition) error {
+	validate := func(ts []v1.Transform) error {
+		for i := range ts {
+			if err := ts[i].Validate(); err != nil {
+				return errors.Wrapf(err, errFmtTransform, i)
+			}
+		}
+		return nil
+	}
+	for _, ps := range comp.Spec.PatchSets {
+		for i := range ps.Patches {
+			if err := validate(ps.Patches[i].Transforms); err != nil {
+				return errors.Wrapf(err, errFmtPatchSetPatch, i, ps.Name)
+			}
+		}
+	}
+	for ti, tmpl := range comp.Spec.Resources {
+		for i := range tmpl.Patches {
+			if err := validate(tmpl.Patches[i].Transforms); err != nil {
+				return errors.Wrapf(err, errFmtResourcePatch, i, ti)
+			}
+		}
+		for i := range tmpl.ConnectionDetails {
+			if err := validate(tmpl.ConnectionDetails[i].Transforms); err != nil {
+				return errors.Wrapf(err, errFmtResourceConnDetail, i, ti)
+			}
+		}
+	}
+	return nil
+}
+
+// RejectInvalidPatches validates that all patches within the supplied
+// Composition specify the fields required by their type.
+func RejectInvalidPatches(comp *v1.Composition) error {
+	for _, ps := range comp.Spec.PatchSets {
+		for i := range ps.Patches {
+			if err := ps.Patches[i].Validate(); err != nil {
+				return errors.Wrapf(err, errFmtPatchSetPatch, i, ps.Name)
+			}
+		}
+	}
+	for ti, tmpl := range comp.Spec.Resources {
+		for i := range tmpl.Patches {
+			if err := tmpl.Patches[i].Validate(); err != nil {
+				return errors.Wrapf(err, errFmtResourcePatch, i, ti)
+			}
+		}
+	}
+	return nil
+}
+
+// RejectUndefinedPatchSets validates that the PatchSets of the supplied
+// Composition can be inlined, i.e. that every PatchSet that is referenced is
+// defined, and that PatchSets do not reference other PatchSets.
+func RejectUndefinedPatchSets(comp *v1.Composition) error {
+	return errors.Wrap(comp.Spec.DeepCopy().InlinePatchSets(), errInline)
+}
+
 // A TemplateAssociation associates a composed resource template with a composed
 // resource. If no such resource exists the reference will be empty.
 type TemplateAssociation struct {
@@ -196,8 +336,9 @@ func (fn CompositionTemplateAssociatorFn) AssociateTemplates(ctx context.Context
 // checking the template name annotation of each referenced resource. If any
 // template or existing composed resource can't be associated by name it falls
 // back to associating them by order. If it encounters a referenced resource
-// that corresponds to a non-existent template the resource will be garbage
-// collected (i.e. deleted).
+// that corresponds to a non-existent or excluded template the resource will be
+// garbage collected (i.e. deleted). A template is excluded if its condition is
+// false.
 type GarbageCollectingAssociator struct {
 	client client.Client
 }
@@ -213,20 +354,37 @@ func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr
 	// NOTE(negz): This method is a little over our complexity goal. Be wary of
 	// making it more complex.
 
-	templates := map[string]int{}
-	for i, t := range comp.Spec.Resources {
+	for _, t := range comp.Spec.Resources {
 		if t.Name == nil {
 			// If our templates aren't named we fall back to assuming that the
 			// existing resource reference array (if any) already matches the
 			// order of our resource template array.
 			return AssociateByOrder(comp.Spec.Resources, cr.GetResourceReferences()), nil
 		}
-		templates[*t.Name] = i
 	}
 
-	tas := make([]TemplateAssociation, len(comp.Spec.Resources))
-	for i := range comp.Spec.Resources {
-		tas[i] = TemplateAssociation{Template: comp.Spec.Resources[i]}
+	// Templates whose condition is false are excluded, while forEach templates
+	// are expanded once per element. Any existing composed resource that
+	// corresponds to an excluded template, or to a since removed element, will
+	// be garbage collected below, as if its template did not exist.
+	templates := map[string]int{}
+	tas := make([]TemplateAssociation, 0, len(comp.Spec.Resources))
+	for _, t := range comp.Spec.Resources {
+		ok, err := IsIncluded(cr, t)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtCondition, *t.Name)
+		}
+		if !ok {
+			continue
+		}
+		ets, err := ExpandForEach(cr, t)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtForEach, *t.Name)
+		}
+		for _, et := range ets {
+			templates[*et.Name] = len(tas)
+			tas = append(tas, TemplateAssociation{Template: et})
+		}
 	}
 
 	for _, ref := range cr.GetResourceReferences() {
@@ -256,7 +414,7 @@ func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr
 			// reference array already matches the order of our resource
 			// template array. Existing composed resources should be annotated
 			// at render time with the name of the template used to create them.
-			return AssociateByOrder(comp.Spec.Resources, cr.GetResourceReferences()), nil
+			return AssociateByOrder(templatesOf(tas), cr.GetResourceReferences()), nil
 		}
 
 		// Inject the reference to this existing resource into the references
@@ -272,8 +430,8 @@ func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr
 			continue
 		}
 
-		// This existing resource does not correspond to an extant template. It
-		// should be garbage collected.
+		// This existing resource does not correspond to an extant, included
+		// template. It should be garbage collected.
 		if err := a.client.Delete(ctx, cd); resource.IgnoreNotFound(err) != nil {
 			return nil, errors.Wrap(err, errGCComposed)
 		}
@@ -282,6 +440,14 @@ func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr
 	return tas, nil
 }
 
+func templatesOf(tas []TemplateAssociation) []v1.ComposedTemplate {
+	t := make([]v1.ComposedTemplate, len(tas))
+	for i := range tas {
+		t[i] = tas[i].Template
+	}
+	return t
+}
+
 // Observation is the result of composed reconciliation.
 type Observation struct {
 	Ref               corev1.ObjectReference
@@ -345,6 +511,14 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 		SetCompositionResourceName(cd, *t.Name)
 	}
 
+	// We record the deletion policy and dependencies of each composed resource
+	// when we render it, so that we know what to do with it when its composite
+	// resource is deleted, even if its Composition has since changed.
+	SetDeletionPolicy(cd, DeletionPolicyFor(cp, t))
+	if len(t.DependsOn) > 0 {
+		SetDependsOn(cd, t.DependsOn)
+	}
+
 	// Unmarshalling the template will overwrite any existing fields, so we must
 	// restore the existing name, if any. We also set generate name in case we
 	// haven't yet named this composed resource.
@@ -378,8 +552,15 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 	// we perform a dry-run create. This name is likely (but not guaranteed) to
 	// be available when we create the composed resource. If the API server
 	// generates a name that is unavailable it will return a 500 ServerTimeout
-	// error.
-	return errors.Wrap(r.client.Create(ctx, cd, client.DryRunAll), errName)
+	// error. We dry-run create a copy of the composed resource and take only
+	// its name, so that the composed resource contains only the fields we
+	// rendered and not any that were defaulted by the API server.
+	dr := cd.DeepCopyObject().(resource.Composed)
+	if err := r.client.Create(ctx, dr, client.DryRunAll); err != nil {
+		return errors.Wrap(err, errName)
+	}
+	cd.SetName(dr.GetName())
+	return nil
 }
 
 // RenderComposite renders the supplied composite resource using the supplied composed
@@ -395,6 +576,35 @@ func RenderComposite(_ context.Context, cp resource.Composite, cd resource.Compo
 	return nil
 }
 
+// RenderFromSiblings renders the supplied composed resource using the supplied
+// sibling composed resources, keyed by the name of their resource template.
+// FromComposedFieldPath patches whose sibling is missing, for example because
+// it has not yet been applied, are skipped unless their policy requires the
+// field path to be present.
+func RenderFromSiblings(_ context.Context, cd resource.Composed, t v1.ComposedTemplate, siblings map[string]resource.Composed) error {
+	for i, p := range t.Patches {
+		if p.Type != v1.PatchTypeFromComposedFieldPath {
+			continue
+		}
+		if p.FromResourceName == nil {
+			// ApplyFromComposed will return an appropriate error.
+			return errors.Wrapf(p.ApplyFromComposed(nil, cd), errFmtPatch, i)
+		}
+		from, ok := siblings[*p.FromResourceName]
+		if !ok {
+			if p.Policy != nil && p.Policy.FromFieldPath != nil && *p.Policy.FromFieldPath == v1.FromFieldPathPolicyRequired {
+				return errors.Wrapf(errors.Errorf(errFmtSiblingMissing, *p.FromResourceName), errFmtPatch, i)
+			}
+			continue
+		}
+		if err := p.ApplyFromComposed(from, cd); err != nil {
+			return errors.Wrapf(err, errFmtPatch, i)
+		}
+	}
+
+	return nil
+}
+
 // An APIConnectionDetailsFetcher may use the API server to read connection
 // details from a Secret.
 type APIConnectionDetailsFetcher struct {
@@ -426,6 +636,9 @@ func (cdf *APIConnectionDetailsFetcher) FetchConnectionDetails(ctx context.Conte
 	conn := managed.ConnectionDetails{}
 
 	for _, d := range t.ConnectionDetails {
+		var key string
+		var val interface{}
+
 		switch tp := connectionDetailType(d); tp {
 		case v1.ConnectionDetailTypeFromValue:
 			// Name, Value must be set if value type
@@ -435,7 +648,7 @@ func (cdf *APIConnectionDetailsFetcher) FetchConnectionDetails(ctx context.Conte
 			case d.Value == nil:
 				return nil, errors.Errorf(errFmtConnDetailVal, tp)
 			default:
-				conn[*d.Name] = []byte(*d.Value)
+				key, val = *d.Name, *d.Value
 			}
 		case v1.ConnectionDetailTypeFromConnectionSecretKey:
 			if d.FromConnectionSecretKey == nil {
@@ -446,25 +659,55 @@ func (cdf *APIConnectionDetailsFetcher) FetchConnectionDetails(ctx context.Conte
 				// key will still be written at some point in the future.
 				continue
 			}
-			key := *d.FromConnectionSecretKey
+			key = *d.FromConnectionSecretKey
 			if d.Name != nil {
 				key = *d.Name
 			}
-			if key != "" {
-				conn[key] = data[*d.FromConnectionSecretKey]
-			}
+			val = data[*d.FromConnectionSecretKey]
 		case v1.ConnectionDetailTypeFromFieldPath:
 			switch {
 			case d.Name == nil:
 				return nil, errors.Errorf(errFmtConnDetailKey, tp)
 			case d.FromFieldPath == nil:
 				return nil, errors.Errorf(errFmtConnDetailPath, tp)
-			default:
-				_ = extractFieldPathValue(cd, d, conn)
 			}
+			v, err := fieldPathValue(cd, *d.FromFieldPath)
+			if err != nil {
+				// The field may be set at some point in the future.
+				continue
+			}
+			key, val = *d.Name, v
+		case v1.ConnectionDetailTypeCombine:
+			switch {
+			case d.Name == nil:
+				return nil, errors.Errorf(errFmtConnDetailKey, tp)
+			case d.Combine == nil:
+				return nil, errors.Errorf(errFmtConnDetailCombine, tp)
+			}
+			vars, err := combineVariables(cd, data, d.Combine.Variables)
+			if err != nil {
+				// Any of the variables may be set at some point in the future.
+				continue
+			}
+			v, err := d.Combine.Combine(vars)
+			if err != nil {
+				return nil, errors.Wrapf(err, errFmtConnDetailCombined, *d.Name)
+			}
+			key, val = *d.Name, v
 		case v1.ConnectionDetailTypeUnknown:
 			// We weren't able to determine the type of this connection detail.
+			continue
+		}
+
+		if key == "" {
+			continue
 		}
+
+		b, err := connectionDetailValue(val, d.Transforms)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConnDetailTransform, key)
+		}
+		conn[key] = b
 	}
 
 	if len(conn) == 0 {
@@ -487,48 +730,95 @@ func connectionDetailType(d v1.ConnectionDetail) v1.ConnectionDetailType {
 		return v1.ConnectionDetailTypeFromConnectionSecretKey
 	case d.FromFieldPath != nil:
 		return v1.ConnectionDetailTypeFromFieldPath
+	case d.Combine != nil:
+		return v1.ConnectionDetailTypeCombine
 	default:
 		return v1.ConnectionDetailTypeUnknown
 	}
 }
 
-func extractFieldPathValue(from runtime.Object, detail v1.ConnectionDetail, conn managed.ConnectionDetails) error {
+func fieldPathValue(from runtime.Object, path string) (interface{}, error) {
+	if u, ok := from.(interface{ UnstructuredContent() map[string]interface{} }); ok {
+		return fieldpath.Pave(u.UnstructuredContent()).GetValue(path)
+	}
 	fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return fieldpath.Pave(fromMap).GetValue(path)
+}
 
-	str, err := fieldpath.Pave(fromMap).GetString(*detail.FromFieldPath)
-	if err == nil {
-		conn[*detail.Name] = []byte(str)
-		return nil
+// combineVariables returns the values of the supplied combine variables, read
+// from either the supplied connection secret data or composed resource. It
+// returns an error if any variable cannot be read.
+func combineVariables(from runtime.Object, data map[string][]byte, cv []v1.CombineVariable) ([]interface{}, error) {
+	out := make([]interface{}, len(cv))
+	for i, v := range cv {
+		if v.FromConnectionSecretKey != "" {
+			b, ok := data[v.FromConnectionSecretKey]
+			if !ok {
+				return nil, errors.Errorf(errFmtConnSecretKeyMissing, v.FromConnectionSecretKey)
+			}
+			out[i] = string(b)
+			continue
+		}
+		val, err := fieldPathValue(from, v.FromFieldPath)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = val
 	}
+	return out, nil
+}
 
-	in, err := fieldpath.Pave(fromMap).GetValue(*detail.FromFieldPath)
-	if err != nil {
-		return err
+// connectionDetailValue returns the supplied value, transformed by the supplied
+// transforms, as connection detail bytes. Bytes are supplied to the first
+// transform as a string. Values other than bytes and strings are JSON encoded.
+func connectionDetailValue(val interface{}, ts []v1.Transform) ([]byte, error) {
+	if b, ok := val.([]byte); ok {
+		if len(ts) == 0 {
+			return b, nil
+		}
+		val = string(b)
 	}
 
-	buffer, err := json.Marshal(in)
-	if err != nil {
-		return err
+	var err error
+	for i, t := range ts {
+		if val, err = t.Transform(val); err != nil {
+			return nil, errors.Wrapf(err, errFmtTransformAtIdx, i)
+		}
+	}
+
+	switch v := val.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return json.Marshal(v)
 	}
-	conn[*detail.Name] = buffer
-	return nil
 }
 
-// IsReady returns whether the composed resource is ready.
+// IsReady returns whether the composed resource is ready. A composed resource
+// is not ready if a field that one of its readiness checks depends on does not
+// exist. A composed resource whose template specifies no readiness checks is
+// ready if its Ready condition is True, or if it is a well-known Kubernetes kind
+// (e.g. a Deployment or a Job) whose status indicates that it is ready.
 func IsReady(_ context.Context, cd resource.Composed, t v1.ComposedTemplate) (bool, error) { // nolint:gocyclo
 	// NOTE(muvaf): The cyclomatic complexity of this function comes from the
 	// mandatory repetitiveness of the switch clause, which is not really complex
 	// in reality. Though beware of adding additional complexity besides that.
 
-	if len(t.ReadinessChecks) == 0 {
-		return resource.IsConditionTrue(cd.GetCondition(xpv1.TypeReady)), nil
-	}
 	// TODO(muvaf): We can probably get rid of resource.Composed interface and fake.Composed
 	// structs and use *composed.Unstructured everywhere including tests.
 	u, ok := cd.(*composed.Unstructured)
+	if len(t.ReadinessChecks) == 0 {
+		var paved *fieldpath.Paved
+		if ok {
+			paved = fieldpath.Pave(u.UnstructuredContent())
+		}
+		return defaultReadiness(cd, paved), nil
+	}
 	if !ok {
 		return false, errors.New("composed resource has to be Unstructured type")
 	}
@@ -536,33 +826,89 @@ func IsReady(_ context.Context, cd resource.Composed, t v1.ComposedTemplate) (bo
 
 	for i, check := range t.ReadinessChecks {
 		var ready bool
+		var err error
 		switch check.Type {
 		case v1.ReadinessCheckTypeNone:
 			return true, nil
 		case v1.ReadinessCheckTypeNonEmpty:
-			_, err := paved.GetValue(check.FieldPath)
-			if resource.Ignore(fieldpath.IsNotFound, err) != nil {
-				return false, err
-			}
-			ready = !fieldpath.IsNotFound(err)
+			_, err = paved.GetValue(check.FieldPath)
+			ready = err == nil
 		case v1.ReadinessCheckTypeMatchString:
-			val, err := paved.GetString(check.FieldPath)
-			if resource.Ignore(fieldpath.IsNotFound, err) != nil {
-				return false, err
+			var val string
+			val, err = paved.GetString(check.FieldPath)
+			ready = err == nil && val == check.MatchString
+		case v1.ReadinessCheckTypeMatchRegex:
+			ready, err = matchRegex(paved, check)
+		case v1.ReadinessCheckTypeMatchTrue, v1.ReadinessCheckTypeMatchFalse:
+			var val bool
+			val, err = paved.GetBool(check.FieldPath)
+			ready = err == nil && val == (check.Type == v1.ReadinessCheckTypeMatchTrue)
+		case v1.ReadinessCheckTypeMatchInteger,
+			v1.ReadinessCheckTypeMatchIntegerGreaterThan,
+			v1.ReadinessCheckTypeMatchIntegerGreaterThanOrEqual,
+			v1.ReadinessCheckTypeMatchIntegerLessThan,
+			v1.ReadinessCheckTypeMatchIntegerLessThanOrEqual:
+			ready, err = matchInteger(paved, check)
+		case v1.ReadinessCheckTypeMatchCondition:
+			if check.MatchCondition == nil {
+				return false, errors.Errorf(errFmtReadinessConfig, i)
 			}
-			ready = !fieldpath.IsNotFound(err) && val == check.MatchString
-		case v1.ReadinessCheckTypeMatchInteger:
-			val, err := paved.GetInteger(check.FieldPath)
-			if err != nil {
-				return false, err
+			want := check.MatchCondition.Status
+			if want == "" {
+				want = corev1.ConditionTrue
 			}
-			ready = !fieldpath.IsNotFound(err) && val == check.MatchInteger
+			ready = cd.GetCondition(check.MatchCondition.Type).Status == want
 		default:
 			return false, errors.New(fmt.Sprintf("readiness check at index %d: an unknown type is chosen", i))
 		}
+		if resource.Ignore(fieldpath.IsNotFound, err) != nil {
+			return false, err
+		}
 		if !ready {
 			return false, nil
 		}
 	}
 	return true, nil
 }
+
+// matchRegex returns true if the string value at the readiness check's field
+// path matches its regular expression.
+func matchRegex(p *fieldpath.Paved, check v1.ReadinessCheck) (bool, error) {
+	re, err := regexp.Compile(check.MatchRegex)
+	if err != nil {
+		return false, errors.Wrap(err, errCompileRegex)
+	}
+	val, err := p.GetString(check.FieldPath)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(val), nil
+}
+
+// matchInteger returns true if the integer value at the readiness check's field
+// path compares as expected to either its MatchInteger value, or the integer
+// value at its MatchIntegerFieldPath.
+func matchInteger(p *fieldpath.Paved, check v1.ReadinessCheck) (bool, error) {
+	val, err := p.GetInteger(check.FieldPath)
+	if err != nil {
+		return false, err
+	}
+	want := check.MatchInteger
+	if check.MatchIntegerFieldPath != nil {
+		if want, err = p.GetInteger(*check.MatchIntegerFieldPath); err != nil {
+			return false, err
+		}
+	}
+	switch check.Type {
+	case v1.ReadinessCheckTypeMatchIntegerGreaterThan:
+		return val > want, nil
+	case v1.ReadinessCheckTypeMatchIntegerGreaterThanOrEqual:
+		return val >= want, nil
+	case v1.ReadinessCheckTypeMatchIntegerLessThan:
+		return val < want, nil
+	case v1.ReadinessCheckTypeMatchIntegerLessThanOrEqual:
+		return val <= want, nil
+	default:
+		return val == want, nil
+	}
+}
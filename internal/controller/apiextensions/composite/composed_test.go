@@ -33,10 +33,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	"github.com/crossplane/crossplane/internal/xcrd"
@@ -166,8 +168,243 @@ func TestRejectDuplicateNames(t *testing.T) {
 	}
 }
 
+func TestRejectInvalidTransforms(t *testing.T) {
+	two := v1.MathOperand("2")
+	invalid := v1.Patch{Transforms: []v1.Transform{
+		{Type: v1.TransformTypeMath, Math: &v1.MathTransform{Multiply: &two}},
+		{Type: v1.TransformTypeMath, Math: &v1.MathTransform{Multiply: &two, Add: &two}},
+	}}
+	valid := v1.Patch{Transforms: []v1.Transform{
+		{Type: v1.TransformTypeMath, Math: &v1.MathTransform{Add: &two}},
+	}}
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"Valid": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					PatchSets: []v1.PatchSet{{Name: "cool", Patches: []v1.Patch{valid}}},
+					Resources: []v1.ComposedTemplate{{Patches: []v1.Patch{valid}}},
+				},
+			},
+			want: nil,
+		},
+		"InvalidPatchSet": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					PatchSets: []v1.PatchSet{{Name: "cool", Patches: []v1.Patch{valid, invalid}}},
+				},
+			},
+			want: errors.Wrapf(errors.Wrapf(invalid.Transforms[1].Validate(), errFmtTransform, 1), errFmtPatchSetPatch, 1, "cool"),
+		},
+		"InvalidResourceTemplate": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Patches: []v1.Patch{valid}},
+						{Patches: []v1.Patch{invalid}},
+					},
+				},
+			},
+			want: errors.Wrapf(errors.Wrapf(invalid.Transforms[1].Validate(), errFmtTransform, 1), errFmtResourcePatch, 0, 1),
+		},
+		"InvalidConnectionDetail": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{ConnectionDetails: []v1.ConnectionDetail{{Transforms: invalid.Transforms}}},
+					},
+				},
+			},
+			want: errors.Wrapf(errors.Wrapf(invalid.Transforms[1].Validate(), errFmtTransform, 1), errFmtResourceConnDetail, 0, 0),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectInvalidTransforms(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectInvalidTransforms(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectInvalidPatches(t *testing.T) {
+	valid := v1.Patch{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.StringPtr("spec")}
+	invalid := v1.Patch{Type: v1.PatchTypeFromCompositeFieldPath}
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"Valid": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				PatchSets: []v1.PatchSet{{Name: "cool", Patches: []v1.Patch{valid}}},
+				Resources: []v1.ComposedTemplate{{Patches: []v1.Patch{valid}}},
+			}},
+			want: nil,
+		},
+		"InvalidPatchSetPatch": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				PatchSets: []v1.PatchSet{{Name: "cool", Patches: []v1.Patch{valid, invalid}}},
+			}},
+			want: errors.Wrapf(invalid.Validate(), errFmtPatchSetPatch, 1, "cool"),
+		},
+		"InvalidResourcePatch": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Patches: []v1.Patch{valid}}, {Patches: []v1.Patch{invalid}}},
+			}},
+			want: errors.Wrapf(invalid.Validate(), errFmtResourcePatch, 0, 1),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectInvalidPatches(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectInvalidPatches(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectUndefinedPatchSets(t *testing.T) {
+	ref := v1.Patch{Type: v1.PatchTypePatchSet, PatchSetName: pointer.StringPtr("cool")}
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"Defined": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				PatchSets: []v1.PatchSet{{Name: "cool"}},
+				Resources: []v1.ComposedTemplate{{Patches: []v1.Patch{ref}}},
+			}},
+			want: nil,
+		},
+		"Undefined": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Patches: []v1.Patch{ref}}},
+			}},
+			want: errors.Wrap(errors.New("cannot find PatchSet by name cool"), errInline),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			orig := tc.comp.DeepCopy()
+			got := RejectUndefinedPatchSets(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectUndefinedPatchSets(...): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(orig, tc.comp); diff != "" {
+				t.Errorf("\nRejectUndefinedPatchSets(...): should not modify Composition: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectAnonymousConditionalTemplates(t *testing.T) {
+	name := "cool"
+	cond := &v1.ComposedTemplateCondition{FromFieldPath: "spec.enabled"}
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"NamedConditionalTemplate": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &name, Condition: cond}}}},
+			want: nil,
+		},
+		"AnonymousTemplate": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{}}}},
+			want: nil,
+		},
+		"AnonymousConditionalTemplate": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{}, {Condition: cond}}}},
+			want: errors.Errorf(errFmtAnonymousCondition, 1),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectAnonymousConditionalTemplates(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectAnonymousConditionalTemplates(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsIncluded(t *testing.T) {
+	cp := composite.New()
+	cp.SetName("cool")
+	cp.SetAnnotations(map[string]string{"a": "b"})
+	cond := func(tp v1.ComposedTemplateConditionType, path, match string) v1.ComposedTemplate {
+		return v1.ComposedTemplate{Condition: &v1.ComposedTemplateCondition{Type: tp, FromFieldPath: path, MatchString: match}}
+	}
+
+	type want struct {
+		included bool
+		err      error
+	}
+
+	cases := map[string]struct {
+		reason string
+		t      v1.ComposedTemplate
+		want   want
+	}{
+		"NoCondition": {
+			reason: "Templates without a condition should always be included.",
+			t:      v1.ComposedTemplate{},
+			want:   want{included: true},
+		},
+		"FieldNotFound": {
+			reason: "Templates should be excluded if the condition's field does not exist.",
+			t:      cond(v1.ComposedTemplateConditionTypeNonEmpty, "metadata.labels.a", ""),
+			want:   want{included: false},
+		},
+		"NonEmpty": {
+			reason: "Templates should be included if the condition's field exists.",
+			t:      cond(v1.ComposedTemplateConditionTypeNonEmpty, "metadata.annotations.a", ""),
+			want:   want{included: true},
+		},
+		"MatchString": {
+			reason: "Templates should be included if the condition's field matches its string.",
+			t:      cond(v1.ComposedTemplateConditionTypeMatchString, "metadata.name", "cool"),
+			want:   want{included: true},
+		},
+		"MatchTrueNotBool": {
+			reason: "Templates should be excluded if the condition's field is not true.",
+			t:      cond("", "metadata.name", ""),
+			want:   want{included: false},
+		},
+		"UnknownType": {
+			reason: "An error should be returned if the condition type is unknown.",
+			t:      cond("Nope", "metadata.name", ""),
+			want:   want{err: errors.Errorf(errFmtConditionType, "Nope")},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := IsIncluded(cp, tc.t)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nIsIncluded(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.included, got); diff != "" {
+				t.Errorf("\n%s\nIsIncluded(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestRender(t *testing.T) {
 	ctrl := true
+	orphan := xpv1.DeletionOrphan
 	tmpl, _ := json.Marshal(&fake.Managed{})
 
 	type args struct {
@@ -229,11 +466,46 @@ func TestRender(t *testing.T) {
 						xcrd.LabelKeyClaimName:             "rola",
 						xcrd.LabelKeyClaimNamespace:        "rolans",
 					},
+					Annotations: map[string]string{
+						AnnotationKeyDeletionPolicy: string(xpv1.DeletionDelete),
+					},
 					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl}},
 				}},
 				err: errors.Wrap(errBoom, errName),
 			},
 		},
+		"DryRunName": {
+			reason: "Only the name should be taken from the dry-run created resource",
+			client: &test.MockClient{MockCreate: func(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+				obj.SetName("ola-generated")
+				obj.SetResourceVersion("42")
+				return nil
+			}},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "ola-generated",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					Annotations: map[string]string{
+						AnnotationKeyDeletionPolicy: string(xpv1.DeletionDelete),
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl}},
+				}},
+			},
+		},
 		"Success": {
 			reason: "Configuration should result in the right object with correct generateName",
 			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
@@ -255,6 +527,38 @@ func TestRender(t *testing.T) {
 						xcrd.LabelKeyClaimName:             "rola",
 						xcrd.LabelKeyClaimNamespace:        "rolans",
 					},
+					Annotations: map[string]string{
+						AnnotationKeyDeletionPolicy: string(xpv1.DeletionDelete),
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl}},
+				}},
+			},
+		},
+		"DeletionPolicyAndDependencies": {
+			reason: "The template's deletion policy and dependencies should be recorded on the rendered resource",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}, DeletionPolicy: &orphan, DependsOn: []string{"a", "b"}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					Annotations: map[string]string{
+						AnnotationKeyDeletionPolicy:               string(xpv1.DeletionOrphan),
+						AnnotationKeyCompositionResourceDependsOn: "a,b",
+					},
 					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl}},
 				}},
 			},
@@ -274,6 +578,104 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestRenderFromSiblings(t *testing.T) {
+	required := v1.FromFieldPathPolicyRequired
+	cd := func(id string) *composed.Unstructured {
+		u := composed.New()
+		if id != "" {
+			_ = fieldpath.Pave(u.Object).SetValue("spec.id", id)
+		}
+		return u
+	}
+	patch := func(from string, p *v1.PatchPolicy) v1.Patch {
+		return v1.Patch{
+			Type:             v1.PatchTypeFromComposedFieldPath,
+			FromResourceName: pointer.StringPtr(from),
+			FromFieldPath:    pointer.StringPtr("status.id"),
+			ToFieldPath:      pointer.StringPtr("spec.id"),
+			Policy:           p,
+		}
+	}
+	sibling := composed.New()
+	_ = fieldpath.Pave(sibling.Object).SetValue("status.id", "cool")
+
+	type args struct {
+		cd       resource.Composed
+		t        v1.ComposedTemplate
+		siblings map[string]resource.Composed
+	}
+	type want struct {
+		cd  resource.Composed
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NoFromResourceName": {
+			reason: "We should return an error if a FromComposedFieldPath patch does not specify a resource name.",
+			args: args{
+				cd: cd(""),
+				t: v1.ComposedTemplate{Patches: []v1.Patch{{
+					Type:          v1.PatchTypeFromComposedFieldPath,
+					FromFieldPath: pointer.StringPtr("status.id"),
+				}}},
+			},
+			want: want{
+				cd:  cd(""),
+				err: errors.Wrapf(errors.Errorf("FromResourceName is required by type %s", v1.PatchTypeFromComposedFieldPath), errFmtPatch, 0),
+			},
+		},
+		"OptionalSiblingMissing": {
+			reason: "We should skip a patch from a missing sibling by default.",
+			args: args{
+				cd: cd(""),
+				t:  v1.ComposedTemplate{Patches: []v1.Patch{patch("other", nil)}},
+			},
+			want: want{
+				cd: cd(""),
+			},
+		},
+		"RequiredSiblingMissing": {
+			reason: "We should return an error if a patch requires a missing sibling.",
+			args: args{
+				cd: cd(""),
+				t:  v1.ComposedTemplate{Patches: []v1.Patch{patch("other", &v1.PatchPolicy{FromFieldPath: &required})}},
+			},
+			want: want{
+				cd:  cd(""),
+				err: errors.Wrapf(errors.Errorf(errFmtSiblingMissing, "other"), errFmtPatch, 0),
+			},
+		},
+		"Success": {
+			reason: "We should patch from the named sibling, ignoring other patch types.",
+			args: args{
+				cd: cd(""),
+				t: v1.ComposedTemplate{Patches: []v1.Patch{
+					{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.StringPtr("spec.nope")},
+					patch("other", nil),
+				}},
+				siblings: map[string]resource.Composed{"other": sibling},
+			},
+			want: want{
+				cd: cd("cool"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := RenderFromSiblings(context.Background(), tc.args.cd, tc.args.t, tc.args.siblings)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRenderFromSiblings(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRenderFromSiblings(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestAssociateByOrder(t *testing.T) {
 	t0 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("zero")}}
 	t1 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("one")}}
@@ -334,8 +736,15 @@ func TestGarbageCollectingAssociator(t *testing.T) {
 
 	n0 := "zero"
 	t0 := v1.ComposedTemplate{Name: &n0}
+	n1 := "one"
+	t1 := v1.ComposedTemplate{Name: &n1, Condition: &v1.ComposedTemplateCondition{
+		Type:          v1.ComposedTemplateConditionTypeMatchString,
+		FromFieldPath: "metadata.labels.ha",
+		MatchString:   "true",
+	}}
 
 	r0 := corev1.ObjectReference{Name: n0}
+	r1 := corev1.ObjectReference{Name: n1}
 
 	type args struct {
 		ctx  context.Context
@@ -511,6 +920,57 @@ func TestGarbageCollectingAssociator(t *testing.T) {
 				tas: []TemplateAssociation{{Template: t0}},
 			},
 		},
+		"IncludedTemplate": {
+			reason: "We should associate resources with templates whose condition is true.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, obj.GetName())
+					return nil
+				}),
+			},
+			args: args{
+				cr: func() resource.Composite {
+					cr := composite.New()
+					cr.SetLabels(map[string]string{"ha": "true"})
+					cr.SetResourceReferences([]corev1.ObjectReference{r0, r1})
+					return cr
+				}(),
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0, t1}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}, {Template: t1, Reference: r1}},
+			},
+		},
+		"ExcludedTemplate": {
+			reason: "We should garbage collect resources whose template's condition is false.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj, obj.GetName())
+					return nil
+				}),
+				MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+					if obj.GetName() != n1 {
+						t.Errorf("Delete(...): unexpected deletion of %q", obj.GetName())
+					}
+					return nil
+				},
+			},
+			args: args{
+				cr: func() resource.Composite {
+					cr := composite.New()
+					cr.SetResourceReferences([]corev1.ObjectReference{r0, r1})
+					return cr
+				}(),
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0, t1}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+			},
+		},
 	}
 
 	for name, tc := range cases {
@@ -532,12 +992,15 @@ func TestFetch(t *testing.T) {
 	fromKey := v1.ConnectionDetailTypeFromConnectionSecretKey
 	fromVal := v1.ConnectionDetailTypeFromValue
 	fromField := v1.ConnectionDetailTypeFromFieldPath
+	toUpper := v1.StringConversionTypeToUpper
+	fromBase64 := v1.StringConversionTypeFromBase64
 
 	sref := &xpv1.SecretReference{Name: "foo", Namespace: "bar"}
 	s := &corev1.Secret{
 		Data: map[string][]byte{
-			"foo": []byte("a"),
-			"bar": []byte("b"),
+			"foo":     []byte("a"),
+			"bar":     []byte("b"),
+			"encoded": []byte("Y29vbA=="),
 		},
 	}
 
@@ -843,6 +1306,78 @@ func TestFetch(t *testing.T) {
 				},
 			},
 		},
+		"SuccessCombineAndTransform": {
+			reason: "Should combine secret keys and field paths, then transform the result",
+			args: args{
+				kube: &test.MockClient{MockGet: func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+					s.DeepCopyInto(obj.(*corev1.Secret))
+					return nil
+				}},
+				cd: &fake.Composed{
+					ConnectionSecretWriterTo: fake.ConnectionSecretWriterTo{Ref: sref},
+					ObjectMeta:               metav1.ObjectMeta{Name: "test"},
+				},
+				t: v1.ComposedTemplate{ConnectionDetails: []v1.ConnectionDetail{
+					{
+						Name: pointer.StringPtr("url"),
+						Combine: &v1.Combine{
+							Variables: []v1.CombineVariable{
+								{FromConnectionSecretKey: "foo"},
+								{FromConnectionSecretKey: "bar"},
+								{FromFieldPath: "objectMeta.name"},
+							},
+							Strategy: v1.CombineStrategyString,
+							String:   &v1.StringCombine{Format: "postgres://%s:%s@%s"},
+						},
+						Transforms: []v1.Transform{{
+							Type:   v1.TransformTypeString,
+							String: &v1.StringTransform{Type: v1.StringTransformTypeConvert, Convert: &toUpper},
+						}},
+					},
+					{
+						Name:                    pointer.StringPtr("decoded"),
+						FromConnectionSecretKey: pointer.StringPtr("encoded"),
+						Transforms: []v1.Transform{{
+							Type:   v1.TransformTypeString,
+							String: &v1.StringTransform{Type: v1.StringTransformTypeConvert, Convert: &fromBase64},
+						}},
+					},
+					{
+						Name: pointer.StringPtr("missing"),
+						Combine: &v1.Combine{
+							Variables: []v1.CombineVariable{{FromConnectionSecretKey: "nope"}},
+							Strategy:  v1.CombineStrategyList,
+						},
+					},
+				}},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"url":     []byte("POSTGRES://A:B@TEST"),
+					"decoded": []byte("cool"),
+				},
+			},
+		},
+		"TransformError": {
+			reason: "Should return an error if a connection detail cannot be transformed",
+			args: args{
+				cd: &fake.Composed{},
+				t: v1.ComposedTemplate{ConnectionDetails: []v1.ConnectionDetail{
+					{
+						Name:  pointer.StringPtr("fixed"),
+						Type:  &fromVal,
+						Value: pointer.StringPtr("value"),
+						Transforms: []v1.Transform{{
+							Type: v1.TransformTypeMath,
+							Math: &v1.MathTransform{Multiply: func() *v1.MathOperand { o := v1.MathOperand("2"); return &o }()},
+						}},
+					},
+				}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrapf(errors.Wrapf(errors.New("input is required to be a number for math transformer"), "%s transform could not resolve", v1.TransformTypeMath), errFmtTransformAtIdx, 0), errFmtConnDetailTransform, "fixed"),
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -936,6 +1471,21 @@ func TestIsReady(t *testing.T) {
 				ready: true,
 			},
 		},
+		"NoCustomCheckWellKnownKind": {
+			reason: "If no custom check is given, well-known kinds should be checked according to their status",
+			args: args{
+				cd: func() *composed.Unstructured {
+					cd := composed.New()
+					cd.SetAPIVersion("v1")
+					cd.SetKind("PersistentVolumeClaim")
+					cd.Object["status"] = map[string]interface{}{"phase": "Bound"}
+					return cd
+				}(),
+			},
+			want: want{
+				ready: true,
+			},
+		},
 		"ExplictNone": {
 			reason: "If the only readiness check is explicitly 'None' the resource is always ready.",
 			args: args{
@@ -1052,6 +1602,167 @@ func TestIsReady(t *testing.T) {
 				ready: true,
 			},
 		},
+		"MatchIntegerMissing": {
+			reason: "If the field does not exist, MatchInteger check should return false",
+			args: args{
+				cd: composed.New(),
+				t:  v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchInteger", FieldPath: "spec.someNum", MatchInteger: 5}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchIntegerGreaterThanOrEqualFieldPathTrue": {
+			reason: "If the value of the field is at least the value of the other field, it should return true",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{
+						"spec":   map[string]interface{}{"replicas": int64(3)},
+						"status": map[string]interface{}{"readyReplicas": int64(3)},
+					}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{
+					Type:                  v1.ReadinessCheckTypeMatchIntegerGreaterThanOrEqual,
+					FieldPath:             "status.readyReplicas",
+					MatchIntegerFieldPath: pointer.StringPtr("spec.replicas"),
+				}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchIntegerGreaterThanFalse": {
+			reason: "If the value of the field is not greater than the match, it should return false",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{"spec": map[string]interface{}{"someNum": int64(5)}}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeMatchIntegerGreaterThan, FieldPath: "spec.someNum", MatchInteger: 5}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchIntegerLessThanTrue": {
+			reason: "If the value of the field is less than the match, it should return true",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{"spec": map[string]interface{}{"someNum": int64(4)}}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeMatchIntegerLessThan, FieldPath: "spec.someNum", MatchInteger: 5}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchIntegerLessThanOrEqualFieldPathMissing": {
+			reason: "If the field to compare against does not exist, it should return false",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{"spec": map[string]interface{}{"someNum": int64(4)}}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{
+					Type:                  v1.ReadinessCheckTypeMatchIntegerLessThanOrEqual,
+					FieldPath:             "spec.someNum",
+					MatchIntegerFieldPath: pointer.StringPtr("spec.otherNum"),
+				}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchTrueTrue": {
+			reason: "If the value of the field is true, MatchTrue should return true",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{"status": map[string]interface{}{"ready": true}}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeMatchTrue, FieldPath: "status.ready"}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchFalseFalse": {
+			reason: "If the value of the field is true, MatchFalse should return false",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{"status": map[string]interface{}{"ready": true}}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeMatchFalse, FieldPath: "status.ready"}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchTrueMissing": {
+			reason: "If the field does not exist, MatchTrue should return false",
+			args: args{
+				cd: composed.New(),
+				t:  v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeMatchTrue, FieldPath: "status.ready"}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchRegexInvalid": {
+			reason: "If the regular expression is invalid, an error should be returned",
+			args: args{
+				cd: composed.New(),
+				t:  v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeMatchRegex, FieldPath: "metadata.uid", MatchRegex: "("}}},
+			},
+			want: want{
+				err: errors.Wrap(errors.New("error parsing regexp: missing closing ): `(`"), errCompileRegex),
+			},
+		},
+		"MatchRegexTrue": {
+			reason: "If the value of the field matches the regular expression, it should return true",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.SetUID("olala")
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeMatchRegex, FieldPath: "metadata.uid", MatchRegex: "^ola"}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchConditionMissingConfig": {
+			reason: "If no condition is specified, an error should be returned",
+			args: args{
+				cd: composed.New(),
+				t:  v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeMatchCondition}}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtReadinessConfig, 0),
+			},
+		},
+		"MatchConditionTrue": {
+			reason: "If the condition has the default True status, it should return true",
+			args: args{
+				cd: composed.New(composed.WithConditions(xpv1.Condition{Type: "Synced", Status: corev1.ConditionTrue})),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{
+					Type:           v1.ReadinessCheckTypeMatchCondition,
+					MatchCondition: &v1.MatchConditionReadinessCheck{Type: "Synced"},
+				}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchConditionFalse": {
+			reason: "If the condition does not have the specified status, it should return false",
+			args: args{
+				cd: composed.New(composed.WithConditions(xpv1.Condition{Type: "Synced", Status: corev1.ConditionTrue})),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{
+					Type:           v1.ReadinessCheckTypeMatchCondition,
+					MatchCondition: &v1.MatchConditionReadinessCheck{Type: "Synced", Status: corev1.ConditionFalse},
+				}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
 		"UnknownType": {
 			reason: "If unknown type is chosen, it should return an error",
 			args: args{
@@ -0,0 +1,264 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+// Error strings.
+const (
+	errOrphanComposed = "cannot orphan composed resource"
+	errDeleteComposed = "cannot delete composed resource"
+)
+
+// Composite resource field paths.
+const (
+	fieldDeletionPolicy = "spec.deletionPolicy"
+)
+
+// Annotation keys.
+const (
+	AnnotationKeyDeletionPolicy = "crossplane.io/composition-deletion-policy"
+)
+
+// GetCompositeDeletionPolicy returns the deletion policy of the supplied
+// composite resource. Composite resources that do not specify a policy delete
+// their composed resources.
+func GetCompositeDeletionPolicy(cr resource.Composite) xpv1.DeletionPolicy {
+	u, ok := cr.(*composite.Unstructured)
+	if !ok {
+		return xpv1.DeletionDelete
+	}
+	p, err := fieldpath.Pave(u.Object).GetString(fieldDeletionPolicy)
+	if err != nil || p == "" {
+		return xpv1.DeletionDelete
+	}
+	return xpv1.DeletionPolicy(p)
+}
+
+// DeletionPolicyFor returns the deletion policy of a composed resource rendered
+// from the supplied template for the supplied composite resource. The
+// template's policy takes precedence over the composite resource's.
+func DeletionPolicyFor(cr resource.Composite, t v1.ComposedTemplate) xpv1.DeletionPolicy {
+	if t.DeletionPolicy != nil {
+		return *t.DeletionPolicy
+	}
+	return GetCompositeDeletionPolicy(cr)
+}
+
+// NeedsFinalizer returns true if a composite resource with the supplied
+// resource templates must not be deleted until Crossplane has orphaned or
+// deleted its composed resources; i.e. if any template orders deletion using
+// dependsOn, or would orphan its composed resource. Otherwise Kubernetes garbage
+// collects the composite resource's composed resources.
+func NeedsFinalizer(cr resource.Composite, ts []v1.ComposedTemplate) bool {
+	for _, t := range ts {
+		if IsObserved(t) {
+			continue
+		}
+		if len(t.DependsOn) > 0 || DeletionPolicyFor(cr, t) == xpv1.DeletionOrphan {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDeletionPolicy sets the deletion policy of a composed resource as an
+// annotation.
+func SetDeletionPolicy(o metav1.Object, p xpv1.DeletionPolicy) {
+	meta.AddAnnotations(o, map[string]string{AnnotationKeyDeletionPolicy: string(p)})
+}
+
+// GetDeletionPolicy gets the deletion policy of a composed resource from its
+// annotations. Composed resources without a deletion policy are deleted.
+func GetDeletionPolicy(o metav1.Object) xpv1.DeletionPolicy {
+	if p := o.GetAnnotations()[AnnotationKeyDeletionPolicy]; p != "" {
+		return xpv1.DeletionPolicy(p)
+	}
+	return xpv1.DeletionDelete
+}
+
+// An Orphaner orphans the composed resources of a composite resource that is
+// being deleted, according to their deletion policies.
+type Orphaner interface {
+	Orphan(ctx context.Context, cr resource.Composite) error
+}
+
+// An OrphanerFn orphans the composed resources of a composite resource that
+// is being deleted.
+type OrphanerFn func(ctx context.Context, cr resource.Composite) error
+
+// Orphan the composed resources of the supplied composite resource.
+func (fn OrphanerFn) Orphan(ctx context.Context, cr resource.Composite) error {
+	return fn(ctx, cr)
+}
+
+// NewAPIOrphaner returns an Orphaner that releases composed resources with
+// the Orphan deletion policy.
+func NewAPIOrphaner(c client.Client) *APIOrphaner {
+	return &APIOrphaner{client: c}
+}
+
+// An APIOrphaner releases composed resources with the Orphan deletion policy
+// from their composite resource, so that they are not garbage collected.
+type APIOrphaner struct {
+	client client.Client
+}
+
+// Orphan the composed resources of the supplied composite resource that have
+// the Orphan deletion policy. Owner references to the composite resource and
+// the labels that associate the composed resources with it are removed, so
+// that they may later be adopted by another composite resource.
+func (o *APIOrphaner) Orphan(ctx context.Context, cr resource.Composite) error {
+	for _, ref := range cr.GetResourceReferences() {
+		// We can't orphan a composed resource that was never named, and thus
+		// never created.
+		if ref.Name == "" {
+			continue
+		}
+		cd := composed.New(composed.FromReference(ref))
+		nn := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+		if err := o.client.Get(ctx, nn, cd); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrap(err, errGetComposed)
+		}
+		if GetDeletionPolicy(cd) != xpv1.DeletionOrphan {
+			continue
+		}
+
+		refs := make([]metav1.OwnerReference, 0, len(cd.GetOwnerReferences()))
+		for _, or := range cd.GetOwnerReferences() {
+			if or.UID != cr.GetUID() {
+				refs = append(refs, or)
+			}
+		}
+		cd.SetOwnerReferences(refs)
+		meta.RemoveLabels(cd, xcrd.LabelKeyNamePrefixForComposed, xcrd.LabelKeyClaimName, xcrd.LabelKeyClaimNamespace)
+
+		if err := o.client.Update(ctx, cd); err != nil {
+			return errors.Wrap(err, errOrphanComposed)
+		}
+	}
+	return nil
+}
+
+// A ComposedDeleter deletes the composed resources of a composite resource
+// that is being deleted.
+type ComposedDeleter interface {
+	// DeleteComposed deletes the composed resources of the supplied composite
+	// resource. It returns true once all of them are deleted or being deleted.
+	DeleteComposed(ctx context.Context, cr resource.Composite) (done bool, err error)
+}
+
+// A ComposedDeleterFn deletes the composed resources of a composite resource
+// that is being deleted.
+type ComposedDeleterFn func(ctx context.Context, cr resource.Composite) (done bool, err error)
+
+// DeleteComposed deletes the composed resources of the supplied composite
+// resource.
+func (fn ComposedDeleterFn) DeleteComposed(ctx context.Context, cr resource.Composite) (done bool, err error) {
+	return fn(ctx, cr)
+}
+
+// NewAPIOrderedDeleter returns a ComposedDeleter that deletes composed
+// resources in the reverse order of their dependencies.
+func NewAPIOrderedDeleter(c client.Client) *APIOrderedDeleter {
+	return &APIOrderedDeleter{client: c}
+}
+
+// An APIOrderedDeleter deletes composed resources in the reverse order of
+// their dependencies; i.e. a composed resource is not deleted until the
+// composed resources that depend on it are gone.
+type APIOrderedDeleter struct {
+	client client.Client
+}
+
+// DeleteComposed deletes each composed resource of the supplied composite
+// resource that no other composed resource depends on. Composed resources that
+// are not controlled by the composite resource, including orphaned ones, are
+// ignored.
+func (d *APIOrderedDeleter) DeleteComposed(ctx context.Context, cr resource.Composite) (bool, error) {
+	cds := make([]*composed.Unstructured, 0, len(cr.GetResourceReferences()))
+	for _, ref := range cr.GetResourceReferences() {
+		if ref.Name == "" {
+			continue
+		}
+		cd := composed.New(composed.FromReference(ref))
+		nn := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+		if err := d.client.Get(ctx, nn, cd); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return false, errors.Wrap(err, errGetComposed)
+		}
+		if c := metav1.GetControllerOf(cd); c == nil || c.UID != cr.GetUID() {
+			continue
+		}
+		cds = append(cds, cd)
+	}
+
+	done := true
+	for _, cd := range cds {
+		if hasDependents(cd, cds) {
+			done = false
+			continue
+		}
+		if meta.WasDeleted(cd) {
+			continue
+		}
+		if err := d.client.Delete(ctx, cd); resource.IgnoreNotFound(err) != nil {
+			return false, errors.Wrap(err, errDeleteComposed)
+		}
+	}
+	return done, nil
+}
+
+// hasDependents returns true if any of the supplied composed resources depend
+// on the supplied composed resource.
+func hasDependents(cd resource.Composed, cds []*composed.Unstructured) bool {
+	name := GetCompositionResourceName(cd)
+	if name == "" {
+		return false
+	}
+	for _, other := range cds {
+		for _, dep := range GetDependsOn(other) {
+			if IsDependency(dep, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,450 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+func TestDeletionPolicyFor(t *testing.T) {
+	orphan := xpv1.DeletionOrphan
+	deleteP := xpv1.DeletionDelete
+	xr := func(p xpv1.DeletionPolicy) *composite.Unstructured {
+		cr := composite.New()
+		if p != "" {
+			_ = fieldpath.Pave(cr.Object).SetValue(fieldDeletionPolicy, string(p))
+		}
+		return cr
+	}
+
+	type args struct {
+		cr resource.Composite
+		t  v1.ComposedTemplate
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   xpv1.DeletionPolicy
+	}{
+		"Default": {
+			reason: "Composed resources should be deleted if neither the template nor the composite resource specify a policy.",
+			args: args{
+				cr: xr(""),
+			},
+			want: xpv1.DeletionDelete,
+		},
+		"CompositePolicy": {
+			reason: "The composite resource's policy should be used if the template does not specify one.",
+			args: args{
+				cr: xr(xpv1.DeletionOrphan),
+			},
+			want: xpv1.DeletionOrphan,
+		},
+		"TemplatePolicy": {
+			reason: "The template's policy should be used if it specifies one.",
+			args: args{
+				cr: xr(""),
+				t:  v1.ComposedTemplate{DeletionPolicy: &orphan},
+			},
+			want: xpv1.DeletionOrphan,
+		},
+		"TemplatePolicyTakesPrecedence": {
+			reason: "The template's policy should take precedence over the composite resource's.",
+			args: args{
+				cr: xr(xpv1.DeletionOrphan),
+				t:  v1.ComposedTemplate{DeletionPolicy: &deleteP},
+			},
+			want: xpv1.DeletionDelete,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := DeletionPolicyFor(tc.args.cr, tc.args.t)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nDeletionPolicyFor(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestNeedsFinalizer(t *testing.T) {
+	orphan := xpv1.DeletionOrphan
+	deleteP := xpv1.DeletionDelete
+	observe := v1.ComposedTemplateModeObserve
+	xr := func(p xpv1.DeletionPolicy) *composite.Unstructured {
+		cr := composite.New()
+		if p != "" {
+			_ = fieldpath.Pave(cr.Object).SetValue(fieldDeletionPolicy, string(p))
+		}
+		return cr
+	}
+
+	type args struct {
+		cr resource.Composite
+		ts []v1.ComposedTemplate
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   bool
+	}{
+		"NoTemplates": {
+			reason: "A composite resource without resource templates does not need a finalizer.",
+			args: args{
+				cr: xr(""),
+			},
+			want: false,
+		},
+		"Delete": {
+			reason: "A composite resource whose composed resources are deleted in no particular order does not need a finalizer.",
+			args: args{
+				cr: xr(""),
+				ts: []v1.ComposedTemplate{{}, {DeletionPolicy: &deleteP}},
+			},
+			want: false,
+		},
+		"TemplateOrphan": {
+			reason: "A composite resource needs a finalizer if one of its templates orphans its composed resource.",
+			args: args{
+				cr: xr(""),
+				ts: []v1.ComposedTemplate{{}, {DeletionPolicy: &orphan}},
+			},
+			want: true,
+		},
+		"CompositeOrphan": {
+			reason: "A composite resource needs a finalizer if it orphans its composed resources.",
+			args: args{
+				cr: xr(xpv1.DeletionOrphan),
+				ts: []v1.ComposedTemplate{{}},
+			},
+			want: true,
+		},
+		"TemplateOverridesCompositeOrphan": {
+			reason: "A composite resource does not need a finalizer if all of its templates override its Orphan policy.",
+			args: args{
+				cr: xr(xpv1.DeletionOrphan),
+				ts: []v1.ComposedTemplate{{DeletionPolicy: &deleteP}},
+			},
+			want: false,
+		},
+		"DependsOn": {
+			reason: "A composite resource needs a finalizer if its composed resources must be deleted in order.",
+			args: args{
+				cr: xr(""),
+				ts: []v1.ComposedTemplate{{}, {DependsOn: []string{"cool"}}},
+			},
+			want: true,
+		},
+		"Observed": {
+			reason: "Observed resources are never orphaned or deleted, so they do not need a finalizer.",
+			args: args{
+				cr: xr(""),
+				ts: []v1.ComposedTemplate{{Mode: &observe, DeletionPolicy: &orphan, DependsOn: []string{"cool"}}},
+			},
+			want: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := NeedsFinalizer(tc.args.cr, tc.args.ts)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nNeedsFinalizer(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPIOrphaner(t *testing.T) {
+	errBoom := errors.New("boom")
+	uid := types.UID("cool-xr")
+
+	xr := func(refs ...corev1.ObjectReference) *composite.Unstructured {
+		cr := composite.New()
+		cr.SetUID(uid)
+		cr.SetResourceReferences(refs)
+		return cr
+	}
+	cd := func(p xpv1.DeletionPolicy) *composed.Unstructured {
+		u := composed.New()
+		u.SetName("cool-cd")
+		u.SetLabels(map[string]string{
+			xcrd.LabelKeyNamePrefixForComposed: "cool-xr",
+			xcrd.LabelKeyClaimName:             "cool-claim",
+			xcrd.LabelKeyClaimNamespace:        "default",
+			"other":                            "label",
+		})
+		u.SetOwnerReferences([]metav1.OwnerReference{{UID: uid}, {UID: "other"}})
+		SetDeletionPolicy(u, p)
+		return u
+	}
+	ref := corev1.ObjectReference{APIVersion: "v", Kind: "K", Name: "cool-cd"}
+
+	type args struct {
+		client client.Client
+		cr     resource.Composite
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"UnnamedReference": {
+			reason: "We should skip references to composed resources that were never named.",
+			args: args{
+				client: &test.MockClient{},
+				cr:     xr(corev1.ObjectReference{APIVersion: "v", Kind: "K"}),
+			},
+		},
+		"NotFound": {
+			reason: "We should skip composed resources that do not exist.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+				},
+				cr: xr(ref),
+			},
+		},
+		"GetError": {
+			reason: "We should return any error encountered getting a composed resource.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: test.NewMockGetFn(errBoom),
+				},
+				cr: xr(ref),
+			},
+			want: errors.Wrap(errBoom, errGetComposed),
+		},
+		"DeletePolicy": {
+			reason: "We should not update composed resources with the Delete policy.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						obj.(*composed.Unstructured).SetUnstructuredContent(cd(xpv1.DeletionDelete).UnstructuredContent())
+						return nil
+					}),
+				},
+				cr: xr(ref),
+			},
+		},
+		"UpdateError": {
+			reason: "We should return any error encountered orphaning a composed resource.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						obj.(*composed.Unstructured).SetUnstructuredContent(cd(xpv1.DeletionOrphan).UnstructuredContent())
+						return nil
+					}),
+					MockUpdate: test.NewMockUpdateFn(errBoom),
+				},
+				cr: xr(ref),
+			},
+			want: errors.Wrap(errBoom, errOrphanComposed),
+		},
+		"Orphaned": {
+			reason: "We should remove our owner reference and labels from composed resources with the Orphan policy.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						obj.(*composed.Unstructured).SetUnstructuredContent(cd(xpv1.DeletionOrphan).UnstructuredContent())
+						return nil
+					}),
+					MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+						want := composed.New()
+						want.SetName("cool-cd")
+						want.SetLabels(map[string]string{"other": "label"})
+						want.SetOwnerReferences([]metav1.OwnerReference{{UID: "other"}})
+						SetDeletionPolicy(want, xpv1.DeletionOrphan)
+						if diff := cmp.Diff(want, obj); diff != "" {
+							t.Errorf("Update(...): -want, +got:\n%s", diff)
+						}
+						return nil
+					}),
+				},
+				cr: xr(ref),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := NewAPIOrphaner(tc.args.client)
+			err := o.Orphan(context.Background(), tc.args.cr)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nOrphan(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPIOrderedDeleter(t *testing.T) {
+	errBoom := errors.New("boom")
+	uid := types.UID("cool-xr")
+	now := metav1.Now()
+
+	xr := func(refs ...corev1.ObjectReference) *composite.Unstructured {
+		cr := composite.New()
+		cr.SetUID(uid)
+		cr.SetResourceReferences(refs)
+		return cr
+	}
+	cd := func(name string, owner types.UID, dependsOn ...string) *composed.Unstructured {
+		ctrl := true
+		u := composed.New()
+		u.SetName(name)
+		u.SetOwnerReferences([]metav1.OwnerReference{{UID: owner, Controller: &ctrl}})
+		SetCompositionResourceName(u, name)
+		if len(dependsOn) > 0 {
+			SetDependsOn(u, dependsOn)
+		}
+		return u
+	}
+	ref := func(name string) corev1.ObjectReference {
+		return corev1.ObjectReference{APIVersion: "v", Kind: "K", Name: name}
+	}
+	get := func(cds ...*composed.Unstructured) test.MockGetFn {
+		return func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+			for _, cd := range cds {
+				if cd.GetName() == key.Name {
+					obj.(*composed.Unstructured).SetUnstructuredContent(cd.DeepCopy().UnstructuredContent())
+					return nil
+				}
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+		}
+	}
+
+	type args struct {
+		client client.Client
+		cr     resource.Composite
+	}
+	type want struct {
+		done bool
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"GetError": {
+			reason: "We should return any error encountered getting a composed resource.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: test.NewMockGetFn(errBoom),
+				},
+				cr: xr(ref("cluster")),
+			},
+			want: want{err: errors.Wrap(errBoom, errGetComposed)},
+		},
+		"DeleteError": {
+			reason: "We should return any error encountered deleting a composed resource.",
+			args: args{
+				client: &test.MockClient{
+					MockGet:    get(cd("cluster", uid)),
+					MockDelete: test.NewMockDeleteFn(errBoom),
+				},
+				cr: xr(ref("cluster")),
+			},
+			want: want{err: errors.Wrap(errBoom, errDeleteComposed)},
+		},
+		"AllGone": {
+			reason: "We should be done if all composed resources are gone.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: get(),
+				},
+				cr: xr(ref("cluster"), corev1.ObjectReference{APIVersion: "v", Kind: "K"}),
+			},
+			want: want{done: true},
+		},
+		"NotControlled": {
+			reason: "We should not delete composed resources we don't control, e.g. because they were orphaned.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: get(cd("cluster", "other")),
+				},
+				cr: xr(ref("cluster")),
+			},
+			want: want{done: true},
+		},
+		"ReverseOrder": {
+			reason: "We should only delete composed resources that no other composed resource depends on.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: get(cd("nodepool", uid, "cluster"), cd("cluster", uid, "network"), cd("network", uid)),
+					MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+						if obj.GetName() != "nodepool" {
+							t.Errorf("Delete(...): unexpected deletion of %q", obj.GetName())
+						}
+						return nil
+					},
+				},
+				cr: xr(ref("nodepool"), ref("cluster"), ref("network")),
+			},
+			want: want{done: false},
+		},
+		"AlreadyDeleting": {
+			reason: "We should not delete composed resources that are already being deleted.",
+			args: args{
+				client: &test.MockClient{
+					MockGet: get(func() *composed.Unstructured {
+						u := cd("cluster", uid)
+						u.SetDeletionTimestamp(&now)
+						return u
+					}()),
+				},
+				cr: xr(ref("cluster")),
+			},
+			want: want{done: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			d := NewAPIOrderedDeleter(tc.args.client)
+			done, err := d.DeleteComposed(context.Background(), tc.args.cr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nDeleteComposed(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.done, done); diff != "" {
+				t.Errorf("\n%s\nDeleteComposed(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/dag"
+)
+
+// Error strings.
+const (
+	errBuildDependencyGraph = "cannot build resource template dependency graph"
+	errCyclicDependencies   = "resource template dependencies must not be cyclic"
+
+	errFmtAnonymousDependsOn  = "resource template at index %d must be named to specify dependsOn"
+	errFmtUnknownDependency   = "resource template %q depends on unknown resource template %q"
+	errFmtDependencyReadiness = "cannot determine whether composed resource of resource template %q is ready"
+)
+
+// Annotation keys.
+const (
+	AnnotationKeyCompositionResourceDependsOn = "crossplane.io/composition-resource-depends-on"
+)
+
+// SetDependsOn sets the names of the composition templates a composed resource
+// depends on as an annotation.
+func SetDependsOn(o metav1.Object, names []string) {
+	meta.AddAnnotations(o, map[string]string{AnnotationKeyCompositionResourceDependsOn: strings.Join(names, ",")})
+}
+
+// GetDependsOn gets the names of the composition templates a composed resource
+// depends on from its annotations.
+func GetDependsOn(o metav1.Object) []string {
+	v := o.GetAnnotations()[AnnotationKeyCompositionResourceDependsOn]
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// A templateNode is a named resource template in a DAG of resource templates.
+// Its neighbors are the templates it depends on.
+type templateNode struct {
+	name      string
+	dependsOn []dag.Node
+}
+
+func (n *templateNode) Identifier() string {
+	return n.name
+}
+
+func (n *templateNode) Neighbors() []dag.Node {
+	return n.dependsOn
+}
+
+func (n *templateNode) AddNeighbors(nodes ...dag.Node) error {
+	for _, node := range nodes {
+		exists := false
+		for _, d := range n.dependsOn {
+			if d.Identifier() == node.Identifier() {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			n.dependsOn = append(n.dependsOn, node)
+		}
+	}
+	return nil
+}
+
+// RejectInvalidDependencies validates that only named templates within the
+// supplied Composition specify dependsOn, that they depend only on other
+// templates within the Composition, and that their dependencies are not
+// cyclic.
+func RejectInvalidDependencies(comp *v1.Composition) error {
+	names := map[string]bool{}
+	for _, t := range comp.Spec.Resources {
+		if t.Name != nil {
+			names[*t.Name] = true
+		}
+	}
+
+	nodes := make([]dag.Node, 0, len(comp.Spec.Resources))
+	for i, t := range comp.Spec.Resources {
+		if t.Name == nil {
+			if len(t.DependsOn) > 0 {
+				return errors.Errorf(errFmtAnonymousDependsOn, i)
+			}
+			continue
+		}
+		n := &templateNode{name: *t.Name}
+		for _, name := range t.DependsOn {
+			if !names[name] {
+				return errors.Errorf(errFmtUnknownDependency, *t.Name, name)
+			}
+			n.dependsOn = append(n.dependsOn, &templateNode{name: name})
+		}
+		nodes = append(nodes, n)
+	}
+
+	d := dag.NewMapDag()
+	if _, err := d.Init(nodes); err != nil {
+		return errors.Wrap(err, errBuildDependencyGraph)
+	}
+	_, err := d.Sort()
+	return errors.Wrap(err, errCyclicDependencies)
+}
+
+// IsDependency returns true if the template with the supplied name satisfies
+// the supplied dependency; i.e. if it is the named template, or was expanded
+// from it by forEach.
+func IsDependency(dependency, name string) bool {
+	if name == dependency {
+		return true
+	}
+	return strings.HasPrefix(name, dependency+"[") && strings.HasSuffix(name, "]")
+}
+
+// ApplyOrder returns the indices of the supplied templates in the order in
+// which their composed resources should be applied. Templates are ordered
+// after the templates they depend on, and otherwise in the order they appear.
+// Templates whose dependencies can never be satisfied, because they are
+// cyclic, are ordered last.
+func ApplyOrder(ts []v1.ComposedTemplate) []int {
+	order := make([]int, 0, len(ts))
+	ordered := make([]bool, len(ts))
+
+	for progress := true; progress; {
+		progress = false
+		for i := range ts {
+			if ordered[i] || !dependenciesOrdered(ts, ordered, ts[i]) {
+				continue
+			}
+			order = append(order, i)
+			ordered[i] = true
+			progress = true
+		}
+	}
+
+	for i := range ts {
+		if !ordered[i] {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+func dependenciesOrdered(ts []v1.ComposedTemplate, ordered []bool, t v1.ComposedTemplate) bool {
+	for _, dep := range t.DependsOn {
+		for i := range ts {
+			if ts[i].Name != nil && IsDependency(dep, *ts[i].Name) && !ordered[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// DependenciesReady returns true if the composed resources of all of the
+// templates the supplied template depends on have been applied and are ready.
+// Applied composed resources are keyed by template name. Dependencies that
+// were excluded by their condition, or that were expanded from an empty
+// forEach array, are considered to be ready.
+func DependenciesReady(ctx context.Context, rc ReadinessChecker, t v1.ComposedTemplate, tas []TemplateAssociation, applied map[string]resource.Composed) (bool, error) {
+	for _, dep := range t.DependsOn {
+		for _, ta := range tas {
+			if ta.Template.Name == nil || !IsDependency(dep, *ta.Template.Name) {
+				continue
+			}
+			cd, ok := applied[*ta.Template.Name]
+			if !ok {
+				return false, nil
+			}
+			ready, err := rc.IsReady(ctx, cd, ta.Template)
+			if err != nil {
+				return false, errors.Wrapf(err, errFmtDependencyReadiness, *ta.Template.Name)
+			}
+			if !ready {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
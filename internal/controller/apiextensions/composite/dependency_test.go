@@ -0,0 +1,227 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func named(name string, dependsOn ...string) v1.ComposedTemplate {
+	return v1.ComposedTemplate{Name: &name, DependsOn: dependsOn}
+}
+
+func TestRejectInvalidDependencies(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		comp   *v1.Composition
+		want   error
+	}{
+		"NoDependencies": {
+			reason: "Templates that don't specify dependsOn should be valid.",
+			comp:   &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{}, {}}}},
+		},
+		"ValidDependencies": {
+			reason: "Named templates that depend on other templates in the Composition should be valid.",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{
+				named("nodepool", "cluster", "network"),
+				named("cluster", "network"),
+				named("network"),
+			}}},
+		},
+		"AnonymousDependsOn": {
+			reason: "Anonymous templates should not be allowed to specify dependsOn.",
+			comp:   &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{DependsOn: []string{"cluster"}}}}},
+			want:   errors.Errorf(errFmtAnonymousDependsOn, 0),
+		},
+		"UnknownDependency": {
+			reason: "Templates should not be allowed to depend on templates that don't exist.",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{
+				named("nodepool", "cluster"),
+			}}},
+			want: errors.Errorf(errFmtUnknownDependency, "nodepool", "cluster"),
+		},
+		"CyclicDependency": {
+			reason: "Templates should not be allowed to depend on themselves.",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{
+				named("cluster", "cluster"),
+			}}},
+			want: errors.Wrap(errors.New("detected cycle on: cluster"), errCyclicDependencies),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectInvalidDependencies(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRejectInvalidDependencies(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestApplyOrder(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		ts     []v1.ComposedTemplate
+		want   []int
+	}{
+		"Anonymous": {
+			reason: "Anonymous templates should be applied in the order they appear.",
+			ts:     []v1.ComposedTemplate{{}, {}, {}},
+			want:   []int{0, 1, 2},
+		},
+		"Dependencies": {
+			reason: "Templates should be applied after the templates they depend on, and otherwise in the order they appear.",
+			ts: []v1.ComposedTemplate{
+				named("nodepool", "cluster"),
+				named("bucket"),
+				named("cluster", "network"),
+				named("network"),
+			},
+			want: []int{1, 3, 2, 0},
+		},
+		"ForEachDependency": {
+			reason: "Templates should be applied after all templates expanded from the template they depend on.",
+			ts: []v1.ComposedTemplate{
+				named("cluster", "subnet"),
+				named(ForEachTemplateName("subnet", "a")),
+				named(ForEachTemplateName("subnet", "b")),
+			},
+			want: []int{1, 2, 0},
+		},
+		"CyclicDependencies": {
+			reason: "Templates with cyclic dependencies should be applied last.",
+			ts: []v1.ComposedTemplate{
+				named("a", "b"),
+				named("b", "a"),
+				named("c"),
+			},
+			want: []int{2, 0, 1},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ApplyOrder(tc.ts)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nApplyOrder(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDependenciesReady(t *testing.T) {
+	errBoom := errors.New("boom")
+	tas := []TemplateAssociation{
+		{Template: named("nodepool", "cluster")},
+		{Template: named("cluster")},
+	}
+	ready := ReadinessCheckerFn(func(_ context.Context, _ resource.Composed, _ v1.ComposedTemplate) (bool, error) {
+		return true, nil
+	})
+
+	type args struct {
+		rc      ReadinessChecker
+		t       v1.ComposedTemplate
+		applied map[string]resource.Composed
+	}
+	type want struct {
+		ready bool
+		err   error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoDependencies": {
+			reason: "A template with no dependencies should always be ready to apply.",
+			args: args{
+				t: named("cluster"),
+			},
+			want: want{ready: true},
+		},
+		"ExcludedDependency": {
+			reason: "Dependencies that were not rendered, e.g. because their condition was false, should be considered ready.",
+			args: args{
+				t: named("nodepool", "network"),
+			},
+			want: want{ready: true},
+		},
+		"DependencyNotApplied": {
+			reason: "A template should not be ready to apply if a dependency has not been applied.",
+			args: args{
+				rc: ready,
+				t:  tas[0].Template,
+			},
+			want: want{ready: false},
+		},
+		"ReadinessError": {
+			reason: "Errors determining whether a dependency is ready should be returned.",
+			args: args{
+				rc: ReadinessCheckerFn(func(_ context.Context, _ resource.Composed, _ v1.ComposedTemplate) (bool, error) {
+					return false, errBoom
+				}),
+				t:       tas[0].Template,
+				applied: map[string]resource.Composed{"cluster": composed.New()},
+			},
+			want: want{err: errors.Wrapf(errBoom, errFmtDependencyReadiness, "cluster")},
+		},
+		"DependencyNotReady": {
+			reason: "A template should not be ready to apply if a dependency is not ready.",
+			args: args{
+				rc: ReadinessCheckerFn(func(_ context.Context, _ resource.Composed, _ v1.ComposedTemplate) (bool, error) {
+					return false, nil
+				}),
+				t:       tas[0].Template,
+				applied: map[string]resource.Composed{"cluster": composed.New()},
+			},
+			want: want{ready: false},
+		},
+		"DependencyReady": {
+			reason: "A template should be ready to apply once its dependencies are ready.",
+			args: args{
+				rc:      ready,
+				t:       tas[0].Template,
+				applied: map[string]resource.Composed{"cluster": composed.New()},
+			},
+			want: want{ready: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := DependenciesReady(context.Background(), tc.args.rc, tc.args.t, tas, tc.args.applied)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nDependenciesReady(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ready, got); diff != "" {
+				t.Errorf("\n%s\nDependenciesReady(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
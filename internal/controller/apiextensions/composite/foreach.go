@@ -0,0 +1,180 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Error strings.
+const (
+	errForEachNotArray = "forEach field path must be an array"
+	errMarshalBase     = "cannot marshal base template"
+
+	errFmtAnonymousForEach    = "resource template at index %d must be named to specify forEach"
+	errFmtReservedName        = "resource template name %q must not contain %q"
+	errFmtForEach             = "cannot expand forEach of resource template %q"
+	errFmtForEachKey          = "cannot determine key of element at index %d"
+	errFmtForEachDuplicateKey = "elements at index %d and %d have the same key %q"
+	errFmtForEachPatch        = "cannot apply the patch at index %d to element at index %d"
+)
+
+// forEach element object fields.
+const (
+	forEachElement = "element"
+	forEachIndex   = "index"
+)
+
+// RejectAnonymousForEachTemplates validates that only named templates within
+// the supplied Composition specify forEach. Expanded templates are identified
+// by their name and the key of their element.
+func RejectAnonymousForEachTemplates(comp *v1.Composition) error {
+	for i, tmpl := range comp.Spec.Resources {
+		if tmpl.Name == nil && tmpl.ForEach != nil {
+			return errors.Errorf(errFmtAnonymousForEach, i)
+		}
+	}
+	return nil
+}
+
+// RejectReservedTemplateNames validates that no template within the supplied
+// Composition has a name containing '['. Such names are reserved for templates
+// expanded by forEach, and would otherwise collide with them.
+func RejectReservedTemplateNames(comp *v1.Composition) error {
+	for _, tmpl := range comp.Spec.Resources {
+		if tmpl.Name != nil && strings.Contains(*tmpl.Name, "[") {
+			return errors.Errorf(errFmtReservedName, *tmpl.Name, "[")
+		}
+	}
+	return nil
+}
+
+// ForEachTemplateName returns the name of the template expanded from the
+// supplied template name for the element with the supplied key.
+func ForEachTemplateName(name, key string) string {
+	return fmt.Sprintf("%s[%s]", name, key)
+}
+
+// ExpandForEach expands the supplied template once per element of its forEach
+// array field. Each expanded template is named for the supplied template and
+// the key of its element, and has its FromForEach patches applied to its base.
+// Templates that do not specify forEach are returned as is.
+func ExpandForEach(cp resource.Composite, t v1.ComposedTemplate) ([]v1.ComposedTemplate, error) {
+	if t.ForEach == nil {
+		return []v1.ComposedTemplate{t}, nil
+	}
+
+	v, err := fieldPathValue(cp, t.ForEach.FromFieldPath)
+	if fieldpath.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	elements, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New(errForEachNotArray)
+	}
+
+	out := make([]v1.ComposedTemplate, len(elements))
+	seen := map[string]int{}
+	for i, e := range elements {
+		key, err := forEachKey(t.ForEach, i, e)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtForEachKey, i)
+		}
+		if j, ok := seen[key]; ok {
+			return nil, errors.Errorf(errFmtForEachDuplicateKey, j, i, key)
+		}
+		seen[key] = i
+
+		et, err := expandElement(t, i, e)
+		if err != nil {
+			return nil, err
+		}
+		name := ForEachTemplateName(*t.Name, key)
+		et.Name = &name
+		out[i] = et
+	}
+	return out, nil
+}
+
+func forEachKey(fe *v1.ComposedTemplateForEach, i int, e interface{}) (string, error) {
+	if fe.KeyFieldPath != nil {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return "", errors.Errorf(errFmtForEachKey, i)
+		}
+		k, err := fieldpath.Pave(m).GetValue(*fe.KeyFieldPath)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(k), nil
+	}
+	switch e.(type) {
+	case string, bool, int64, float64:
+		return fmt.Sprint(e), nil
+	default:
+		return fmt.Sprint(i), nil
+	}
+}
+
+// expandElement returns a copy of the supplied template with its FromForEach
+// patches applied to its base, and removed from its patches.
+func expandElement(t v1.ComposedTemplate, i int, e interface{}) (v1.ComposedTemplate, error) {
+	out := *t.DeepCopy()
+	out.ForEach = nil
+	out.Patches = nil
+
+	cd := composed.New()
+	if err := json.Unmarshal(t.Base.Raw, cd); err != nil {
+		return v1.ComposedTemplate{}, errors.Wrap(err, errUnmarshal)
+	}
+	from := &kunstructured.Unstructured{Object: map[string]interface{}{
+		forEachElement: runtime.DeepCopyJSONValue(e),
+		forEachIndex:   int64(i),
+	}}
+
+	for pi, p := range t.Patches {
+		if p.Type != v1.PatchTypeFromForEach {
+			out.Patches = append(out.Patches, p)
+			continue
+		}
+		if err := p.ApplyFromForEach(from, cd); err != nil {
+			return v1.ComposedTemplate{}, errors.Wrapf(err, errFmtForEachPatch, pi, i)
+		}
+	}
+
+	raw, err := json.Marshal(cd)
+	if err != nil {
+		return v1.ComposedTemplate{}, errors.Wrap(err, errMarshalBase)
+	}
+	out.Base = runtime.RawExtension{Raw: raw}
+	return out, nil
+}
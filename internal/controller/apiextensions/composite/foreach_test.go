@@ -0,0 +1,235 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestRejectAnonymousForEachTemplates(t *testing.T) {
+	name := "cool"
+	fe := &v1.ComposedTemplateForEach{FromFieldPath: "spec.zones"}
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"NamedForEachTemplate": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &name, ForEach: fe}}}},
+			want: nil,
+		},
+		"AnonymousForEachTemplate": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{ForEach: fe}}}},
+			want: errors.Errorf(errFmtAnonymousForEach, 0),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectAnonymousForEachTemplates(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectAnonymousForEachTemplates(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectReservedTemplateNames(t *testing.T) {
+	name := "cool"
+	reserved := ForEachTemplateName(name, "a")
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"ValidName": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &name}}}},
+			want: nil,
+		},
+		"ReservedName": {
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &name}, {Name: &reserved}}}},
+			want: errors.Errorf(errFmtReservedName, reserved, "["),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectReservedTemplateNames(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectReservedTemplateNames(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExpandForEach(t *testing.T) {
+	name := "subnet"
+	base := runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"Subnet"}`)}
+	xr := func(zones interface{}) resource.Composite {
+		cr := composite.New()
+		if zones != nil {
+			_ = fieldpath.Pave(cr.Object).SetValue("spec.zones", zones)
+		}
+		return cr
+	}
+	tmpl := func(key *string, p ...v1.Patch) v1.ComposedTemplate {
+		return v1.ComposedTemplate{
+			Name:    &name,
+			Base:    base,
+			Patches: p,
+			ForEach: &v1.ComposedTemplateForEach{FromFieldPath: "spec.zones", KeyFieldPath: key},
+		}
+	}
+	expanded := func(key, raw string, p ...v1.Patch) v1.ComposedTemplate {
+		n := ForEachTemplateName(name, key)
+		return v1.ComposedTemplate{Name: &n, Base: runtime.RawExtension{Raw: []byte(raw)}, Patches: p}
+	}
+	fromElement := v1.Patch{
+		Type:          v1.PatchTypeFromForEach,
+		FromFieldPath: pointer.StringPtr("element"),
+		ToFieldPath:   pointer.StringPtr("spec.zone"),
+	}
+	fromIndex := v1.Patch{
+		Type:          v1.PatchTypeFromForEach,
+		FromFieldPath: pointer.StringPtr("index"),
+		ToFieldPath:   pointer.StringPtr("spec.index"),
+	}
+	fromXR := v1.Patch{FromFieldPath: pointer.StringPtr("spec.region")}
+
+	type args struct {
+		cp resource.Composite
+		t  v1.ComposedTemplate
+	}
+	type want struct {
+		t   []v1.ComposedTemplate
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoForEach": {
+			reason: "Templates that don't specify forEach should be returned as is.",
+			args: args{
+				cp: xr(nil),
+				t:  v1.ComposedTemplate{Name: &name, Base: base},
+			},
+			want: want{
+				t: []v1.ComposedTemplate{{Name: &name, Base: base}},
+			},
+		},
+		"FieldNotFound": {
+			reason: "Templates should not be expanded if the forEach field does not exist.",
+			args: args{
+				cp: xr(nil),
+				t:  tmpl(nil),
+			},
+			want: want{
+				t: nil,
+			},
+		},
+		"NotAnArray": {
+			reason: "An error should be returned if the forEach field is not an array.",
+			args: args{
+				cp: xr("a"),
+				t:  tmpl(nil),
+			},
+			want: want{
+				err: errors.New(errForEachNotArray),
+			},
+		},
+		"DuplicateKey": {
+			reason: "An error should be returned if two elements have the same key.",
+			args: args{
+				cp: xr([]interface{}{"a", "a"}),
+				t:  tmpl(nil),
+			},
+			want: want{
+				err: errors.Errorf(errFmtForEachDuplicateKey, 0, 1, "a"),
+			},
+		},
+		"ScalarElements": {
+			reason: "Scalar elements should be their own key, and should be patched into the expanded template's base.",
+			args: args{
+				cp: xr([]interface{}{"a", "b"}),
+				t:  tmpl(nil, fromElement, fromXR, fromIndex),
+			},
+			want: want{
+				t: []v1.ComposedTemplate{
+					expanded("a", `{"apiVersion":"v","kind":"Subnet","spec":{"index":0,"zone":"a"}}`, fromXR),
+					expanded("b", `{"apiVersion":"v","kind":"Subnet","spec":{"index":1,"zone":"b"}}`, fromXR),
+				},
+			},
+		},
+		"ObjectElementsWithKey": {
+			reason: "Object elements should be identified by the value at their key field path.",
+			args: args{
+				cp: xr([]interface{}{
+					map[string]interface{}{"name": "a", "cidr": "10.0.0.0/24"},
+				}),
+				t: tmpl(pointer.StringPtr("name"), v1.Patch{
+					Type:          v1.PatchTypeFromForEach,
+					FromFieldPath: pointer.StringPtr("element.cidr"),
+					ToFieldPath:   pointer.StringPtr("spec.cidr"),
+				}),
+			},
+			want: want{
+				t: []v1.ComposedTemplate{
+					expanded("a", `{"apiVersion":"v","kind":"Subnet","spec":{"cidr":"10.0.0.0/24"}}`),
+				},
+			},
+		},
+		"ObjectElementsWithoutKey": {
+			reason: "Object elements should be identified by their index if no key field path is specified.",
+			args: args{
+				cp: xr([]interface{}{map[string]interface{}{"name": "a"}}),
+				t:  tmpl(nil),
+			},
+			want: want{
+				t: []v1.ComposedTemplate{
+					expanded("0", `{"apiVersion":"v","kind":"Subnet"}`),
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ExpandForEach(tc.args.cp, tc.args.t)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nExpandForEach(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.t, got); diff != "" {
+				t.Errorf("\n%s\nExpandForEach(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
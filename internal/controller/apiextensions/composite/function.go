@@ -0,0 +1,393 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Error strings.
+const (
+	errMarshalFunctionIO   = "cannot marshal FunctionIO document"
+	errUnmarshalFunctionIO = "cannot unmarshal FunctionIO document"
+	errMarshalDesired      = "cannot marshal desired resource"
+	errUnmarshalStatus     = "cannot unmarshal desired composite resource status"
+	errExecFunction        = "cannot run executable"
+	errFunctionsDisabled   = "composition functions are an alpha feature that is not enabled"
+
+	errFmtAnonymousFunctionTemplate = "resource template at index %d must be named to use functions"
+	errFmtDuplicateFunction         = "function name %q is not unique within its Composition"
+	errFmtFunctionConfig            = "function %q of type %q must specify its configuration"
+	errFmtFunctionType              = "function type %q is not supported"
+	errFmtExecNoDir                 = "function %q cannot run %q: no directory of executables is configured for Exec functions"
+	errFmtExecNotAllowed            = "function %q cannot run %q: Exec functions may only run executables within %q"
+	errFmtRunFunction               = "cannot run function %q"
+	errFmtExecFunction              = "cannot run executable: %s"
+	errFmtUnmarshalDesired          = "cannot unmarshal desired resource %q"
+	errFmtFunctionUnknownResource   = "functions returned unknown desired resource %q"
+	errFmtFunctionMissingResource   = "functions did not return desired resource %q"
+)
+
+// FunctionIO document type metadata.
+const (
+	FunctionIOAPIVersion = "apiextensions.crossplane.io/v1alpha1"
+	FunctionIOKind       = "FunctionIO"
+)
+
+// The default time an Exec function may run before it is killed.
+const defaultExecFunctionTimeout = 20 * time.Second
+
+// A FunctionIO document is supplied to each composition function, which must
+// return an updated FunctionIO document.
+type FunctionIO struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// Observed state of the composite resource and its composed resources.
+	// Changes made by functions to the observed state are ignored.
+	Observed FunctionState `json:"observed"`
+
+	// Desired state of the composite resource and its composed resources.
+	// Functions may change the status of the desired composite resource, and
+	// anything but the identity of its desired composed resources.
+	Desired FunctionState `json:"desired"`
+}
+
+// FunctionState is the state of a composite resource and its composed
+// resources.
+type FunctionState struct {
+	// Composite resource.
+	Composite runtime.RawExtension `json:"composite"`
+
+	// Resources composed by the composite resource.
+	Resources []FunctionResource `json:"resources,omitempty"`
+}
+
+// A FunctionResource is a composed resource, identified by the name of the
+// resource template it was rendered from.
+type FunctionResource struct {
+	Name     string               `json:"name"`
+	Resource runtime.RawExtension `json:"resource"`
+}
+
+// FunctionOptions configure whether and how composition functions are run.
+type FunctionOptions struct {
+	// Enabled specifies that Compositions may use composition functions,
+	// which are an alpha feature. Compositions that specify functions are
+	// rejected unless functions are enabled.
+	Enabled bool
+
+	// ExecDir is the directory containing the executables Exec functions may
+	// run. Exec functions run with the privileges of Crossplane, so they may
+	// not run any executable outside this directory. No Exec functions may be
+	// run if it is empty.
+	ExecDir string
+}
+
+// validate returns an error if the supplied function may not be run.
+func (o FunctionOptions) validate(fn v1.Function) error {
+	if !o.Enabled {
+		return errors.New(errFunctionsDisabled)
+	}
+	switch fn.Type {
+	case v1.FunctionTypeExec:
+		if fn.Exec == nil || fn.Exec.Command == "" {
+			return errors.Errorf(errFmtFunctionConfig, fn.Name, fn.Type)
+		}
+		if o.ExecDir == "" {
+			return errors.Errorf(errFmtExecNoDir, fn.Name, fn.Exec.Command)
+		}
+		if !withinDir(o.ExecDir, fn.Exec.Command) {
+			return errors.Errorf(errFmtExecNotAllowed, fn.Name, fn.Exec.Command, o.ExecDir)
+		}
+	default:
+		return errors.Errorf(errFmtFunctionType, fn.Type)
+	}
+	return nil
+}
+
+// withinDir returns true if the supplied path is an absolute path within the
+// supplied directory. Symlinks are resolved before the path is checked, so a
+// symlink within the directory may not point outside it.
+func withinDir(dir, path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	dir, err := evalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	path, err = evalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// evalSymlinks returns the supplied path with any symlinks resolved. A path
+// that doesn't exist can't be a symlink, so it's returned cleaned instead.
+// Exec functions are validated again immediately before they're run.
+func evalSymlinks(path string) (string, error) {
+	p, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) {
+		return filepath.Clean(path), nil
+	}
+	return p, err
+}
+
+// RejectInvalidFunctions returns a CompositionValidator that validates the
+// functions of a Composition. Function names must be unique and functions must
+// be enabled and configured for their type. Exec functions may only run
+// executables within the configured directory. Compositions that specify
+// functions must name all of their resource templates, because functions
+// identify composed resources by name.
+func RejectInvalidFunctions(o FunctionOptions) CompositionValidatorFn {
+	return func(comp *v1.Composition) error {
+		if len(comp.Spec.Functions) == 0 {
+			return nil
+		}
+		if !o.Enabled {
+			return errors.New(errFunctionsDisabled)
+		}
+		for i, tmpl := range comp.Spec.Resources {
+			if tmpl.Name == nil {
+				return errors.Errorf(errFmtAnonymousFunctionTemplate, i)
+			}
+		}
+		seen := map[string]bool{}
+		for _, fn := range comp.Spec.Functions {
+			if seen[fn.Name] {
+				return errors.Errorf(errFmtDuplicateFunction, fn.Name)
+			}
+			seen[fn.Name] = true
+
+			if err := o.validate(fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// An ExecFunctionRunner runs a pipeline of composition functions by executing
+// them as local processes.
+type ExecFunctionRunner struct {
+	client  client.Reader
+	options FunctionOptions
+}
+
+// NewExecFunctionRunner returns a FunctionRunner that runs composition
+// functions as local processes, supplying them the observed state of composed
+// resources read from the API server. Only functions permitted by the supplied
+// options are run.
+func NewExecFunctionRunner(c client.Reader, o FunctionOptions) *ExecFunctionRunner {
+	return &ExecFunctionRunner{client: c, options: o}
+}
+
+// RunFunctions runs the supplied functions in order. Each function is supplied
+// the desired state returned by the function before it. The desired composed
+// resources, keyed by resource template name, are updated only once all
+// functions have run successfully.
+func (r *ExecFunctionRunner) RunFunctions(ctx context.Context, cp resource.Composite, fns []v1.Function, desired map[string]resource.Composed) error {
+	if len(fns) == 0 {
+		return nil
+	}
+
+	in, err := r.functionIO(ctx, cp, desired)
+	if err != nil {
+		return err
+	}
+
+	for _, fn := range fns {
+		out, err := r.runFunction(ctx, fn, in)
+		if err != nil {
+			return errors.Wrapf(err, errFmtRunFunction, fn.Name)
+		}
+		out.Observed = in.Observed
+		in = out
+	}
+
+	return updateDesired(cp, in.Desired, desired)
+}
+
+func (r *ExecFunctionRunner) functionIO(ctx context.Context, cp resource.Composite, desired map[string]resource.Composed) (*FunctionIO, error) {
+	xr, err := json.Marshal(cp)
+	if err != nil {
+		return nil, errors.Wrap(err, errMarshalFunctionIO)
+	}
+
+	fio := &FunctionIO{
+		APIVersion: FunctionIOAPIVersion,
+		Kind:       FunctionIOKind,
+		Observed:   FunctionState{Composite: runtime.RawExtension{Raw: xr}},
+		Desired:    FunctionState{Composite: runtime.RawExtension{Raw: xr}},
+	}
+
+	// Resources are supplied in a stable order so that functions (and their
+	// authors) may rely on it.
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cd := desired[name]
+		d, err := json.Marshal(cd)
+		if err != nil {
+			return nil, errors.Wrap(err, errMarshalDesired)
+		}
+		fio.Desired.Resources = append(fio.Desired.Resources, FunctionResource{Name: name, Resource: runtime.RawExtension{Raw: d}})
+
+		// The desired resource may have been named by a dry-run create, so a
+		// name doesn't guarantee that the resource exists.
+		if cd.GetName() == "" {
+			continue
+		}
+		ob := composed.New()
+		ob.SetGroupVersionKind(cd.GetObjectKind().GroupVersionKind())
+		err = r.client.Get(ctx, types.NamespacedName{Namespace: cd.GetNamespace(), Name: cd.GetName()}, ob)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errGetComposed)
+		}
+		o, err := json.Marshal(ob)
+		if err != nil {
+			return nil, errors.Wrap(err, errMarshalFunctionIO)
+		}
+		fio.Observed.Resources = append(fio.Observed.Resources, FunctionResource{Name: name, Resource: runtime.RawExtension{Raw: o}})
+	}
+
+	return fio, nil
+}
+
+func (r *ExecFunctionRunner) runFunction(ctx context.Context, fn v1.Function, in *FunctionIO) (*FunctionIO, error) {
+	// Compositions are validated before they are used, but we check again
+	// immediately before running an executable to be safe.
+	if err := r.options.validate(fn); err != nil {
+		return nil, err
+	}
+
+	timeout := defaultExecFunctionTimeout
+	if fn.Exec.Timeout != nil {
+		timeout = fn.Exec.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, errors.Wrap(err, errMarshalFunctionIO)
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, fn.Exec.Command, fn.Exec.Args...) // nolint:gosec // The executable is within the configured directory.
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stderr = stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.Wrapf(err, errFmtExecFunction, msg)
+		}
+		return nil, errors.Wrap(err, errExecFunction)
+	}
+
+	// Functions may return either a YAML or a JSON document.
+	out := &FunctionIO{}
+	return out, errors.Wrap(yaml.Unmarshal(stdout, out), errUnmarshalFunctionIO)
+}
+
+// updateDesired updates the supplied composite resource's status and its
+// desired composed resources using the supplied desired state. All desired
+// composed resources must be returned, and none may be added. The identity of
+// each composed resource - its kind, name, namespace, owner references, and
+// annotations identifying its resource template - is preserved.
+func updateDesired(cp resource.Composite, s FunctionState, desired map[string]resource.Composed) error {
+	updated := make(map[string]*composed.Unstructured, len(s.Resources))
+	for _, res := range s.Resources {
+		cd, ok := desired[res.Name]
+		if !ok {
+			return errors.Errorf(errFmtFunctionUnknownResource, res.Name)
+		}
+		u := composed.New()
+		if err := json.Unmarshal(res.Resource.Raw, u); err != nil {
+			return errors.Wrapf(err, errFmtUnmarshalDesired, res.Name)
+		}
+		if u.GetObjectKind().GroupVersionKind() != cd.GetObjectKind().GroupVersionKind() {
+			return errors.Wrapf(errors.New(errKindChanged), errFmtUnmarshalDesired, res.Name)
+		}
+		u.SetName(cd.GetName())
+		u.SetNamespace(cd.GetNamespace())
+		u.SetGenerateName(cd.GetGenerateName())
+		u.SetOwnerReferences(cd.GetOwnerReferences())
+		SetCompositionResourceName(u, GetCompositionResourceName(cd))
+		updated[res.Name] = u
+	}
+
+	for name := range desired {
+		if _, ok := updated[name]; !ok {
+			return errors.Errorf(errFmtFunctionMissingResource, name)
+		}
+	}
+
+	xr := &struct {
+		Status map[string]interface{} `json:"status,omitempty"`
+	}{}
+	if err := json.Unmarshal(s.Composite.Raw, xr); err != nil {
+		return errors.Wrap(err, errUnmarshalStatus)
+	}
+	if u, ok := cp.(interface{ UnstructuredContent() map[string]interface{} }); ok && xr.Status != nil {
+		u.UnstructuredContent()["status"] = xr.Status
+	}
+
+	for name, u := range updated {
+		b, err := json.Marshal(u)
+		if err != nil {
+			return errors.Wrap(err, errMarshalDesired)
+		}
+		if err := json.Unmarshal(b, desired[name]); err != nil {
+			return errors.Wrapf(err, errFmtUnmarshalDesired, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,479 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestRejectInvalidFunctions(t *testing.T) {
+	name := "cool"
+	exec := &v1.ExecFunction{Command: "/functions/fn"}
+	enabled := FunctionOptions{Enabled: true, ExecDir: "/functions"}
+
+	cases := map[string]struct {
+		reason string
+		o      FunctionOptions
+		comp   *v1.Composition
+		want   error
+	}{
+		"NoFunctions": {
+			reason: "Compositions without functions may use anonymous templates.",
+			comp:   &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{}}}},
+			want:   nil,
+		},
+		"AnonymousTemplate": {
+			reason: "Compositions with functions must name their templates.",
+			o:      enabled,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{}},
+				Functions: []v1.Function{{Name: "fn", Type: v1.FunctionTypeExec, Exec: exec}},
+			}},
+			want: errors.Errorf(errFmtAnonymousFunctionTemplate, 0),
+		},
+		"DuplicateFunction": {
+			reason: "Function names must be unique.",
+			o:      enabled,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Name: &name}},
+				Functions: []v1.Function{
+					{Name: "fn", Type: v1.FunctionTypeExec, Exec: exec},
+					{Name: "fn", Type: v1.FunctionTypeExec, Exec: exec},
+				},
+			}},
+			want: errors.Errorf(errFmtDuplicateFunction, "fn"),
+		},
+		"MissingConfig": {
+			reason: "Functions must be configured for their type.",
+			o:      enabled,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Name: &name}},
+				Functions: []v1.Function{{Name: "fn", Type: v1.FunctionTypeExec}},
+			}},
+			want: errors.Errorf(errFmtFunctionConfig, "fn", v1.FunctionTypeExec),
+		},
+		"UnknownType": {
+			reason: "Functions must be of a supported type.",
+			o:      enabled,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Name: &name}},
+				Functions: []v1.Function{{Name: "fn", Type: "Wat"}},
+			}},
+			want: errors.Errorf(errFmtFunctionType, "Wat"),
+		},
+		"Disabled": {
+			reason: "Compositions may not use functions unless they are enabled.",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Name: &name}},
+				Functions: []v1.Function{{Name: "fn", Type: v1.FunctionTypeExec, Exec: exec}},
+			}},
+			want: errors.New(errFunctionsDisabled),
+		},
+		"NoExecDir": {
+			reason: "Exec functions may not run any executable unless a directory of executables is configured.",
+			o:      FunctionOptions{Enabled: true},
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Name: &name}},
+				Functions: []v1.Function{{Name: "fn", Type: v1.FunctionTypeExec, Exec: exec}},
+			}},
+			want: errors.Errorf(errFmtExecNoDir, "fn", "/functions/fn"),
+		},
+		"ExecOutsideDir": {
+			reason: "Exec functions may not run executables outside the configured directory.",
+			o:      enabled,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Name: &name}},
+				Functions: []v1.Function{{Name: "fn", Type: v1.FunctionTypeExec, Exec: &v1.ExecFunction{Command: "/functions/../bin/sh"}}},
+			}},
+			want: errors.Errorf(errFmtExecNotAllowed, "fn", "/functions/../bin/sh", "/functions"),
+		},
+		"ExecRelativePath": {
+			reason: "Exec functions may not run executables specified by a relative path.",
+			o:      enabled,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Name: &name}},
+				Functions: []v1.Function{{Name: "fn", Type: v1.FunctionTypeExec, Exec: &v1.ExecFunction{Command: "fn"}}},
+			}},
+			want: errors.Errorf(errFmtExecNotAllowed, "fn", "fn", "/functions"),
+		},
+		"Valid": {
+			reason: "Valid functions should be accepted.",
+			o:      enabled,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Resources: []v1.ComposedTemplate{{Name: &name}},
+				Functions: []v1.Function{{Name: "fn", Type: v1.FunctionTypeExec, Exec: exec}},
+			}},
+			want: nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectInvalidFunctions(tc.o)(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRejectInvalidFunctions(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWithinDir(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "functions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp) // nolint:errcheck // Best effort cleanup.
+
+	// Resolve the temporary directory, which may itself be a symlink.
+	tmp, err = filepath.EvalSymlinks(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(tmp, "functions")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{filepath.Join(dir, "fn"), filepath.Join(tmp, "sh")} {
+		if err := ioutil.WriteFile(f, nil, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(tmp, "sh"), filepath.Join(dir, "outside")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "fn"), filepath.Join(dir, "inside")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(tmp, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		reason string
+		dir    string
+		path   string
+		want   bool
+	}{
+		"File": {
+			reason: "An executable within the directory should be allowed.",
+			dir:    dir,
+			path:   filepath.Join(dir, "fn"),
+			want:   true,
+		},
+		"Missing": {
+			reason: "A path within the directory that doesn't exist should be checked lexically.",
+			dir:    dir,
+			path:   filepath.Join(dir, "missing"),
+			want:   true,
+		},
+		"Parent": {
+			reason: "A path that traverses out of the directory should not be allowed.",
+			dir:    dir,
+			path:   filepath.Join(dir, "..", "sh"),
+			want:   false,
+		},
+		"SymlinkInside": {
+			reason: "A symlink within the directory that points within it should be allowed.",
+			dir:    dir,
+			path:   filepath.Join(dir, "inside"),
+			want:   true,
+		},
+		"SymlinkOutside": {
+			reason: "A symlink within the directory that points outside it should not be allowed.",
+			dir:    dir,
+			path:   filepath.Join(dir, "outside"),
+			want:   false,
+		},
+		"SymlinkDir": {
+			reason: "The directory itself may be a symlink.",
+			dir:    filepath.Join(tmp, "link"),
+			path:   filepath.Join(tmp, "link", "fn"),
+			want:   true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := withinDir(tc.dir, tc.path)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nwithinDir(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// TestHelperFunction is not a real test. It is run as a composition function
+// by TestExecFunctionRunner, which passes the desired behaviour after "--".
+func TestHelperFunction(t *testing.T) {
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		return
+	}
+
+	fio := &FunctionIO{}
+	if err := json.NewDecoder(os.Stdin).Decode(fio); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch args[1] {
+	case "fail":
+		fmt.Fprintln(os.Stderr, "boom")
+		os.Exit(1)
+	case "garbage":
+		fmt.Print("}{")
+		os.Exit(0)
+	case "add":
+		fio.Desired.Resources = append(fio.Desired.Resources, FunctionResource{Name: "new", Resource: runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"k"}`)}})
+	case "label":
+		for i, r := range fio.Desired.Resources {
+			cd := composed.New()
+			_ = json.Unmarshal(r.Resource.Raw, cd)
+			cd.SetLabels(map[string]string{"fn": "cool"})
+			cd.SetName("evil")
+			fio.Desired.Resources[i].Resource.Raw, _ = json.Marshal(cd)
+		}
+		xr := composite.New()
+		_ = json.Unmarshal(fio.Desired.Composite.Raw, xr)
+		_ = fieldpath.Pave(xr.Object).SetValue("status.observed", fmt.Sprint(len(fio.Observed.Resources)))
+		fio.Desired.Composite.Raw, _ = json.Marshal(xr)
+	}
+
+	_ = json.NewEncoder(os.Stdout).Encode(fio)
+	os.Exit(0)
+}
+
+func TestExecFunctionRunner(t *testing.T) {
+	errBoom := errors.New("boom")
+	gvk := schema.GroupVersionKind{Group: "g", Version: "v", Kind: "k"}
+
+	// Our functions are run by executing this test binary.
+	enabled := FunctionOptions{Enabled: true, ExecDir: filepath.Dir(os.Args[0])}
+
+	fn := func(mode string) v1.Function {
+		return v1.Function{
+			Name: "cool-fn",
+			Type: v1.FunctionTypeExec,
+			Exec: &v1.ExecFunction{Command: os.Args[0], Args: []string{"-test.run=TestHelperFunction", "--", mode}},
+		}
+	}
+	xr := func(observed string) *composite.Unstructured {
+		cr := composite.New()
+		cr.SetName("cool-xr")
+		if observed != "" {
+			_ = fieldpath.Pave(cr.Object).SetValue("status.observed", observed)
+		}
+		return cr
+	}
+	cd := func(name string, labels map[string]string) *composed.Unstructured {
+		cd := composed.New()
+		cd.SetGroupVersionKind(gvk)
+		cd.SetName(name)
+		cd.SetGenerateName("cool-xr-")
+		SetCompositionResourceName(cd, "cool")
+		if labels != nil {
+			cd.SetLabels(labels)
+		}
+		return cd
+	}
+
+	type args struct {
+		kube    client.Reader
+		o       *FunctionOptions
+		cp      resource.Composite
+		fns     []v1.Function
+		desired map[string]resource.Composed
+	}
+	type want struct {
+		cp      resource.Composite
+		desired map[string]resource.Composed
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoFunctions": {
+			reason: "We should not change anything if there are no functions to run.",
+			args: args{
+				cp:      xr(""),
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+			},
+			want: want{
+				cp:      xr(""),
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+			},
+		},
+		"FunctionsDisabled": {
+			reason: "We should refuse to run functions if they are not enabled.",
+			args: args{
+				kube:    &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				o:       &FunctionOptions{},
+				cp:      xr(""),
+				fns:     []v1.Function{fn("label")},
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+			},
+			want: want{
+				cp:      xr(""),
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+				err:     errors.Wrapf(errors.New(errFunctionsDisabled), errFmtRunFunction, "cool-fn"),
+			},
+		},
+		"ExecNotAllowed": {
+			reason: "We should refuse to run executables outside the configured directory.",
+			args: args{
+				kube:    &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				o:       &FunctionOptions{Enabled: true, ExecDir: "/functions"},
+				cp:      xr(""),
+				fns:     []v1.Function{fn("label")},
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+			},
+			want: want{
+				cp:      xr(""),
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+				err:     errors.Wrapf(errors.Errorf(errFmtExecNotAllowed, "cool-fn", os.Args[0], "/functions"), errFmtRunFunction, "cool-fn"),
+			},
+		},
+		"GetObservedError": {
+			reason: "We should return any error encountered while getting an observed composed resource.",
+			args: args{
+				kube:    &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cp:      xr(""),
+				fns:     []v1.Function{fn("label")},
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+			},
+			want: want{
+				cp:      xr(""),
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+				err:     errors.Wrap(errBoom, errGetComposed),
+			},
+		},
+		"Success": {
+			reason: "We should update our desired resources and composite resource status, without allowing functions to rename composed resources.",
+			args: args{
+				kube: &test.MockClient{MockGet: func(_ context.Context, key client.ObjectKey, _ client.Object) error {
+					if key.Name == "cool-cd" {
+						return nil
+					}
+					return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+				}},
+				cp:  xr(""),
+				fns: []v1.Function{fn("label")},
+				desired: map[string]resource.Composed{
+					"cool":  cd("cool-cd", nil),
+					"other": cd("other-cd", nil),
+				},
+			},
+			want: want{
+				cp: xr("1"),
+				desired: map[string]resource.Composed{
+					"cool":  cd("cool-cd", map[string]string{"fn": "cool"}),
+					"other": cd("other-cd", map[string]string{"fn": "cool"}),
+				},
+			},
+		},
+		"FunctionError": {
+			reason: "We should return the stderr of functions that fail.",
+			args: args{
+				kube:    &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				cp:      xr(""),
+				fns:     []v1.Function{fn("label"), fn("fail")},
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+			},
+			want: want{
+				cp:      xr(""),
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+				err:     errors.Wrapf(errors.Wrapf(errors.New("exit status 1"), errFmtExecFunction, "boom"), errFmtRunFunction, "cool-fn"),
+			},
+		},
+		"InvalidOutput": {
+			reason: "We should return an error if a function does not return a FunctionIO document.",
+			args: args{
+				kube:    &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				cp:      xr(""),
+				fns:     []v1.Function{fn("garbage")},
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+			},
+			want: want{
+				cp:      xr(""),
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+				err:     errors.Wrapf(errors.Wrap(errors.New("error converting YAML to JSON: yaml: did not find expected node content"), errUnmarshalFunctionIO), errFmtRunFunction, "cool-fn"),
+			},
+		},
+		"UnknownResource": {
+			reason: "We should return an error if functions add a desired resource.",
+			args: args{
+				kube:    &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				cp:      xr(""),
+				fns:     []v1.Function{fn("add")},
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+			},
+			want: want{
+				cp:      xr(""),
+				desired: map[string]resource.Composed{"cool": cd("cool-cd", nil)},
+				err:     errors.Errorf(errFmtFunctionUnknownResource, "new"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := enabled
+			if tc.args.o != nil {
+				o = *tc.args.o
+			}
+			r := NewExecFunctionRunner(tc.args.kube, o)
+			err := r.RunFunctions(context.Background(), tc.args.cp, tc.args.fns, tc.args.desired)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRunFunctions(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cp, tc.args.cp); diff != "" {
+				t.Errorf("\n%s\nRunFunctions(...): -want composite, +got composite:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.desired, tc.args.desired); diff != "" {
+				t.Errorf("\n%s\nRunFunctions(...): -want desired, +got desired:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Error strings.
+const (
+	errGetObserved     = "cannot get observed resource"
+	errListObserved    = "cannot list observed resources"
+	errObserveSelector = "an observed resource template must render a name or labels"
+
+	errFmtObserveMatches = "observed resource template labels must match exactly one resource, but they match %d"
+)
+
+// IsObserved returns true if composed resources rendered from the supplied
+// template are observed, rather than managed, by Crossplane.
+func IsObserved(t v1.ComposedTemplate) bool {
+	return t.Mode != nil && *t.Mode == v1.ComposedTemplateModeObserve
+}
+
+// An ObservedResolver resolves the existing resource observed by an Observe
+// mode resource template.
+type ObservedResolver interface {
+	ResolveObserved(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error
+}
+
+// An ObservedResolverFn resolves the existing resource observed by an Observe
+// mode resource template.
+type ObservedResolverFn func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error
+
+// ResolveObserved resolves the existing resource observed by the supplied
+// template.
+func (fn ObservedResolverFn) ResolveObserved(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+	return fn(ctx, cp, cd, t)
+}
+
+// An APIObservedResolver resolves the existing resources observed by Observe
+// mode resource templates by reading them from an API server.
+type APIObservedResolver struct {
+	client client.Reader
+}
+
+// NewAPIObservedResolver returns an ObservedResolver that reads observed
+// resources from an API server.
+func NewAPIObservedResolver(c client.Reader) *APIObservedResolver {
+	return &APIObservedResolver{client: c}
+}
+
+// ResolveObserved renders the supplied template's base and its patches from
+// the supplied composite resource, then reads the existing resource they
+// identify into the supplied composed resource. The resource is identified by
+// its rendered name if it has one, or otherwise by its rendered labels, which
+// must match exactly one resource. The resource is identified using only the
+// supplied template; anything already in the supplied composed resource is
+// ignored.
+func (r *APIObservedResolver) ResolveObserved(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+	rendered := composed.New()
+	if err := json.Unmarshal(t.Base.Raw, rendered); err != nil {
+		return errors.Wrap(err, errUnmarshal)
+	}
+
+	onlyPatches := []v1.PatchType{v1.PatchTypeFromCompositeFieldPath, v1.PatchTypeCombineFromComposite}
+	for i, p := range t.Patches {
+		if err := p.Apply(cp, rendered, onlyPatches...); err != nil {
+			return errors.Wrapf(err, errFmtPatch, i)
+		}
+	}
+
+	if rendered.GetName() == "" {
+		if len(rendered.GetLabels()) == 0 {
+			return errors.New(errObserveSelector)
+		}
+
+		gvk := rendered.GetObjectKind().GroupVersionKind()
+		l := &kunstructured.UnstructuredList{}
+		l.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := r.client.List(ctx, l, client.InNamespace(rendered.GetNamespace()), client.MatchingLabels(rendered.GetLabels())); err != nil {
+			return errors.Wrap(err, errListObserved)
+		}
+		if len(l.Items) != 1 {
+			return errors.Errorf(errFmtObserveMatches, len(l.Items))
+		}
+		rendered.SetName(l.Items[0].GetName())
+		rendered.SetNamespace(l.Items[0].GetNamespace())
+	}
+
+	// Reading the resource replaces everything in the supplied composed
+	// resource with its observed state.
+	cd.GetObjectKind().SetGroupVersionKind(rendered.GetObjectKind().GroupVersionKind())
+	nn := types.NamespacedName{Namespace: rendered.GetNamespace(), Name: rendered.GetName()}
+	return errors.Wrap(r.client.Get(ctx, nn, cd), errGetObserved)
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestIsObserved(t *testing.T) {
+	apply := v1.ComposedTemplateModeApply
+	observe := v1.ComposedTemplateModeObserve
+
+	cases := map[string]struct {
+		reason string
+		t      v1.ComposedTemplate
+		want   bool
+	}{
+		"Default": {
+			reason: "Templates that don't specify a mode should not be observed.",
+			t:      v1.ComposedTemplate{},
+			want:   false,
+		},
+		"Apply": {
+			reason: "Apply mode templates should not be observed.",
+			t:      v1.ComposedTemplate{Mode: &apply},
+			want:   false,
+		},
+		"Observe": {
+			reason: "Observe mode templates should be observed.",
+			t:      v1.ComposedTemplate{Mode: &observe},
+			want:   true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := IsObserved(tc.t)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nIsObserved(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPIObservedResolver(t *testing.T) {
+	errBoom := errors.New("boom")
+	observe := v1.ComposedTemplateModeObserve
+
+	xr := composite.New()
+	_ = fieldpath.Pave(xr.Object).SetValue("spec.vpcName", "cool-vpc")
+
+	base := func(labels map[string]string) runtime.RawExtension {
+		u := composed.New()
+		u.SetAPIVersion("example.org/v1")
+		u.SetKind("VPC")
+		u.SetLabels(labels)
+		j, _ := json.Marshal(u)
+		return runtime.RawExtension{Raw: j}
+	}
+	nameFromXR := v1.Patch{
+		Type:          v1.PatchTypeFromCompositeFieldPath,
+		FromFieldPath: pointer.StringPtr("spec.vpcName"),
+		ToFieldPath:   pointer.StringPtr("metadata.name"),
+	}
+
+	// observed is the existing resource our templates should resolve.
+	observed := func() *composed.Unstructured {
+		u := composed.New()
+		u.SetAPIVersion("example.org/v1")
+		u.SetKind("VPC")
+		u.SetName("cool-vpc")
+		u.SetLabels(map[string]string{"cool": "true"})
+		_ = fieldpath.Pave(u.Object).SetValue("status.atProvider.id", "vpc-42")
+		return u
+	}
+	get := func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+		if key.Name != "cool-vpc" {
+			return errBoom
+		}
+		obj.(*composed.Unstructured).SetUnstructuredContent(observed().UnstructuredContent())
+		return nil
+	}
+
+	type args struct {
+		client client.Reader
+		cd     *composed.Unstructured
+		t      v1.ComposedTemplate
+	}
+	type want struct {
+		cd  resource.Composed
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"InvalidBase": {
+			reason: "We should return an error if we cannot unmarshal the template's base.",
+			args: args{
+				t: v1.ComposedTemplate{Mode: &observe, Base: runtime.RawExtension{Raw: []byte("olala")}},
+			},
+			want: want{
+				err: errors.Wrap(errors.New("invalid character 'o' looking for beginning of value"), errUnmarshal),
+			},
+		},
+		"NoNameOrLabels": {
+			reason: "We should return an error if the template renders neither a name nor labels.",
+			args: args{
+				t: v1.ComposedTemplate{Mode: &observe, Base: base(nil)},
+			},
+			want: want{
+				err: errors.New(errObserveSelector),
+			},
+		},
+		"GetError": {
+			reason: "We should return any error encountered getting the observed resource.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				t:      v1.ComposedTemplate{Mode: &observe, Base: base(nil), Patches: []v1.Patch{nameFromXR}},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGetObserved),
+			},
+		},
+		"ByName": {
+			reason: "We should read the observed resource by the name the template renders.",
+			args: args{
+				client: &test.MockClient{MockGet: get},
+				t:      v1.ComposedTemplate{Mode: &observe, Base: base(nil), Patches: []v1.Patch{nameFromXR}},
+			},
+			want: want{
+				cd: observed(),
+			},
+		},
+		"ListError": {
+			reason: "We should return any error encountered listing resources by label.",
+			args: args{
+				client: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				t:      v1.ComposedTemplate{Mode: &observe, Base: base(map[string]string{"cool": "true"})},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errListObserved),
+			},
+		},
+		"AmbiguousLabels": {
+			reason: "We should return an error if the template's labels match more than one resource.",
+			args: args{
+				client: &test.MockClient{MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+					obj.(*kunstructured.UnstructuredList).Items = []kunstructured.Unstructured{{}, {}}
+					return nil
+				}},
+				t: v1.ComposedTemplate{Mode: &observe, Base: base(map[string]string{"cool": "true"})},
+			},
+			want: want{
+				err: errors.Errorf(errFmtObserveMatches, 2),
+			},
+		},
+		"ByLabels": {
+			reason: "We should read the one observed resource matching the labels the template renders.",
+			args: args{
+				client: &test.MockClient{
+					MockList: func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+						lo := &client.ListOptions{}
+						lo.ApplyOptions(opts)
+						if diff := cmp.Diff("cool=true", lo.LabelSelector.String()); diff != "" {
+							t.Errorf("List(...): -want selector, +got selector:\n%s", diff)
+						}
+						obj.(*kunstructured.UnstructuredList).Items = []kunstructured.Unstructured{*observed().GetUnstructured()}
+						return nil
+					},
+					MockGet: get,
+				},
+				t: v1.ComposedTemplate{Mode: &observe, Base: base(map[string]string{"cool": "true"})},
+			},
+			want: want{
+				cd: observed(),
+			},
+		},
+		"IgnoreExistingIdentity": {
+			reason: "We should identify the observed resource using only the template, not the supplied composed resource.",
+			args: args{
+				client: &test.MockClient{
+					MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+						obj.(*kunstructured.UnstructuredList).Items = []kunstructured.Unstructured{*observed().GetUnstructured()}
+						return nil
+					},
+					MockGet: get,
+				},
+				cd: func() *composed.Unstructured {
+					// The composed resource this template managed before it
+					// switched to Observe mode.
+					u := composed.New()
+					u.SetAPIVersion("example.org/v1")
+					u.SetKind("VPC")
+					u.SetName("managed-vpc")
+					return u
+				}(),
+				t: v1.ComposedTemplate{Mode: &observe, Base: base(map[string]string{"cool": "true"})},
+			},
+			want: want{
+				cd: observed(),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cd := tc.args.cd
+			if cd == nil {
+				cd = composed.New()
+			}
+			r := NewAPIObservedResolver(tc.args.client)
+			err := r.ResolveObserved(context.Background(), xr, cd, tc.args.t)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.ResolveObserved(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if tc.want.cd == nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.cd, cd); diff != "" {
+				t.Errorf("\n%s\nr.ResolveObserved(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
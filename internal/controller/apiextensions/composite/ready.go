@@ -0,0 +1,163 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// A readinessEvaluator reports whether a composed resource of a well-known
+// kind is ready.
+type readinessEvaluator func(cd resource.Composed, p *fieldpath.Paved) bool
+
+// Readiness evaluators used for well-known kinds that don't report the
+// Crossplane Ready condition, when their template specifies no readiness
+// checks. Kinds that have no meaningful status, like ConfigMaps, are always
+// ready.
+var readinessEvaluators = map[schema.GroupKind]readinessEvaluator{
+	{Group: "", Kind: "ConfigMap"}:                                    always,
+	{Group: "", Kind: "Secret"}:                                       always,
+	{Group: "", Kind: "ServiceAccount"}:                               always,
+	{Group: "", Kind: "Namespace"}:                                    phaseIn("Active"),
+	{Group: "", Kind: "PersistentVolume"}:                             phaseIn("Available", "Bound"),
+	{Group: "", Kind: "PersistentVolumeClaim"}:                        phaseIn("Bound"),
+	{Group: "", Kind: "Pod"}:                                          podReady,
+	{Group: "", Kind: "Service"}:                                      serviceReady,
+	{Group: "apps", Kind: "Deployment"}:                               replicasReady("status.availableReplicas"),
+	{Group: "apps", Kind: "ReplicaSet"}:                               replicasReady("status.availableReplicas"),
+	{Group: "apps", Kind: "StatefulSet"}:                              replicasReady("status.readyReplicas"),
+	{Group: "apps", Kind: "DaemonSet"}:                                daemonSetReady,
+	{Group: "batch", Kind: "Job"}:                                     conditionTrue("Complete"),
+	{Group: "rbac.authorization.k8s.io", Kind: "Role"}:                always,
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:         always,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:         always,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:  always,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: conditionTrue("Established"),
+}
+
+// defaultReadiness returns whether the supplied composed resource is ready
+// when its template specifies no readiness checks. Well-known kinds are
+// evaluated according to their status. All other kinds are ready when their
+// Ready condition is True.
+func defaultReadiness(cd resource.Composed, p *fieldpath.Paved) bool {
+	if fn, ok := readinessEvaluators[cd.GetObjectKind().GroupVersionKind().GroupKind()]; ok && p != nil {
+		return fn(cd, p)
+	}
+	return resource.IsConditionTrue(cd.GetCondition(xpv1.TypeReady))
+}
+
+func always(_ resource.Composed, _ *fieldpath.Paved) bool {
+	return true
+}
+
+// phaseIn returns an evaluator that is ready when status.phase is one of the
+// supplied phases.
+func phaseIn(phases ...string) readinessEvaluator {
+	return func(_ resource.Composed, p *fieldpath.Paved) bool {
+		phase, err := p.GetString("status.phase")
+		if err != nil {
+			return false
+		}
+		for _, want := range phases {
+			if phase == want {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// conditionTrue returns an evaluator that is ready when the supplied condition
+// is True.
+func conditionTrue(ct xpv1.ConditionType) readinessEvaluator {
+	return func(cd resource.Composed, _ *fieldpath.Paved) bool {
+		return cd.GetCondition(ct).Status == corev1.ConditionTrue
+	}
+}
+
+// observedCurrent returns true if the controller of the resource has observed
+// its latest generation. Resources that don't report an observed generation
+// are assumed to be current.
+func observedCurrent(cd resource.Composed, p *fieldpath.Paved) bool {
+	og, err := p.GetInteger("status.observedGeneration")
+	if err != nil {
+		return true
+	}
+	return og >= cd.GetGeneration()
+}
+
+// replicasReady returns an evaluator that is ready when the integer at the
+// supplied field path is at least the desired number of replicas, which
+// defaults to one.
+func replicasReady(path string) readinessEvaluator {
+	return func(cd resource.Composed, p *fieldpath.Paved) bool {
+		if !observedCurrent(cd, p) {
+			return false
+		}
+		want, err := p.GetInteger("spec.replicas")
+		if err != nil {
+			want = 1
+		}
+		got, err := p.GetInteger(path)
+		if err != nil {
+			// Controllers omit zero valued replica counts.
+			got = 0
+		}
+		return got >= want
+	}
+}
+
+func daemonSetReady(cd resource.Composed, p *fieldpath.Paved) bool {
+	if !observedCurrent(cd, p) {
+		return false
+	}
+	want, err := p.GetInteger("status.desiredNumberScheduled")
+	if err != nil {
+		return false
+	}
+	got, err := p.GetInteger("status.numberAvailable")
+	if err != nil {
+		got = 0
+	}
+	return got >= want
+}
+
+func podReady(cd resource.Composed, p *fieldpath.Paved) bool {
+	if phase, err := p.GetString("status.phase"); err == nil && phase == string(corev1.PodSucceeded) {
+		return true
+	}
+	return cd.GetCondition(xpv1.ConditionType(corev1.PodReady)).Status == corev1.ConditionTrue
+}
+
+// serviceReady returns true unless the Service is a LoadBalancer that has not
+// yet been assigned an ingress point.
+func serviceReady(_ resource.Composed, p *fieldpath.Paved) bool {
+	if t, _ := p.GetString("spec.type"); t != string(corev1.ServiceTypeLoadBalancer) {
+		return true
+	}
+	ingress, err := p.GetValue("status.loadBalancer.ingress")
+	if err != nil {
+		return false
+	}
+	s, ok := ingress.([]interface{})
+	return ok && len(s) > 0
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+)
+
+func TestDefaultReadiness(t *testing.T) {
+	obj := func(apiVersion, kind string, fields map[string]interface{}) *composed.Unstructured {
+		cd := composed.New()
+		cd.Object = fields
+		cd.SetAPIVersion(apiVersion)
+		cd.SetKind(kind)
+		return cd
+	}
+	cond := func(ct, status string) map[string]interface{} {
+		return map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": ct, "status": status}},
+		}
+	}
+
+	cases := map[string]struct {
+		reason string
+		cd     *composed.Unstructured
+		want   bool
+	}{
+		"UnknownKindReady": {
+			reason: "Kinds that aren't well-known should be ready when their Ready condition is True.",
+			cd:     composed.New(composed.WithConditions(xpv1.Available())),
+			want:   true,
+		},
+		"UnknownKindNotReady": {
+			reason: "Kinds that aren't well-known should not be ready when their Ready condition is not True.",
+			cd:     composed.New(composed.WithConditions(xpv1.Creating())),
+			want:   false,
+		},
+		"ConfigMap": {
+			reason: "ConfigMaps have no status and should always be ready.",
+			cd:     obj("v1", "ConfigMap", map[string]interface{}{}),
+			want:   true,
+		},
+		"PersistentVolumeClaimPending": {
+			reason: "A PVC should not be ready until it is bound.",
+			cd:     obj("v1", "PersistentVolumeClaim", map[string]interface{}{"status": map[string]interface{}{"phase": "Pending"}}),
+			want:   false,
+		},
+		"PersistentVolumeClaimBound": {
+			reason: "A bound PVC should be ready.",
+			cd:     obj("v1", "PersistentVolumeClaim", map[string]interface{}{"status": map[string]interface{}{"phase": "Bound"}}),
+			want:   true,
+		},
+		"ClusterIPService": {
+			reason: "A Service that isn't a LoadBalancer should always be ready.",
+			cd:     obj("v1", "Service", map[string]interface{}{"spec": map[string]interface{}{"type": "ClusterIP"}}),
+			want:   true,
+		},
+		"LoadBalancerServicePending": {
+			reason: "A LoadBalancer Service should not be ready until it has been assigned an ingress point.",
+			cd: obj("v1", "Service", map[string]interface{}{
+				"spec":   map[string]interface{}{"type": "LoadBalancer"},
+				"status": map[string]interface{}{"loadBalancer": map[string]interface{}{}},
+			}),
+			want: false,
+		},
+		"LoadBalancerServiceReady": {
+			reason: "A LoadBalancer Service that has been assigned an ingress point should be ready.",
+			cd: obj("v1", "Service", map[string]interface{}{
+				"spec": map[string]interface{}{"type": "LoadBalancer"},
+				"status": map[string]interface{}{"loadBalancer": map[string]interface{}{
+					"ingress": []interface{}{map[string]interface{}{"ip": "192.0.2.1"}},
+				}},
+			}),
+			want: true,
+		},
+		"PodReady": {
+			reason: "A Pod should be ready when its Ready condition is True.",
+			cd:     obj("v1", "Pod", map[string]interface{}{"status": cond("Ready", "True")}),
+			want:   true,
+		},
+		"PodSucceeded": {
+			reason: "A Pod that has succeeded should be ready.",
+			cd:     obj("v1", "Pod", map[string]interface{}{"status": map[string]interface{}{"phase": "Succeeded"}}),
+			want:   true,
+		},
+		"DeploymentUnavailable": {
+			reason: "A Deployment should not be ready until its desired replicas are available.",
+			cd: obj("apps/v1", "Deployment", map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"availableReplicas": int64(2)},
+			}),
+			want: false,
+		},
+		"DeploymentDefaultReplicasUnavailable": {
+			reason: "A Deployment that doesn't specify replicas should not be ready until one replica is available.",
+			cd:     obj("apps/v1", "Deployment", map[string]interface{}{}),
+			want:   false,
+		},
+		"DeploymentStale": {
+			reason: "A Deployment should not be ready until its latest generation has been observed.",
+			cd: func() *composed.Unstructured {
+				cd := obj("apps/v1", "Deployment", map[string]interface{}{
+					"spec":   map[string]interface{}{"replicas": int64(3)},
+					"status": map[string]interface{}{"availableReplicas": int64(3), "observedGeneration": int64(1)},
+				})
+				cd.SetGeneration(2)
+				return cd
+			}(),
+			want: false,
+		},
+		"DeploymentAvailable": {
+			reason: "A Deployment should be ready when its desired replicas are available.",
+			cd: func() *composed.Unstructured {
+				cd := obj("apps/v1", "Deployment", map[string]interface{}{
+					"spec":   map[string]interface{}{"replicas": int64(3)},
+					"status": map[string]interface{}{"availableReplicas": int64(3), "observedGeneration": int64(2)},
+				})
+				cd.SetGeneration(2)
+				return cd
+			}(),
+			want: true,
+		},
+		"DeploymentScaledToZero": {
+			reason: "A Deployment scaled to zero replicas should be ready.",
+			cd:     obj("apps/v1", "Deployment", map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(0)}}),
+			want:   true,
+		},
+		"DaemonSetNotScheduled": {
+			reason: "A DaemonSet should not be ready until its controller has reported how many pods it desires.",
+			cd:     obj("apps/v1", "DaemonSet", map[string]interface{}{}),
+			want:   false,
+		},
+		"DaemonSetAvailable": {
+			reason: "A DaemonSet should be ready when all of its desired pods are available.",
+			cd: obj("apps/v1", "DaemonSet", map[string]interface{}{
+				"status": map[string]interface{}{"desiredNumberScheduled": int64(2), "numberAvailable": int64(2)},
+			}),
+			want: true,
+		},
+		"JobRunning": {
+			reason: "A Job should not be ready until it is complete.",
+			cd:     obj("batch/v1", "Job", map[string]interface{}{"status": map[string]interface{}{"active": int64(1)}}),
+			want:   false,
+		},
+		"JobComplete": {
+			reason: "A complete Job should be ready.",
+			cd:     obj("batch/v1", "Job", map[string]interface{}{"status": cond("Complete", "True")}),
+			want:   true,
+		},
+		"CustomResourceDefinitionEstablished": {
+			reason: "An established CRD should be ready.",
+			cd:     obj("apiextensions.k8s.io/v1", "CustomResourceDefinition", map[string]interface{}{"status": cond("Established", "True")}),
+			want:   true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := defaultReadiness(tc.cd, fieldpath.Pave(tc.cd.UnstructuredContent()))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\ndefaultReadiness(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -22,9 +22,12 @@ import (
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -39,30 +42,41 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/engine"
 )
 
 const (
 	shortWait = 30 * time.Second
 	longWait  = 1 * time.Minute
 	timeout   = 2 * time.Minute
+
+	watchTimeout = 10 * time.Second
+
+	finalizer = "composite.apiextensions.crossplane.io"
 )
 
 // Error strings
 const (
-	errGet          = "cannot get composite resource"
-	errUpdate       = "cannot update composite resource"
-	errUpdateStatus = "cannot update composite resource status"
-	errSelectComp   = "cannot select Composition"
-	errGetComp      = "cannot get Composition"
-	errConfigure    = "cannot configure composite resource"
-	errPublish      = "cannot publish connection details"
-	errRenderCD     = "cannot render composed resource"
-	errRenderCR     = "cannot render composite resource"
-	errValidate     = "refusing to use invalid Composition"
-	errInline       = "cannot inline Composition patch sets"
-	errAssociate    = "cannot associate composed resources with Composition resource templates"
-
-	errFmtRender = "cannot render composed resource from resource template at index %d"
+	errGet             = "cannot get composite resource"
+	errUpdate          = "cannot update composite resource"
+	errUpdateStatus    = "cannot update composite resource status"
+	errSelectComp      = "cannot select Composition"
+	errFetchComp       = "cannot fetch Composition"
+	errConfigure       = "cannot configure composite resource"
+	errPublish         = "cannot publish connection details"
+	errRenderCD        = "cannot render composed resource"
+	errRenderCR        = "cannot render composite resource"
+	errValidate        = "refusing to use invalid Composition"
+	errInline          = "cannot inline Composition patch sets"
+	errAssociate       = "cannot associate composed resources with Composition resource templates"
+	errRunFunctions    = "cannot run composition functions"
+	errRenderTemplates = "cannot render Composition resource templates"
+	errOrphan          = "cannot orphan composed resources"
+	errDelete          = "cannot delete composed resources"
+	errWatchComposed   = "cannot watch composed resources"
+
+	errFmtRender         = "cannot render composed resource from resource template at index %d"
+	errFmtObserveManaged = "resource template at index %d cannot observe a resource until the %s %q it composed before switching to Observe mode is deleted"
 )
 
 // Event reasons.
@@ -70,6 +84,7 @@ const (
 	reasonResolve event.Reason = "SelectComposition"
 	reasonCompose event.Reason = "ComposeResources"
 	reasonPublish event.Reason = "PublishConnectionSecret"
+	reasonDelete  event.Reason = "DeleteResource"
 )
 
 // ControllerName returns the recommended name for controllers that use this
@@ -116,6 +131,21 @@ func (fn CompositionSelectorFn) SelectComposition(ctx context.Context, cr resour
 	return fn(ctx, cr)
 }
 
+// A CompositionFetcher fetches an appropriate Composition for the supplied
+// composite resource.
+type CompositionFetcher interface {
+	Fetch(ctx context.Context, cr resource.Composite) (*v1.Composition, error)
+}
+
+// A CompositionFetcherFn fetches an appropriate Composition for the supplied
+// composite resource.
+type CompositionFetcherFn func(ctx context.Context, cr resource.Composite) (*v1.Composition, error)
+
+// Fetch an appropriate Composition for the supplied composite resource.
+func (fn CompositionFetcherFn) Fetch(ctx context.Context, cr resource.Composite) (*v1.Composition, error) {
+	return fn(ctx, cr)
+}
+
 // A Configurator configures a composite resource using its composition.
 type Configurator interface {
 	Configure(ctx context.Context, cr resource.Composite, cp *v1.Composition) error
@@ -143,6 +173,37 @@ func (fn RendererFn) Render(ctx context.Context, cp resource.Composite, cd resou
 	return fn(ctx, cp, cd, t)
 }
 
+// A SiblingRenderer is used to render a composed resource using the other
+// composed resources of its composite resource.
+type SiblingRenderer interface {
+	RenderFromSiblings(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate, siblings map[string]resource.Composed) error
+}
+
+// A SiblingRendererFn may be used to render a composed resource using the
+// other composed resources of its composite resource.
+type SiblingRendererFn func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate, siblings map[string]resource.Composed) error
+
+// RenderFromSiblings renders the supplied composed resource using the supplied
+// template and sibling composed resources, keyed by template name.
+func (fn SiblingRendererFn) RenderFromSiblings(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate, siblings map[string]resource.Composed) error {
+	return fn(ctx, cd, t, siblings)
+}
+
+// A FunctionRunner runs a pipeline of composition functions, updating the
+// supplied desired composed resources.
+type FunctionRunner interface {
+	RunFunctions(ctx context.Context, cp resource.Composite, fns []v1.Function, desired map[string]resource.Composed) error
+}
+
+// A FunctionRunnerFn may be used to run a pipeline of composition functions.
+type FunctionRunnerFn func(ctx context.Context, cp resource.Composite, fns []v1.Function, desired map[string]resource.Composed) error
+
+// RunFunctions runs the supplied functions, updating the supplied desired
+// composed resources, keyed by resource template name.
+func (fn FunctionRunnerFn) RunFunctions(ctx context.Context, cp resource.Composite, fns []v1.Function, desired map[string]resource.Composed) error {
+	return fn(ctx, cp, fns, desired)
+}
+
 // ConnectionDetailsFetcher fetches the connection details of the Composed resource.
 type ConnectionDetailsFetcher interface {
 	FetchConnectionDetails(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error)
@@ -170,6 +231,19 @@ func (fn ReadinessCheckerFn) IsReady(ctx context.Context, cd resource.Composed,
 	return fn(ctx, cd, t)
 }
 
+// A WatchStarter starts watches on behalf of a running controller.
+type WatchStarter interface {
+	StartWatches(ctx context.Context, controllerName string, ws ...engine.Watch) error
+}
+
+// A WatchStarterFn starts watches on behalf of a running controller.
+type WatchStarterFn func(ctx context.Context, controllerName string, ws ...engine.Watch) error
+
+// StartWatches starts the supplied watches on the named controller.
+func (fn WatchStarterFn) StartWatches(ctx context.Context, controllerName string, ws ...engine.Watch) error {
+	return fn(ctx, controllerName, ws...)
+}
+
 // ReconcilerOption is used to configure the Reconciler.
 type ReconcilerOption func(*Reconciler)
 
@@ -195,6 +269,14 @@ func WithClientApplicator(ca resource.ClientApplicator) ReconcilerOption {
 	}
 }
 
+// WithCompositionFetcher specifies how the composition to be used should be
+// fetched.
+func WithCompositionFetcher(f CompositionFetcher) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composition.CompositionFetcher = f
+	}
+}
+
 // WithCompositionValidator specifies how the Reconciler should validate
 // Compositions.
 func WithCompositionValidator(v CompositionValidator) ReconcilerOption {
@@ -203,6 +285,14 @@ func WithCompositionValidator(v CompositionValidator) ReconcilerOption {
 	}
 }
 
+// WithCompositionTemplater specifies how the Reconciler should render the
+// resource templates of Compositions.
+func WithCompositionTemplater(t CompositionTemplater) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composition.CompositionTemplater = t
+	}
+}
+
 // WithCompositionTemplateAssociator specifies how the Reconciler should
 // associate composition templates with composed resources.
 func WithCompositionTemplateAssociator(a CompositionTemplateAssociator) ReconcilerOption {
@@ -218,6 +308,30 @@ func WithRenderer(rd Renderer) ReconcilerOption {
 	}
 }
 
+// WithSiblingRenderer specifies how the Reconciler should render composed
+// resources using their sibling composed resources.
+func WithSiblingRenderer(rd SiblingRenderer) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.SiblingRenderer = rd
+	}
+}
+
+// WithFunctionRunner specifies how the Reconciler should run a Composition's
+// pipeline of composition functions.
+func WithFunctionRunner(fr FunctionRunner) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.FunctionRunner = fr
+	}
+}
+
+// WithObservedResolver specifies how the Reconciler should resolve the
+// existing resources observed by Observe mode resource templates.
+func WithObservedResolver(or ObservedResolver) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.ObservedResolver = or
+	}
+}
+
 // WithConnectionDetailsFetcher specifies how the Reconciler should fetch the
 // connection details of composed resources.
 func WithConnectionDetailsFetcher(f ConnectionDetailsFetcher) ReconcilerOption {
@@ -265,8 +379,36 @@ func WithCompositeRenderer(rd Renderer) ReconcilerOption {
 	}
 }
 
+// WithOrphaner specifies how the Reconciler should orphan composed resources
+// when their composite resource is deleted.
+func WithOrphaner(o Orphaner) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.Orphaner = o
+	}
+}
+
+// WithComposedDeleter specifies how the Reconciler should delete composed
+// resources when their composite resource is deleted.
+func WithComposedDeleter(d ComposedDeleter) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.ComposedDeleter = d
+	}
+}
+
+// WithWatchStarter specifies how the Reconciler should start watches on the
+// kinds of composed resources it applies. Watches are started on behalf of the
+// named controller, which should be the controller running the Reconciler.
+func WithWatchStarter(controllerName string, w WatchStarter) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.controllerName = controllerName
+		r.watches = w
+	}
+}
+
 type composition struct {
+	CompositionFetcher
 	CompositionValidator
+	CompositionTemplater
 	CompositionTemplateAssociator
 }
 
@@ -275,10 +417,15 @@ type compositeResource struct {
 	Configurator
 	ConnectionPublisher
 	Renderer
+	Orphaner
+	ComposedDeleter
 }
 
 type composedResource struct {
 	Renderer
+	ObservedResolver
+	SiblingRenderer
+	FunctionRunner
 	ConnectionDetailsFetcher
 	ReadinessChecker
 }
@@ -298,10 +445,9 @@ func NewReconciler(mgr manager.Manager, of resource.CompositeKind, opts ...Recon
 		newComposite: nc,
 
 		composition: composition{
-			CompositionValidator: ValidationChain{
-				CompositionValidatorFn(RejectMixedTemplates),
-				CompositionValidatorFn(RejectDuplicateNames),
-			},
+			CompositionFetcher:            NewAPICompositionFetcher(kube),
+			CompositionValidator:          DefaultValidationChain(FunctionOptions{}),
+			CompositionTemplater:          NewAPIGoTemplater(kube),
 			CompositionTemplateAssociator: NewGarbageCollectingAssociator(kube),
 		},
 
@@ -310,14 +456,21 @@ func NewReconciler(mgr manager.Manager, of resource.CompositeKind, opts ...Recon
 			Configurator:        NewConfiguratorChain(NewAPINamingConfigurator(kube), NewAPIConfigurator(kube)),
 			ConnectionPublisher: NewAPIFilteredSecretPublisher(kube, []string{}),
 			Renderer:            RendererFn(RenderComposite),
+			Orphaner:            NewAPIOrphaner(kube),
+			ComposedDeleter:     NewAPIOrderedDeleter(kube),
 		},
 
 		composed: composedResource{
 			Renderer:                 NewAPIDryRunRenderer(kube),
+			ObservedResolver:         NewAPIObservedResolver(kube),
+			SiblingRenderer:          SiblingRendererFn(RenderFromSiblings),
+			FunctionRunner:           NewExecFunctionRunner(kube, FunctionOptions{}),
 			ReadinessChecker:         ReadinessCheckerFn(IsReady),
 			ConnectionDetailsFetcher: NewAPIConnectionDetailsFetcher(kube),
 		},
 
+		watches: WatchStarterFn(func(context.Context, string, ...engine.Watch) error { return nil }),
+
 		log:    logging.NewNopLogger(),
 		record: event.NewNopRecorder(),
 	}
@@ -337,15 +490,66 @@ type Reconciler struct {
 	composite   compositeResource
 	composed    composedResource
 
+	controllerName string
+	watches        WatchStarter
+
 	log    logging.Logger
 	record event.Recorder
 }
 
 // composedRendered is a wrapper around a composed resource that tracks whether
-// it was successfully rendered or not.
+// it was successfully rendered or not, and why. Observed composed resources
+// are read rather than rendered, and are never applied. Held back composed
+// resources already exist, but were not applied because the composed resources
+// they depend on are not yet ready.
 type composedRendered struct {
 	resource resource.Composed
 	rendered bool
+	observed bool
+	heldBack bool
+	message  string
+}
+
+// composedWatches returns a watch for each kind of composed resource that was
+// rendered. Each watch enqueues the composite resource that controls the
+// composed resource that changed.
+func (r *Reconciler) composedWatches(cds []composedRendered) []engine.Watch {
+	h := &handler.EnqueueRequestForOwner{OwnerType: r.newComposite(), IsController: true}
+	seen := map[schema.GroupVersionKind]bool{}
+	ws := make([]engine.Watch, 0, len(cds))
+	for _, cd := range cds {
+		gvk := cd.resource.GetObjectKind().GroupVersionKind()
+		if !cd.rendered || cd.observed || seen[gvk] {
+			continue
+		}
+		seen[gvk] = true
+		u := composed.New()
+		u.SetGroupVersionKind(gvk)
+		ws = append(ws, engine.WatchFor(u, h))
+	}
+	return ws
+}
+
+// getControlled returns the supplied composed resource as it currently exists,
+// or nil if it doesn't exist or isn't controlled by the supplied composite
+// resource.
+func getControlled(ctx context.Context, c client.Reader, cr resource.Composite, cd resource.Composed) (resource.Composed, error) {
+	if cd.GetName() == "" {
+		return nil, nil
+	}
+	existing := composed.New()
+	existing.SetGroupVersionKind(cd.GetObjectKind().GroupVersionKind())
+	err := c.Get(ctx, types.NamespacedName{Namespace: cd.GetNamespace(), Name: cd.GetName()}, existing)
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errGetComposed)
+	}
+	if !metav1.IsControlledBy(existing, cr) {
+		return nil, nil
+	}
+	return existing, nil
 }
 
 // Reconcile a composite resource.
@@ -372,6 +576,44 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		"name", cr.GetName(),
 	)
 
+	// We release any composed resources with the Orphan deletion policy, then
+	// delete the remaining composed resources in the reverse order of their
+	// dependencies before we remove our finalizer.
+	if meta.WasDeleted(cr) {
+		log = log.WithValues("deletion-timestamp", cr.GetDeletionTimestamp())
+
+		if err := r.composite.Orphan(ctx, cr); err != nil {
+			log.Debug(errOrphan, "error", err)
+			r.record.Event(cr, event.Warning(reasonDelete, err))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+
+		done, err := r.composite.DeleteComposed(ctx, cr)
+		if err != nil {
+			log.Debug(errDelete, "error", err)
+			r.record.Event(cr, event.Warning(reasonDelete, err))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		if !done {
+			log.Debug("Waiting for composed resources to be deleted")
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+
+		if !meta.FinalizerExists(cr, finalizer) {
+			return reconcile.Result{Requeue: false}, nil
+		}
+
+		meta.RemoveFinalizer(cr, finalizer)
+		if err := r.client.Update(ctx, cr); err != nil {
+			log.Debug(errUpdate, "error", err)
+			r.record.Event(cr, event.Warning(reasonDelete, err))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+
+		log.Debug("Successfully deleted composite resource")
+		return reconcile.Result{Requeue: false}, nil
+	}
+
 	if err := r.composite.SelectComposition(ctx, cr); err != nil {
 		log.Debug(errSelectComp, "error", err)
 		r.record.Event(cr, event.Warning(reasonResolve, err))
@@ -379,11 +621,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	}
 	r.record.Event(cr, event.Normal(reasonResolve, "Successfully selected composition"))
 
-	// TODO(muvaf): We should lock the deletion of Composition via finalizer
-	// because its deletion will break the field propagation.
-	comp := &v1.Composition{}
-	if err := r.client.Get(ctx, meta.NamespacedNameOf(cr.GetCompositionReference()), comp); err != nil {
-		log.Debug(errGetComp, "error", err)
+	// Compositions that are in use are protected from deletion by a finalizer,
+	// but an XR may select a Composition shortly before it is deleted.
+	comp, err := r.composition.Fetch(ctx, cr)
+	if err != nil {
+		log.Debug(errFetchComp, "error", err)
 		r.record.Event(cr, event.Warning(reasonCompose, err))
 		return reconcile.Result{RequeueAfter: shortWait}, nil
 	}
@@ -400,8 +642,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		"composition-name", comp.GetName(),
 	)
 
-	// TODO(negz): Composition validation should be handled by a validation
-	// webhook, not by this controller.
+	// Compositions are validated by an admission webhook when webhooks are
+	// enabled. We validate them here too, because they may not be.
 	if err := r.composition.Validate(comp); err != nil {
 		log.Debug(errValidate, "error", err)
 		r.record.Event(cr, event.Warning(reasonCompose, err))
@@ -415,6 +657,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		return reconcile.Result{RequeueAfter: shortWait}, nil
 	}
 
+	// GoTemplate mode Compositions render their resource templates from a Go
+	// template before we associate them with composed resources.
+	if err := r.composition.RenderTemplates(ctx, cr, comp); err != nil {
+		log.Debug(errRenderTemplates, "error", err)
+		r.record.Event(cr, event.Warning(reasonCompose, errors.Wrap(err, errRenderTemplates)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
 	tas, err := r.composition.AssociateTemplates(ctx, cr, comp)
 	if err != nil {
 		log.Debug(errAssociate, "error", err)
@@ -430,24 +680,91 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	cds := make([]composedRendered, len(tas))
 	for i, ta := range tas {
 		cd := composed.New(composed.FromReference(ta.Reference))
-		rendered := true
-		if err := r.composed.Render(ctx, cr, cd, ta.Template); err != nil {
+		cds[i] = composedRendered{
+			resource: cd,
+			rendered: true,
+			observed: IsObserved(ta.Template),
+		}
+		render := r.composed.Render
+		if cds[i].observed {
+			// A resource template that switched to Observe mode may still be
+			// associated with a composed resource it used to manage. We
+			// refuse to observe anything until that composed resource is
+			// gone. This way we keep referencing it, rather than leaking it.
+			existing, err := getControlled(ctx, r.client, cr, cd)
+			if err != nil {
+				log.Debug(errGetComposed, "error", err, "index", i)
+				r.record.Event(cr, event.Warning(reasonCompose, err))
+				return reconcile.Result{RequeueAfter: shortWait}, nil
+			}
+			if existing != nil {
+				err := errors.Errorf(errFmtObserveManaged, i, existing.GetObjectKind().GroupVersionKind().Kind, existing.GetName())
+				log.Debug(errRenderCD, "error", err, "index", i)
+				r.record.Event(cr, event.Warning(reasonCompose, err))
+				cds[i].observed = false
+				cds[i].rendered = false
+				cds[i].message = err.Error()
+				continue
+			}
+
+			// We resolve observed resources from scratch each time.
+			cd = composed.New()
+			cds[i].resource = cd
+			render = r.composed.ResolveObserved
+		}
+		if err := render(ctx, cr, cd, ta.Template); err != nil {
 			log.Debug(errRenderCD, "error", err, "index", i)
-			r.record.Event(cr, event.Warning(reasonCompose, errors.Wrapf(err, errFmtRender, i)))
-			rendered = false
+			err = errors.Wrapf(err, errFmtRender, i)
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+			cds[i].rendered = false
+			cds[i].message = err.Error()
 		}
+	}
 
-		cds[i] = composedRendered{
-			resource: cd,
-			rendered: rendered,
+	// Composition functions may update any composed resource we were able to
+	// render. Functions run before we persist references to our composed
+	// resources, but they cannot change their identity.
+	desired := map[string]resource.Composed{}
+	for i := range cds {
+		if cds[i].rendered && !cds[i].observed && tas[i].Template.Name != nil {
+			desired[*tas[i].Template.Name] = cds[i].resource
 		}
+	}
+	if err := r.composed.RunFunctions(ctx, cr, comp.Spec.Functions, desired); err != nil {
+		log.Debug(errRunFunctions, "error", err)
+		r.record.Event(cr, event.Warning(reasonCompose, errors.Wrap(err, errRunFunctions)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
+	for i := range cds {
+		cd := cds[i].resource
 		refs[i] = *meta.ReferenceTo(cd, cd.GetObjectKind().GroupVersionKind())
+
+		// We don't reference the resources we observe by name, so that we
+		// never mistake them for composed resources that we may garbage
+		// collect, orphan, or delete. We resolve them each time we reconcile.
+		if cds[i].observed {
+			refs[i] = corev1.ObjectReference{APIVersion: refs[i].APIVersion, Kind: refs[i].Kind}
+		}
 	}
 
 	// We persist references to our composed resources before we create them.
 	// This way we can render composed resources with non-deterministic names,
 	// and also potentially recover from any errors we encounter while applying
-	// composed resources without leaking them.
+	// composed resources without leaking them. We add our finalizer at the
+	// same time if we need a chance to orphan composed resources that should
+	// outlive us, or to delete composed resources in order. We remove it if we
+	// don't, for example because it was added by an earlier Crossplane
+	// version, and let Kubernetes garbage collect our composed resources.
+	ts := make([]v1.ComposedTemplate, len(tas))
+	for i := range tas {
+		ts[i] = tas[i].Template
+	}
+	if NeedsFinalizer(cr, ts) {
+		meta.AddFinalizer(cr, finalizer)
+	} else {
+		meta.RemoveFinalizer(cr, finalizer)
+	}
 	cr.SetResourceReferences(refs)
 	if err := r.client.Update(ctx, cr); err != nil {
 		log.Debug(errUpdate, "error", err)
@@ -459,27 +776,111 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	// the composite resource accordingly in the loop below. This ensures that
 	// issues observing and processing one composed resource won't block the
 	// application of another.
-	for _, cd := range cds {
+	// Composed resources are applied in order, after any composed resources
+	// they depend on, and each is added to the set of siblings once applied.
+	// This allows FromComposedFieldPath patches to read the observed state of
+	// composed resources that appear earlier in the Composition. We hold back
+	// composed resources until the composed resources they depend on are
+	// ready.
+	siblings := map[string]resource.Composed{}
+	for _, i := range ApplyOrder(templatesOf(tas)) {
 		// If we were unable to render the composed resource we should not try
 		// and apply it.
-		if !cd.rendered {
+		if !cds[i].rendered {
 			continue
 		}
-		if err := r.client.Apply(ctx, cd.resource, resource.MustBeControllableBy(cr.GetUID())); err != nil {
+		t := tas[i].Template
+
+		// We never apply the resources we observe, but their siblings may
+		// patch from them.
+		if cds[i].observed {
+			if t.Name != nil {
+				siblings[*t.Name] = cds[i].resource
+			}
+			continue
+		}
+
+		ready, err := DependenciesReady(ctx, r.composed.ReadinessChecker, t, tas, siblings)
+		if err != nil {
+			log.Debug(errReadiness, "error", err)
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		if !ready {
+			// We don't apply composed resources that we hold back, but we
+			// keep observing any that already exist so that we don't lose
+			// their connection details, readiness, or patches.
+			log.Debug("Holding back composed resource until its dependencies are ready", "index", i)
+			existing, err := getControlled(ctx, r.client, cr, cds[i].resource)
+			if err != nil {
+				log.Debug(errGetComposed, "error", err, "index", i)
+				r.record.Event(cr, event.Warning(reasonCompose, err))
+				return reconcile.Result{RequeueAfter: shortWait}, nil
+			}
+			cds[i].message = msgDependenciesNotReady
+			if existing == nil {
+				cds[i].rendered = false
+				continue
+			}
+			cds[i].resource = existing
+			cds[i].heldBack = true
+			continue
+		}
+		if err := r.composed.RenderFromSiblings(ctx, cds[i].resource, t, siblings); err != nil {
+			log.Debug(errRenderCD, "error", err, "index", i)
+			err = errors.Wrapf(err, errFmtRender, i)
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+			cds[i].rendered = false
+			cds[i].message = err.Error()
+			continue
+		}
+		err = r.client.Apply(ctx, cds[i].resource, resource.MustBeControllableBy(cr.GetUID()))
+		if IsApplyConflict(err) {
+			// Another field manager owns some of the fields we render. We
+			// report the conflict and carry on applying our other composed
+			// resources rather than fighting for ownership.
+			log.Debug(errApply, "error", err, "index", i)
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+			cds[i].rendered = false
+			cds[i].message = err.Error()
+			continue
+		}
+		if err != nil {
 			log.Debug(errApply, "error", err)
 			r.record.Event(cr, event.Warning(reasonCompose, err))
 			return reconcile.Result{RequeueAfter: shortWait}, nil
 		}
+		if t.Name != nil {
+			siblings[*t.Name] = cds[i].resource
+		}
+	}
+
+	// We watch the kinds of composed resources we apply so that we're queued
+	// as soon as any of them change, rather than waiting to poll them. Failing
+	// to start a watch isn't fatal; we'll still poll, and try to start it
+	// again next time we reconcile. We don't wait long for a watch to start,
+	// because it may never start if we're forbidden from watching its kind.
+	wctx, cancel := context.WithTimeout(ctx, watchTimeout)
+	err = r.watches.StartWatches(wctx, r.controllerName, r.composedWatches(cds)...)
+	cancel()
+	if err != nil {
+		log.Debug(errWatchComposed, "error", err)
+		r.record.Event(cr, event.Warning(reasonCompose, errors.Wrap(err, errWatchComposed)))
 	}
 
 	conn := managed.ConnectionDetails{}
 	ready := 0
-	for i, tpl := range comp.Spec.Resources {
+	statuses := make([]ResourceStatus, len(tas))
+	for i, ta := range tas {
 		cd := cds[i]
+		tpl := ta.Template
 
 		// If we were unable to render the composed resource we should not try
 		// and to observe it.
 		if !cd.rendered {
+			statuses[i] = NewResourceStatus(tpl, cd.resource)
+			statuses[i].Synced = false
+			statuses[i].Message = cd.message
 			continue
 		}
 
@@ -510,7 +911,15 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		if rdy {
 			ready++
 		}
+
+		statuses[i] = NewResourceStatus(tpl, cd.resource)
+		statuses[i].Ready = rdy
+		if cd.heldBack {
+			statuses[i].Synced = false
+			statuses[i].Message = cd.message
+		}
 	}
+	SetResourceStatuses(cr, statuses)
 
 	// We pass a deepcopy because the update method doesn't update status,
 	// but calling update resets any pending status changes.
@@ -546,12 +955,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		r.record.Event(cr, event.Normal(reasonPublish, "Successfully published connection details"))
 	}
 
-	// TODO(muvaf):
-	// * Report which resources are not ready.
-	// * If a resource becomes Unavailable at some point, should we still report
-	//   it as Creating?
+	// TODO(muvaf): If a resource becomes Unavailable at some point, should we
+	// still report it as Creating?
 	if ready != len(refs) {
-		cr.SetConditions(xpv1.Creating())
+		cr.SetConditions(xpv1.Creating().WithMessage(UnreadyMessage(statuses)))
 		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
 	}
 
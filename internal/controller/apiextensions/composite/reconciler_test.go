@@ -24,22 +24,28 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/engine"
 )
 
 func TestReconcile(t *testing.T) {
 	errBoom := errors.New("boom")
+	errConflict := kerrors.NewApplyConflict([]metav1.StatusCause{{Type: metav1.CauseTypeFieldManagerConflict}}, "boom")
+	observe := v1.ComposedTemplateModeObserve
 	cd := managed.ConnectionDetails{"a": []byte("b")}
+	now := metav1.Now()
 
 	type args struct {
 		mgr  manager.Manager
@@ -89,17 +95,21 @@ func TestReconcile(t *testing.T) {
 				err: errors.Wrap(errBoom, errGet),
 			},
 		},
-		"SelectCompositionError": {
-			reason: "We should requeue after a short wait if we encounter an error while selecting a composition.",
+		"OrphanComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while orphaning composed resources.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil),
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								obj.SetDeletionTimestamp(&now)
+								obj.SetFinalizers([]string{finalizer})
+								return nil
+							}),
 						},
 					}),
-					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, _ resource.Composite) error {
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) error {
 						return errBoom
 					})),
 				},
@@ -108,21 +118,149 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"GetCompositionError": {
-			reason: "We should requeue after a short wait if we encounter an error while getting a composition.",
+		"DeleteComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while deleting composed resources.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
 							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if _, ok := obj.(*v1.Composition); ok {
-									return errBoom
+								obj.SetDeletionTimestamp(&now)
+								obj.SetFinalizers([]string{finalizer})
+								return nil
+							}),
+						},
+					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) error {
+						return nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite) (bool, error) {
+						return false, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"WaitForComposedDeletion": {
+			reason: "We should requeue after a short wait if composed resources are still waiting to be deleted.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								obj.SetDeletionTimestamp(&now)
+								obj.SetFinalizers([]string{finalizer})
+								return nil
+							}),
+						},
+					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) error {
+						return nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite) (bool, error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"RemoveFinalizerError": {
+			reason: "We should requeue after a short wait if we encounter an error while removing our finalizer.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								obj.SetDeletionTimestamp(&now)
+								obj.SetFinalizers([]string{finalizer})
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(errBoom),
+						},
+					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) error {
+						return nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite) (bool, error) {
+						return true, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"SuccessfulDelete": {
+			reason: "We should not requeue once we have orphaned composed resources and removed our finalizer.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								obj.SetDeletionTimestamp(&now)
+								obj.SetFinalizers([]string{finalizer})
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								if len(obj.GetFinalizers()) != 0 {
+									t.Errorf("Update(...): want no finalizers, got %v", obj.GetFinalizers())
 								}
 								return nil
 							}),
 						},
 					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) error {
+						return nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite) (bool, error) {
+						return true, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"SelectCompositionError": {
+			reason: "We should requeue after a short wait if we encounter an error while selecting a composition.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, _ resource.Composite) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"FetchCompositionError": {
+			reason: "We should requeue after a short wait if we encounter an error while fetching a composition.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+						},
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						return nil, errBoom
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -143,6 +281,9 @@ func TestReconcile(t *testing.T) {
 							MockGet: test.NewMockGetFn(nil),
 						},
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						return &v1.Composition{}, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -166,6 +307,9 @@ func TestReconcile(t *testing.T) {
 							MockGet: test.NewMockGetFn(nil),
 						},
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						return &v1.Composition{}, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -187,22 +331,49 @@ func TestReconcile(t *testing.T) {
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{
-										Patches: []v1.Patch{{
-											Type:         v1.PatchTypePatchSet,
-											PatchSetName: pointer.StringPtr("nonexistent-patchset"),
-										}},
-									}}
-								}
-								return nil
-							}),
+							MockGet: test.NewMockGetFn(nil),
 						},
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
 							return nil
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{
+							Patches: []v1.Patch{{
+								Type:         v1.PatchTypePatchSet,
+								PatchSetName: pointer.StringPtr("nonexistent-patchset"),
+							}},
+						}}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithCompositionValidator(CompositionValidatorFn(func(comp *v1.Composition) error { return nil })),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"RenderTemplatesError": {
+			reason: "We should requeue after a short wait if we encounter an error while rendering Composition resource templates.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+						},
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						return &v1.Composition{}, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -211,6 +382,9 @@ func TestReconcile(t *testing.T) {
 						return nil
 					})),
 					WithCompositionValidator(CompositionValidatorFn(func(comp *v1.Composition) error { return nil })),
+					WithCompositionTemplater(CompositionTemplaterFn(func(ctx context.Context, cr resource.Composite, comp *v1.Composition) error {
+						return errBoom
+					})),
 				},
 			},
 			want: want{
@@ -230,6 +404,9 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						return &v1.Composition{}, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -254,12 +431,7 @@ func TestReconcile(t *testing.T) {
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
-								}
-								return nil
-							}),
+							MockGet:          test.NewMockGetFn(nil),
 							MockUpdate:       test.NewMockUpdateFn(nil),
 							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
@@ -267,6 +439,11 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -289,6 +466,41 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
+		"RunFunctionsError": {
+			reason: "We should requeue after a short wait if we encounter an error while running composition functions.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet:    test.NewMockGetFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil),
+						},
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+					WithFunctionRunner(FunctionRunnerFn(func(ctx context.Context, cp resource.Composite, fns []v1.Function, desired map[string]resource.Composed) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
 		"UpdateCompositeError": {
 			reason: "We should requeue after a short wait if we encounter an error while updating our composite resource with references.",
 			args: args{
@@ -296,15 +508,15 @@ func TestReconcile(t *testing.T) {
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
-								}
-								return nil
-							}),
+							MockGet:    test.NewMockGetFn(nil),
 							MockUpdate: test.NewMockUpdateFn(errBoom),
 						},
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -328,18 +540,18 @@ func TestReconcile(t *testing.T) {
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
-								}
-								return nil
-							}),
+							MockGet:    test.NewMockGetFn(nil),
 							MockUpdate: test.NewMockUpdateFn(nil),
 						},
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
 							return errBoom
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -356,25 +568,32 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"FetchConnectionDetailsError": {
-			reason: "We should requeue after a short wait if we encounter an error while fetching a composed resource's connection details.",
+		"AddFinalizer": {
+			reason: "We should add our finalizer if we may need to orphan composed resources.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+							MockGet: test.NewMockGetFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								if diff := cmp.Diff([]string{finalizer}, obj.GetFinalizers()); diff != "" {
+									t.Errorf("Update(...): -want finalizers, +got finalizers:\n%s", diff)
 								}
 								return nil
 							}),
-							MockUpdate: test.NewMockUpdateFn(nil),
 						},
+						// We return an error to prove we reached the apply.
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
-							return nil
+							return errBoom
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						orphan := xpv1.DeletionOrphan
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{DeletionPolicy: &orphan}}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -385,35 +604,40 @@ func TestReconcile(t *testing.T) {
 					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
 						return nil
 					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return nil, errBoom
-					})),
 				},
 			},
 			want: want{
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"CheckReadinessError": {
-			reason: "We should requeue after a short wait if we encounter an error while checking whether a composed resource is ready.",
+		"RemoveUnneededFinalizer": {
+			reason: "We should remove our finalizer if we don't need to orphan composed resources or delete them in order.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
 							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								obj.SetFinalizers([]string{finalizer})
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								if len(obj.GetFinalizers()) != 0 {
+									t.Errorf("Update(...): want no finalizers, got %v", obj.GetFinalizers())
 								}
 								return nil
 							}),
-							MockUpdate:       test.NewMockUpdateFn(nil),
-							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
+						// We return an error to prove we reached the apply.
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
-							return nil
+							return errBoom
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -424,38 +648,31 @@ func TestReconcile(t *testing.T) {
 					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
 						return nil
 					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return nil, nil
-					})),
-					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
-						return false, errBoom
-					})),
 				},
 			},
 			want: want{
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"CompositeRenderError": {
-			reason: "We should requeue after a short wait if we encounter an error while rendering the Composite.",
+		"ApplyComposedLockConflict": {
+			reason: "We should requeue after a short wait if applying a composed resource fails with a conflict that was not caused by another field manager.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
-								}
-								return nil
-							}),
-							MockUpdate:       test.NewMockUpdateFn(nil),
-							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+							MockGet:    test.NewMockGetFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil),
 						},
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
-							return nil
+							return kerrors.NewConflict(schema.GroupResource{}, "conflicted", errBoom)
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -466,47 +683,49 @@ func TestReconcile(t *testing.T) {
 					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
 						return nil
 					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return nil, nil
-					})),
-					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
-						return false, nil
-					})),
-					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						return errBoom
-					})),
 				},
 			},
 			want: want{
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"CompositeUpdateError": {
-			reason: "We should requeue after a short wait if we encounter an error while updating the Composite.",
+		"ApplyComposedConflict": {
+			reason: "We should report a field manager conflict applying a composed resource and carry on applying the others.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+							MockGet:    test.NewMockGetFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil),
+							// We return an error to prove we reached the end
+							// of the reconcile.
+							MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom, func(obj client.Object) error {
+								want := []ResourceStatus{
+									{TemplateName: "conflicted", Name: "conflicted", Message: errConflict.Error()},
+									{TemplateName: "applied", Name: "applied", Ready: true, Synced: true},
 								}
-								return nil
-							}),
-							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
-								// annotation will be set by mock composite render
-								if obj.GetAnnotations()["composite-rendered"] == "true" {
-									return errBoom
+								if diff := cmp.Diff(want, GetResourceStatuses(obj.(resource.Composite))); diff != "" {
+									t.Errorf("Status().Update(...): -want resource statuses, +got resource statuses:\n%s", diff)
 								}
 								return nil
 							}),
-							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							if r.GetName() == "conflicted" {
+								return errConflict
+							}
 							return nil
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{
+							{Name: pointer.StringPtr("conflicted")},
+							{Name: pointer.StringPtr("applied")},
+						}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -515,39 +734,212 @@ func TestReconcile(t *testing.T) {
 						return nil
 					})),
 					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						cd.SetName(*t.Name)
 						return nil
 					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
 						return nil, nil
 					})),
 					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
 						return true, nil
 					})),
-					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						// use arbitrary annotation to track api-server requests
-						// made after composite render
-						cp.SetAnnotations(map[string]string{"composite-rendered": "true"})
-						return nil
-					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r:   reconcile.Result{RequeueAfter: shortWait},
+				err: errors.Wrap(errBoom, errUpdateStatus),
+			},
+		},
+		"FetchConnectionDetailsError": {
+			reason: "We should requeue after a short wait if we encounter an error while fetching a composed resource's connection details.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet:    test.NewMockGetFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, errBoom
+					})),
 				},
 			},
 			want: want{
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"CompositeUpdateEarlyExit": {
-			reason: "We should early exit to be immediately enqueued if the composite is updated by composed.",
+		"CheckReadinessError": {
+			reason: "We should requeue after a short wait if we encounter an error while checking whether a composed resource is ready.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+							MockGet:          test.NewMockGetFn(nil),
+							MockUpdate:       test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return false, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CompositeRenderError": {
+			reason: "We should requeue after a short wait if we encounter an error while rendering the Composite.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet:          test.NewMockGetFn(nil),
+							MockUpdate:       test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return false, nil
+					})),
+					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CompositeUpdateError": {
+			reason: "We should requeue after a short wait if we encounter an error while updating the Composite.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								// annotation will be set by mock composite render
+								if obj.GetAnnotations()["composite-rendered"] == "true" {
+									return errBoom
 								}
 								return nil
 							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return true, nil
+					})),
+					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						// use arbitrary annotation to track api-server requests
+						// made after composite render
+						cp.SetAnnotations(map[string]string{"composite-rendered": "true"})
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CompositeUpdateEarlyExit": {
+			reason: "We should early exit to be immediately enqueued if the composite is updated by composed.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
 							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
 								// annotation will be set by mock composite render
 								if obj.GetAnnotations()["composite-rendered"] == "true" {
@@ -562,6 +954,11 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -605,6 +1002,9 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						return &v1.Composition{}, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -628,12 +1028,7 @@ func TestReconcile(t *testing.T) {
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
-								}
-								return nil
-							}),
+							MockGet:          test.NewMockGetFn(nil),
 							MockUpdate:       test.NewMockUpdateFn(nil),
 							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
@@ -641,6 +1036,11 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -667,19 +1067,163 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"ComposedResourcesReady": {
-			reason: "We should requeue after a long wait if all of our composed resources are ready.",
+		"ComposedResourceHeldBack": {
+			reason: "We should not apply a composed resource until the composed resources it depends on are ready.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+							MockGet:    test.NewMockGetFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(resource.Composite)
+								wantMsg := "Unready resources: nodepool, cluster"
+								if diff := cmp.Diff(wantMsg, cr.GetCondition(xpv1.TypeReady).Message); diff != "" {
+									t.Errorf("Status().Update(...): -want message, +got message:\n%s", diff)
+								}
+								want := []ResourceStatus{
+									{TemplateName: "nodepool", Name: "nodepool", Message: msgDependenciesNotReady},
+									{TemplateName: "cluster", Name: "cluster", Synced: true},
+								}
+								if diff := cmp.Diff(want, GetResourceStatuses(cr)); diff != "" {
+									t.Errorf("Status().Update(...): -want resource statuses, +got resource statuses:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							if r.GetName() != "cluster" {
+								t.Errorf("Apply(...): unexpected apply of %q", r.GetName())
+							}
+							return nil
+						}),
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{
+							{Name: pointer.StringPtr("nodepool"), DependsOn: []string{"cluster"}},
+							{Name: pointer.StringPtr("cluster")},
+						}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						cd.SetName(*t.Name)
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						// Our cluster is not yet ready.
+						return false, nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ExistingComposedResourceHeldBack": {
+			reason: "We should continue to observe a composed resource that already exists while we hold it back.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+								if cr, ok := obj.(resource.Composite); ok {
+									cr.SetUID("cool-xr")
+									return nil
+								}
+								// Our nodepool already exists.
+								obj.SetName(key.Name)
+								obj.SetOwnerReferences([]metav1.OwnerReference{{UID: "cool-xr", Controller: pointer.BoolPtr(true)}})
+								return nil
+							},
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(resource.Composite)
+								wantMsg := "Unready resources: cluster"
+								if diff := cmp.Diff(wantMsg, cr.GetCondition(xpv1.TypeReady).Message); diff != "" {
+									t.Errorf("Status().Update(...): -want message, +got message:\n%s", diff)
+								}
+								want := []ResourceStatus{
+									{TemplateName: "nodepool", Name: "nodepool", Ready: true, Message: msgDependenciesNotReady},
+									{TemplateName: "cluster", Name: "cluster", Synced: true},
+								}
+								if diff := cmp.Diff(want, GetResourceStatuses(cr)); diff != "" {
+									t.Errorf("Status().Update(...): -want resource statuses, +got resource statuses:\n%s", diff)
 								}
 								return nil
 							}),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							if r.GetName() != "cluster" {
+								t.Errorf("Apply(...): unexpected apply of %q", r.GetName())
+							}
+							return nil
+						}),
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{
+							{Name: pointer.StringPtr("nodepool"), DependsOn: []string{"cluster"}},
+							{Name: pointer.StringPtr("cluster")},
+						}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						cd.SetName(*t.Name)
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						if *t.Name == "nodepool" {
+							return cd, nil
+						}
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						// Our nodepool is ready, but our cluster is not.
+						return *t.Name == "nodepool", nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+						if diff := cmp.Diff(cd, got); diff != "" {
+							t.Errorf("PublishConnection(...): -want, +got:\n%s", diff)
+						}
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"StartComposedWatchesError": {
+			reason: "We should start a watch for each kind of composed resource we apply, and not fail if we can't.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet:          test.NewMockGetFn(nil),
 							MockUpdate:       test.NewMockUpdateFn(nil),
 							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
@@ -687,6 +1231,229 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{
+							{Name: pointer.StringPtr("cluster")},
+							{Name: pointer.StringPtr("nodepool-a")},
+							{Name: pointer.StringPtr("nodepool-b")},
+						}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						// Our two node pools are of the same kind.
+						kind := "NodePool"
+						if *t.Name == "cluster" {
+							kind = "Cluster"
+						}
+						cd.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: kind})
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return true, nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+					WithWatchStarter("cool-controller", WatchStarterFn(func(_ context.Context, name string, ws ...engine.Watch) error {
+						if diff := cmp.Diff("cool-controller", name); diff != "" {
+							t.Errorf("StartWatches(...): -want name, +got name:\n%s", diff)
+						}
+						if diff := cmp.Diff(2, len(ws)); diff != "" {
+							t.Errorf("StartWatches(...): -want watches, +got watches:\n%s", diff)
+						}
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: longWait},
+			},
+		},
+		"ObservedResourceNotApplied": {
+			reason: "We should observe, but never apply or reference by name, the resources of Observe mode templates.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								// We're also called with a deep copy of the
+								// composite resource that has lost its type.
+								cr, ok := obj.(resource.Composite)
+								if !ok {
+									return nil
+								}
+								want := []corev1.ObjectReference{
+									{APIVersion: "example.org/v1", Kind: "VPC"},
+									{Name: "subnet"},
+								}
+								if diff := cmp.Diff(want, cr.GetResourceReferences()); diff != "" {
+									t.Errorf("Update(...): -want resource references, +got resource references:\n%s", diff)
+								}
+								return nil
+							}),
+							// We return an error to prove we reached the end
+							// of the reconcile.
+							MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							if r.GetName() != "subnet" {
+								t.Errorf("Apply(...): unexpected apply of %q", r.GetName())
+							}
+							return nil
+						}),
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{
+							{Name: pointer.StringPtr("vpc"), Mode: &observe},
+							{Name: pointer.StringPtr("subnet")},
+						}
+						return comp, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						cd.SetName(*t.Name)
+						return nil
+					})),
+					WithObservedResolver(ObservedResolverFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						cd.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "VPC"})
+						cd.SetName(*t.Name)
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return true, nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r:   reconcile.Result{RequeueAfter: longWait},
+				err: errors.Wrap(errBoom, errUpdateStatus),
+			},
+		},
+		"ObserveResourceStillManaged": {
+			reason: "We should refuse to observe a resource, and keep referencing the composed resource, if a template that switched to Observe mode still composes a resource.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+								if cr, ok := obj.(resource.Composite); ok {
+									cr.SetUID("cool-xr")
+									return nil
+								}
+								// The VPC this template used to manage still
+								// exists.
+								obj.SetName(key.Name)
+								obj.SetOwnerReferences([]metav1.OwnerReference{{UID: "cool-xr", Controller: pointer.BoolPtr(true)}})
+								return nil
+							},
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								cr, ok := obj.(resource.Composite)
+								if !ok {
+									return nil
+								}
+								want := []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "VPC", Name: "managed-vpc"}}
+								if diff := cmp.Diff(want, cr.GetResourceReferences()); diff != "" {
+									t.Errorf("Update(...): -want resource references, +got resource references:\n%s", diff)
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(resource.Composite)
+								want := []ResourceStatus{{
+									TemplateName: "vpc",
+									Kind:         "VPC",
+									Name:         "managed-vpc",
+									Message:      errors.Errorf(errFmtObserveManaged, 0, "VPC", "managed-vpc").Error(),
+								}}
+								if diff := cmp.Diff(want, GetResourceStatuses(cr)); diff != "" {
+									t.Errorf("Status().Update(...): -want resource statuses, +got resource statuses:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							t.Errorf("Apply(...): unexpected apply of %q", r.GetName())
+							return nil
+						}),
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{Name: pointer.StringPtr("vpc"), Mode: &observe}}
+						return comp, nil
+					})),
+					WithCompositionTemplateAssociator(CompositionTemplateAssociatorFn(func(_ context.Context, _ resource.Composite, comp *v1.Composition) ([]TemplateAssociation, error) {
+						return []TemplateAssociation{{
+							Template:  comp.Spec.Resources[0],
+							Reference: corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "VPC", Name: "managed-vpc"},
+						}}, nil
+					})),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithObservedResolver(ObservedResolverFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, _ v1.ComposedTemplate) error {
+						t.Errorf("ResolveObserved(...): unexpected call")
+						return nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ComposedResourcesReady": {
+			reason: "We should requeue after a long wait if all of our composed resources are ready.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet:          test.NewMockGetFn(nil),
+							MockUpdate:       test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionFetcher(CompositionFetcherFn(func(_ context.Context, _ resource.Composite) (*v1.Composition, error) {
+						comp := &v1.Composition{}
+						comp.Spec.Resources = []v1.ComposedTemplate{{}}
+						return comp, nil
+					})),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -0,0 +1,255 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Error strings.
+const (
+	errNoCompositionRef = "composite resource does not reference a Composition"
+	errNoRevisionRef    = "composite resource does not reference a CompositionRevision"
+	errGetRevision      = "cannot get CompositionRevision"
+	errListRevisions    = "cannot list CompositionRevisions"
+	errNoRevisions      = "cannot find any revisions of the referenced Composition"
+	errRevisionMismatch = "referenced CompositionRevision is not a revision of the referenced Composition"
+)
+
+// Composite resource field paths.
+const (
+	fieldCompositionRevisionRef  = "spec.compositionRevisionRef"
+	fieldCompositionUpdatePolicy = "spec.compositionUpdatePolicy"
+)
+
+// GetCompositionRevisionReference returns the CompositionRevision referenced by
+// the supplied composite resource, if any.
+func GetCompositionRevisionReference(cr resource.Composite) *corev1.ObjectReference {
+	u, ok := cr.(*composite.Unstructured)
+	if !ok {
+		return nil
+	}
+	out := &corev1.ObjectReference{}
+	if err := fieldpath.Pave(u.Object).GetValueInto(fieldCompositionRevisionRef, out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// SetCompositionRevisionReference sets the CompositionRevision referenced by
+// the supplied composite resource.
+func SetCompositionRevisionReference(cr resource.Composite, ref *corev1.ObjectReference) {
+	u, ok := cr.(*composite.Unstructured)
+	if !ok {
+		return
+	}
+	_ = fieldpath.Pave(u.Object).SetValue(fieldCompositionRevisionRef, ref)
+}
+
+// GetCompositionUpdatePolicy returns the composition update policy of the
+// supplied composite resource. Composite resources that do not specify a
+// policy are updated automatically.
+func GetCompositionUpdatePolicy(cr resource.Composite) v1.UpdatePolicy {
+	u, ok := cr.(*composite.Unstructured)
+	if !ok {
+		return v1.UpdateAutomatic
+	}
+	p, err := fieldpath.Pave(u.Object).GetString(fieldCompositionUpdatePolicy)
+	if err != nil || p == "" {
+		return v1.UpdateAutomatic
+	}
+	return v1.UpdatePolicy(p)
+}
+
+// NewCompositionFromRevision returns a Composition with the spec of the
+// supplied CompositionRevision. The returned Composition is named for the
+// Composition the revision was derived from. It should not be persisted to the
+// API server.
+func NewCompositionFromRevision(rev *v1.CompositionRevision) *v1.Composition {
+	return &v1.Composition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            CompositionNameOf(rev),
+			UID:             rev.GetUID(),
+			ResourceVersion: rev.GetResourceVersion(),
+			Labels:          rev.GetLabels(),
+		},
+		Spec: *rev.Spec.CompositionSpec.DeepCopy(),
+	}
+}
+
+// An APIRevisionSelector selects the appropriate CompositionRevision for a
+// composite resource.
+type APIRevisionSelector struct {
+	client client.Client
+}
+
+// NewAPIRevisionSelector returns a CompositionSelector that selects the
+// appropriate CompositionRevision of the Composition referenced by a composite
+// resource.
+func NewAPIRevisionSelector(c client.Client) *APIRevisionSelector {
+	return &APIRevisionSelector{client: c}
+}
+
+// SelectComposition selects the appropriate revision of the Composition
+// referenced by the supplied composite resource. Composite resources with an
+// Automatic update policy use the latest revision of their Composition.
+// Composite resources with a Manual update policy use the revision they
+// reference, or are pinned to the latest revision if they don't yet reference
+// one.
+func (s *APIRevisionSelector) SelectComposition(ctx context.Context, cr resource.Composite) error {
+	ref := cr.GetCompositionReference()
+	if ref == nil {
+		return errors.New(errNoCompositionRef)
+	}
+
+	current := GetCompositionRevisionReference(cr)
+	if current != nil && GetCompositionUpdatePolicy(cr) == v1.UpdateManual {
+		rev := &v1.CompositionRevision{}
+		if err := s.client.Get(ctx, types.NamespacedName{Name: current.Name}, rev); err != nil {
+			return errors.Wrap(err, errGetRevision)
+		}
+
+		// The pinned revision may belong to a Composition other than the one
+		// the composite resource now references, for example because its
+		// compositionRef was changed. If so we pin the latest revision of the
+		// newly referenced Composition instead.
+		if CompositionNameOf(rev) == ref.Name {
+			return nil
+		}
+	}
+
+	revs, err := ListRevisions(ctx, s.client, ref.Name)
+	if err != nil {
+		return errors.Wrap(err, errListRevisions)
+	}
+
+	latest := LatestRevision(revs)
+	if latest == nil {
+		return errors.New(errNoRevisions)
+	}
+
+	if current != nil && current.Name == latest.GetName() {
+		return nil
+	}
+
+	SetCompositionRevisionReference(cr, &corev1.ObjectReference{Name: latest.GetName()})
+	return errors.Wrap(s.client.Update(ctx, cr), errUpdateComposite)
+}
+
+// An APIRevisionFetcher fetches the CompositionRevision referenced by a
+// composite resource from the API server.
+type APIRevisionFetcher struct {
+	client client.Reader
+}
+
+// NewAPIRevisionFetcher returns a CompositionFetcher that fetches the
+// CompositionRevision referenced by a composite resource.
+func NewAPIRevisionFetcher(c client.Reader) *APIRevisionFetcher {
+	return &APIRevisionFetcher{client: c}
+}
+
+// Fetch the CompositionRevision referenced by the supplied composite resource.
+// The revision is expected to have been selected by an APIRevisionSelector.
+func (f *APIRevisionFetcher) Fetch(ctx context.Context, cr resource.Composite) (*v1.Composition, error) {
+	ref := cr.GetCompositionReference()
+	if ref == nil {
+		return nil, errors.New(errNoCompositionRef)
+	}
+
+	current := GetCompositionRevisionReference(cr)
+	if current == nil {
+		return nil, errors.New(errNoRevisionRef)
+	}
+
+	rev := &v1.CompositionRevision{}
+	if err := f.client.Get(ctx, types.NamespacedName{Name: current.Name}, rev); err != nil {
+		return nil, errors.Wrap(err, errGetRevision)
+	}
+
+	if CompositionNameOf(rev) != ref.Name {
+		return nil, errors.New(errRevisionMismatch)
+	}
+
+	return NewCompositionFromRevision(rev), nil
+}
+
+// LatestRevision returns the revision with the highest revision number from the
+// supplied list, or nil if the list is empty. Revisions with equal revision
+// numbers are ordered by creation time, then by name, so that the same revision
+// is returned regardless of the order of the supplied list.
+func LatestRevision(revs []v1.CompositionRevision) *v1.CompositionRevision {
+	var latest *v1.CompositionRevision
+	for i := range revs {
+		if latest == nil || newerRevision(&revs[i], latest) {
+			latest = &revs[i]
+		}
+	}
+	return latest
+}
+
+func newerRevision(a, b *v1.CompositionRevision) bool {
+	if a.Spec.Revision != b.Spec.Revision {
+		return a.Spec.Revision > b.Spec.Revision
+	}
+	ta, tb := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if !ta.Equal(&tb) {
+		return tb.Before(&ta)
+	}
+	return a.GetName() > b.GetName()
+}
+
+// CompositionNameOf returns the name of the Composition the supplied revision
+// was derived from.
+func CompositionNameOf(rev *v1.CompositionRevision) string {
+	if ref := metav1.GetControllerOf(rev); ref != nil && ref.Kind == v1.CompositionKind {
+		return ref.Name
+	}
+	return rev.GetLabels()[v1.LabelCompositionName]
+}
+
+// ListRevisions lists the revisions of the named Composition.
+func ListRevisions(ctx context.Context, c client.Reader, name string) ([]v1.CompositionRevision, error) {
+	rl := &v1.CompositionRevisionList{}
+	var o []client.ListOption
+	if len(validation.IsValidLabelValue(name)) == 0 {
+		o = append(o, client.MatchingLabels{v1.LabelCompositionName: name})
+	}
+	if err := c.List(ctx, rl, o...); err != nil {
+		return nil, err
+	}
+
+	revs := make([]v1.CompositionRevision, 0, len(rl.Items))
+	for i := range rl.Items {
+		if CompositionNameOf(&rl.Items[i]) == name {
+			revs = append(revs, rl.Items[i])
+		}
+	}
+	return revs, nil
+}
@@ -0,0 +1,476 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestAPIRevisionSelector(t *testing.T) {
+	rev := func(name, comp string, num int64) v1.CompositionRevision {
+		return v1.CompositionRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{v1.LabelCompositionName: comp},
+			},
+			Spec: v1.CompositionRevisionSpec{
+				CompositionSpec: v1.CompositionSpec{
+					CompositeTypeRef: v1.TypeReference{APIVersion: "v", Kind: name},
+				},
+				Revision: num,
+			},
+		}
+	}
+	xr := func(rev string, p v1.UpdatePolicy) *composite.Unstructured {
+		cr := composite.New()
+		cr.SetCompositionReference(&corev1.ObjectReference{Name: "coolcomp"})
+		if rev != "" {
+			SetCompositionRevisionReference(cr, &corev1.ObjectReference{Name: rev})
+		}
+		if p != "" {
+			_ = fieldpath.Pave(cr.Object).SetValue(fieldCompositionUpdatePolicy, string(p))
+		}
+		return cr
+	}
+
+	older := rev("coolcomp-1", "coolcomp", 1)
+	latest := rev("coolcomp-2", "coolcomp", 2)
+	other := rev("othercomp-1", "othercomp", 1)
+
+	list := func(revs ...v1.CompositionRevision) test.MockListFn {
+		return func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+			want := []client.ListOption{client.MatchingLabels{v1.LabelCompositionName: "coolcomp"}}
+			if diff := cmp.Diff(want, opts); diff != "" {
+				t.Errorf("List(...): -want, +got:\n%s", diff)
+			}
+			obj.(*v1.CompositionRevisionList).Items = revs
+			return nil
+		}
+	}
+	get := func(r v1.CompositionRevision) test.MockGetFn {
+		return func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+			r.DeepCopyInto(obj.(*v1.CompositionRevision))
+			return nil
+		}
+	}
+
+	type args struct {
+		kube client.Client
+		cr   resource.Composite
+	}
+	type want struct {
+		cr  resource.Composite
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NoCompositionReference": {
+			reason: "We should return an error if the composite resource does not reference a Composition.",
+			args: args{
+				cr: composite.New(),
+			},
+			want: want{
+				cr:  composite.New(),
+				err: errors.New(errNoCompositionRef),
+			},
+		},
+		"GetPinnedRevisionError": {
+			reason: "We should return any error encountered while getting a pinned revision.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cr:   xr("coolcomp-1", v1.UpdateManual),
+			},
+			want: want{
+				cr:  xr("coolcomp-1", v1.UpdateManual),
+				err: errors.Wrap(errBoom, errGetRevision),
+			},
+		},
+		"PinnedRevision": {
+			reason: "We should keep the pinned revision if the update policy is Manual.",
+			args: args{
+				kube: &test.MockClient{MockGet: get(older)},
+				cr:   xr("coolcomp-1", v1.UpdateManual),
+			},
+			want: want{
+				cr: xr("coolcomp-1", v1.UpdateManual),
+			},
+		},
+		"PinnedRevisionOfAnotherComposition": {
+			reason: "We should pin the latest revision if the pinned revision belongs to a different Composition.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    get(other),
+					MockList:   list(older, latest),
+					MockUpdate: test.NewMockUpdateFn(nil),
+				},
+				cr: xr("othercomp-1", v1.UpdateManual),
+			},
+			want: want{
+				cr: xr("coolcomp-2", v1.UpdateManual),
+			},
+		},
+		"ListRevisionsError": {
+			reason: "We should return any error encountered while listing revisions.",
+			args: args{
+				kube: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				cr:   xr("", ""),
+			},
+			want: want{
+				cr:  xr("", ""),
+				err: errors.Wrap(errBoom, errListRevisions),
+			},
+		},
+		"NoRevisions": {
+			reason: "We should return an error if the referenced Composition has no revisions.",
+			args: args{
+				kube: &test.MockClient{MockList: list()},
+				cr:   xr("", ""),
+			},
+			want: want{
+				cr:  xr("", ""),
+				err: errors.New(errNoRevisions),
+			},
+		},
+		"UpdateCompositeError": {
+			reason: "We should return any error encountered while updating the composite resource's revision reference.",
+			args: args{
+				kube: &test.MockClient{
+					MockList:   list(older, latest),
+					MockUpdate: test.NewMockUpdateFn(errBoom),
+				},
+				cr: xr("coolcomp-1", v1.UpdateAutomatic),
+			},
+			want: want{
+				cr:  xr("coolcomp-2", v1.UpdateAutomatic),
+				err: errors.Wrap(errBoom, errUpdateComposite),
+			},
+		},
+		"AutomaticUpdate": {
+			reason: "We should update a composite resource with an Automatic update policy to the latest revision.",
+			args: args{
+				kube: &test.MockClient{
+					MockList:   list(latest, older),
+					MockUpdate: test.NewMockUpdateFn(nil),
+				},
+				cr: xr("coolcomp-1", ""),
+			},
+			want: want{
+				cr: xr("coolcomp-2", ""),
+			},
+		},
+		"PinLatestRevision": {
+			reason: "We should pin a composite resource with a Manual update policy to the latest revision if it does not reference one.",
+			args: args{
+				kube: &test.MockClient{
+					MockList:   list(older, latest),
+					MockUpdate: test.NewMockUpdateFn(nil),
+				},
+				cr: xr("", v1.UpdateManual),
+			},
+			want: want{
+				cr: xr("coolcomp-2", v1.UpdateManual),
+			},
+		},
+		"AlreadyLatest": {
+			reason: "We should not update a composite resource that already references the latest revision.",
+			args: args{
+				kube: &test.MockClient{MockList: list(older, latest)},
+				cr:   xr("coolcomp-2", v1.UpdateAutomatic),
+			},
+			want: want{
+				cr: xr("coolcomp-2", v1.UpdateAutomatic),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := NewAPIRevisionSelector(tc.args.kube)
+			err := s.SelectComposition(context.Background(), tc.args.cr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nSelectComposition(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cr, tc.args.cr); diff != "" {
+				t.Errorf("\n%s\nSelectComposition(...): -want composite, +got composite:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPIRevisionFetcher(t *testing.T) {
+	rev := v1.CompositionRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "coolcomp-1",
+			Labels: map[string]string{v1.LabelCompositionName: "coolcomp"},
+		},
+		Spec: v1.CompositionRevisionSpec{Revision: 1},
+	}
+	xr := func(comp, rev string) *composite.Unstructured {
+		cr := composite.New()
+		if comp != "" {
+			cr.SetCompositionReference(&corev1.ObjectReference{Name: comp})
+		}
+		if rev != "" {
+			SetCompositionRevisionReference(cr, &corev1.ObjectReference{Name: rev})
+		}
+		return cr
+	}
+
+	type args struct {
+		kube client.Reader
+		cr   resource.Composite
+	}
+	type want struct {
+		comp *v1.Composition
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NoCompositionReference": {
+			reason: "We should return an error if the composite resource does not reference a Composition.",
+			args: args{
+				cr: xr("", "coolcomp-1"),
+			},
+			want: want{
+				err: errors.New(errNoCompositionRef),
+			},
+		},
+		"NoRevisionReference": {
+			reason: "We should return an error if the composite resource does not reference a CompositionRevision.",
+			args: args{
+				cr: xr("coolcomp", ""),
+			},
+			want: want{
+				err: errors.New(errNoRevisionRef),
+			},
+		},
+		"GetRevisionError": {
+			reason: "We should return any error encountered while getting the referenced revision.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cr:   xr("coolcomp", "coolcomp-1"),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGetRevision),
+			},
+		},
+		"RevisionOfAnotherComposition": {
+			reason: "We should return an error if the referenced revision belongs to a different Composition.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					rev.DeepCopyInto(obj.(*v1.CompositionRevision))
+					return nil
+				})},
+				cr: xr("othercomp", "coolcomp-1"),
+			},
+			want: want{
+				err: errors.New(errRevisionMismatch),
+			},
+		},
+		"Success": {
+			reason: "We should return a Composition with the spec of the referenced revision.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					rev.DeepCopyInto(obj.(*v1.CompositionRevision))
+					return nil
+				})},
+				cr: xr("coolcomp", "coolcomp-1"),
+			},
+			want: want{
+				comp: NewCompositionFromRevision(&rev),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := NewAPIRevisionFetcher(tc.args.kube)
+			comp, err := f.Fetch(context.Background(), tc.args.cr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFetch(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.comp, comp); diff != "" {
+				t.Errorf("\n%s\nFetch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestLatestRevision(t *testing.T) {
+	revs := []v1.CompositionRevision{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Spec: v1.CompositionRevisionSpec{Revision: 2}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}, Spec: v1.CompositionRevisionSpec{Revision: 3}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c"}, Spec: v1.CompositionRevisionSpec{Revision: 1}},
+	}
+
+	earlier := metav1.NewTime(time.Unix(0, 0))
+	later := metav1.NewTime(time.Unix(60, 0))
+	equal := []v1.CompositionRevision{
+		{ObjectMeta: metav1.ObjectMeta{Name: "b", CreationTimestamp: earlier}, Spec: v1.CompositionRevisionSpec{Revision: 2}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "a", CreationTimestamp: later}, Spec: v1.CompositionRevisionSpec{Revision: 2}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c", CreationTimestamp: later}, Spec: v1.CompositionRevisionSpec{Revision: 1}},
+	}
+	sameTime := []v1.CompositionRevision{
+		{ObjectMeta: metav1.ObjectMeta{Name: "b", CreationTimestamp: earlier}, Spec: v1.CompositionRevisionSpec{Revision: 2}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "a", CreationTimestamp: earlier}, Spec: v1.CompositionRevisionSpec{Revision: 2}},
+	}
+
+	cases := map[string]struct {
+		reason string
+		revs   []v1.CompositionRevision
+		want   *v1.CompositionRevision
+	}{
+		"Empty": {
+			reason: "We should return nil if there are no revisions.",
+			want:   nil,
+		},
+		"Latest": {
+			reason: "We should return the revision with the highest revision number.",
+			revs:   revs,
+			want:   &revs[1],
+		},
+		"EqualRevisionsNewest": {
+			reason: "We should return the most recently created of several revisions with the highest revision number.",
+			revs:   equal,
+			want:   &equal[1],
+		},
+		"EqualRevisionsName": {
+			reason: "We should return the revision with the greatest name if several with the highest revision number were created at the same time.",
+			revs:   sameTime,
+			want:   &sameTime[0],
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := LatestRevision(tc.revs)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nLatestRevision(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestListRevisions(t *testing.T) {
+	errBoom := errors.New("boom")
+	long := strings.Repeat("a", 64)
+
+	revOf := func(name string) v1.CompositionRevision {
+		rev := v1.CompositionRevision{}
+		meta.AddOwnerReference(&rev, meta.AsController(&xpv1.TypedReference{
+			APIVersion: v1.SchemeGroupVersion.String(),
+			Kind:       v1.CompositionKind,
+			Name:       name,
+		}))
+		return rev
+	}
+
+	type args struct {
+		c    client.Reader
+		name string
+	}
+	type want struct {
+		revs []v1.CompositionRevision
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ListError": {
+			reason: "We should return any error encountered listing revisions.",
+			args: args{
+				c:    &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				name: "cool",
+			},
+			want: want{
+				err: errBoom,
+			},
+		},
+		"ShortName": {
+			reason: "We should list the revisions of a Composition by its name label when its name is a valid label value.",
+			args: args{
+				c: &test.MockClient{MockList: func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+					if diff := cmp.Diff([]client.ListOption{client.MatchingLabels{v1.LabelCompositionName: "cool"}}, opts); diff != "" {
+						t.Errorf("List(...): -want, +got:\n%s", diff)
+					}
+					obj.(*v1.CompositionRevisionList).Items = []v1.CompositionRevision{revOf("cool")}
+					return nil
+				}},
+				name: "cool",
+			},
+			want: want{
+				revs: []v1.CompositionRevision{revOf("cool")},
+			},
+		},
+		"LongName": {
+			reason: "We should list the revisions of a Composition by their owner reference when its name is too long to be a label value.",
+			args: args{
+				c: &test.MockClient{MockList: func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+					if len(opts) != 0 {
+						t.Errorf("List(...): unexpected options %v", opts)
+					}
+					obj.(*v1.CompositionRevisionList).Items = []v1.CompositionRevision{revOf("cool"), revOf(long)}
+					return nil
+				}},
+				name: long,
+			},
+			want: want{
+				revs: []v1.CompositionRevision{revOf(long)},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ListRevisions(context.Background(), tc.args.c, tc.args.name)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nListRevisions(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.revs, got); diff != "" {
+				t.Errorf("\n%s\nListRevisions(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
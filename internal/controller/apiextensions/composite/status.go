@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Composite resource status field paths.
+const (
+	fieldResourceStatuses = "status.resourceStatuses"
+)
+
+// Composed resource status messages.
+const (
+	msgDependenciesNotReady = "Waiting for the resources it depends on to become ready"
+	fmtUnready              = "Unready resources: %s"
+)
+
+// A ResourceStatus reports the status of a composed resource.
+type ResourceStatus struct {
+	// TemplateName is the name of the resource template the composed resource
+	// was rendered from, if any.
+	TemplateName string `json:"templateName,omitempty"`
+
+	// Kind of the composed resource.
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the composed resource.
+	Name string `json:"name,omitempty"`
+
+	// Ready is true if the composed resource is ready.
+	Ready bool `json:"ready"`
+
+	// Synced is true if the composed resource was applied, and is not
+	// reporting that it failed to sync.
+	Synced bool `json:"synced"`
+
+	// Message is the last error message relating to the composed resource.
+	Message string `json:"message,omitempty"`
+}
+
+// NewResourceStatus returns the status of the supplied composed resource,
+// which was rendered from the supplied template. Its readiness must be set by
+// the caller.
+func NewResourceStatus(t v1.ComposedTemplate, cd resource.Composed) ResourceStatus {
+	s := ResourceStatus{
+		Kind: cd.GetObjectKind().GroupVersionKind().Kind,
+		Name: cd.GetName(),
+	}
+	if t.Name != nil {
+		s.TemplateName = *t.Name
+	}
+
+	// Only managed resources report whether they are synced. We consider
+	// any other kind of composed resource to be synced once it's applied.
+	synced := cd.GetCondition(xpv1.TypeSynced)
+	s.Synced = synced.Status != corev1.ConditionFalse
+	if !s.Synced {
+		s.Message = synced.Message
+	}
+	return s
+}
+
+// SetResourceStatuses sets the statuses of the composed resources of the
+// supplied composite resource.
+func SetResourceStatuses(cr resource.Composite, s []ResourceStatus) {
+	u, ok := cr.(*composite.Unstructured)
+	if !ok {
+		return
+	}
+	_ = fieldpath.Pave(u.Object).SetValue(fieldResourceStatuses, s)
+}
+
+// GetResourceStatuses returns the statuses of the composed resources of the
+// supplied composite resource, if any.
+func GetResourceStatuses(cr resource.Composite) []ResourceStatus {
+	u, ok := cr.(*composite.Unstructured)
+	if !ok {
+		return nil
+	}
+	out := []ResourceStatus{}
+	if err := fieldpath.Pave(u.Object).GetValueInto(fieldResourceStatuses, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// UnreadyMessage returns a message listing the supplied composed resources that
+// are not ready. Composed resources are identified by their template name, or
+// by their kind and name if they were rendered from an anonymous template.
+func UnreadyMessage(s []ResourceStatus) string {
+	unready := make([]string, 0, len(s))
+	for _, rs := range s {
+		if rs.Ready {
+			continue
+		}
+		switch {
+		case rs.TemplateName != "":
+			unready = append(unready, rs.TemplateName)
+		case rs.Name != "":
+			unready = append(unready, rs.Kind+"/"+rs.Name)
+		default:
+			unready = append(unready, rs.Kind)
+		}
+	}
+	return fmt.Sprintf(fmtUnready, strings.Join(unready, ", "))
+}
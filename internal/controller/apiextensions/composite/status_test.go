@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/pointer"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestNewResourceStatus(t *testing.T) {
+	cd := func(c ...xpv1.Condition) resource.Composed {
+		u := composed.New()
+		u.SetKind("Cluster")
+		u.SetName("cool-cluster")
+		u.SetConditions(c...)
+		return u
+	}
+
+	type args struct {
+		t  v1.ComposedTemplate
+		cd resource.Composed
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   ResourceStatus
+	}{
+		"Anonymous": {
+			reason: "Composed resources of anonymous templates should have no template name.",
+			args: args{
+				cd: cd(),
+			},
+			want: ResourceStatus{Kind: "Cluster", Name: "cool-cluster", Synced: true},
+		},
+		"Synced": {
+			reason: "Composed resources that report they are synced should be synced.",
+			args: args{
+				t:  v1.ComposedTemplate{Name: pointer.StringPtr("cluster")},
+				cd: cd(xpv1.ReconcileSuccess()),
+			},
+			want: ResourceStatus{TemplateName: "cluster", Kind: "Cluster", Name: "cool-cluster", Synced: true},
+		},
+		"NotSynced": {
+			reason: "Composed resources that report they are not synced should report why.",
+			args: args{
+				t:  v1.ComposedTemplate{Name: pointer.StringPtr("cluster")},
+				cd: cd(xpv1.ReconcileError(errBoom)),
+			},
+			want: ResourceStatus{TemplateName: "cluster", Kind: "Cluster", Name: "cool-cluster", Message: errBoom.Error()},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := NewResourceStatus(tc.args.t, tc.args.cd)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nNewResourceStatus(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestResourceStatuses(t *testing.T) {
+	want := []ResourceStatus{
+		{TemplateName: "cluster", Kind: "Cluster", Name: "cool-cluster", Ready: true, Synced: true},
+		{TemplateName: "nodepool", Kind: "NodePool", Synced: false, Message: "boom"},
+	}
+	cr := composite.New()
+	SetResourceStatuses(cr, want)
+	got := GetResourceStatuses(cr)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetResourceStatuses(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestUnreadyMessage(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		s      []ResourceStatus
+		want   string
+	}{
+		"AllReady": {
+			reason: "No resources should be listed if they are all ready.",
+			s:      []ResourceStatus{{TemplateName: "cluster", Ready: true}},
+			want:   "Unready resources: ",
+		},
+		"SomeUnready": {
+			reason: "Unready resources should be identified by their template name, or kind and name.",
+			s: []ResourceStatus{
+				{TemplateName: "cluster", Ready: true},
+				{TemplateName: "nodepool"},
+				{Kind: "Bucket", Name: "cool-bucket"},
+				{Kind: "Bucket"},
+			},
+			want: "Unready resources: nodepool, Bucket/cool-bucket, Bucket",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := UnreadyMessage(tc.s)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nUnreadyMessage(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
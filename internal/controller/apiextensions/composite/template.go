@@ -0,0 +1,456 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Error strings.
+const (
+	errGoTemplateRequired  = "GoTemplate mode Compositions must specify a goTemplate source"
+	errGoTemplateResources = "GoTemplate mode Compositions must not specify resources"
+	errGoTemplateMode      = "only GoTemplate mode Compositions may specify a goTemplate"
+	errParseGoTemplate     = "cannot parse goTemplate"
+	errExecuteGoTemplate   = "cannot execute goTemplate"
+	errReadGoTemplate      = "cannot read resources rendered by goTemplate"
+	errUnstructuredContent = "cannot convert composite resource to unstructured content"
+
+	errFmtCompositionMode           = "composition mode %q is not supported"
+	errFmtTemplatedResource         = "cannot decode resource at index %d rendered by goTemplate"
+	errFmtTemplatedResourceKind     = "resource at index %d rendered by goTemplate must specify an apiVersion and kind"
+	errFmtTemplatedResourceName     = "resource at index %d rendered by goTemplate must be named using the %s annotation"
+	errFmtTemplatedResourceDupeName = "resource name %q rendered by goTemplate is not unique"
+	errFmtTemplateFnRequired        = "required value is missing: %s"
+	errFmtTemplateFnInteger         = "cannot convert %v (%T) to an integer"
+)
+
+// Go template data keys.
+const (
+	goTemplateComposite = "Composite"
+	goTemplateObserved  = "Observed"
+)
+
+// A CompositionTemplater renders the resource templates of a Composition.
+type CompositionTemplater interface {
+	RenderTemplates(ctx context.Context, cr resource.Composite, comp *v1.Composition) error
+}
+
+// A CompositionTemplaterFn renders the resource templates of a Composition.
+type CompositionTemplaterFn func(ctx context.Context, cr resource.Composite, comp *v1.Composition) error
+
+// RenderTemplates of the supplied Composition for the supplied composite
+// resource.
+func (fn CompositionTemplaterFn) RenderTemplates(ctx context.Context, cr resource.Composite, comp *v1.Composition) error {
+	return fn(ctx, cr, comp)
+}
+
+// GetCompositionMode returns the mode of the supplied Composition. Compositions
+// that do not specify a mode are Resources mode Compositions.
+func GetCompositionMode(comp *v1.Composition) v1.CompositionMode {
+	if comp.Spec.Mode == nil {
+		return v1.CompositionModeResources
+	}
+	return *comp.Spec.Mode
+}
+
+// RejectInvalidGoTemplate validates that GoTemplate mode Compositions specify
+// a Go template that can be parsed, and only a Go template. Other Compositions
+// must not specify a Go template.
+func RejectInvalidGoTemplate(comp *v1.Composition) error {
+	switch m := GetCompositionMode(comp); m {
+	case v1.CompositionModeResources:
+		if comp.Spec.GoTemplate != nil {
+			return errors.New(errGoTemplateMode)
+		}
+	case v1.CompositionModeGoTemplate:
+		if comp.Spec.GoTemplate == nil || comp.Spec.GoTemplate.Source == "" {
+			return errors.New(errGoTemplateRequired)
+		}
+		if len(comp.Spec.Resources) > 0 {
+			return errors.New(errGoTemplateResources)
+		}
+		_, err := template.New(comp.GetName()).Funcs(TemplateFuncs()).Parse(comp.Spec.GoTemplate.Source)
+		return errors.Wrap(err, errParseGoTemplate)
+	default:
+		return errors.Errorf(errFmtCompositionMode, m)
+	}
+	return nil
+}
+
+// An APIGoTemplater renders the resource templates of GoTemplate mode
+// Compositions, using the observed composed resources it reads from the API
+// server as inputs.
+type APIGoTemplater struct {
+	client client.Reader
+}
+
+// NewAPIGoTemplater returns a CompositionTemplater that renders the resource
+// templates of GoTemplate mode Compositions.
+func NewAPIGoTemplater(c client.Reader) *APIGoTemplater {
+	return &APIGoTemplater{client: c}
+}
+
+// RenderTemplates replaces the resource templates of the supplied GoTemplate
+// mode Composition with a named template per composed resource rendered by
+// its Go template. Other Compositions are not modified. The updated
+// Composition should not be persisted to the API server.
+func (t *APIGoTemplater) RenderTemplates(ctx context.Context, cr resource.Composite, comp *v1.Composition) error {
+	if GetCompositionMode(comp) != v1.CompositionModeGoTemplate {
+		return nil
+	}
+	if comp.Spec.GoTemplate == nil {
+		return errors.New(errGoTemplateRequired)
+	}
+
+	tmpl, err := template.New(comp.GetName()).Funcs(TemplateFuncs()).Parse(comp.Spec.GoTemplate.Source)
+	if err != nil {
+		return errors.Wrap(err, errParseGoTemplate)
+	}
+
+	xr, err := unstructuredContent(cr)
+	if err != nil {
+		return errors.Wrap(err, errUnstructuredContent)
+	}
+	observed, err := t.observed(ctx, cr)
+	if err != nil {
+		return err
+	}
+
+	out := &bytes.Buffer{}
+	if err := tmpl.Execute(out, map[string]interface{}{goTemplateComposite: xr, goTemplateObserved: observed}); err != nil {
+		return errors.Wrap(err, errExecuteGoTemplate)
+	}
+
+	tmpls, err := ParseTemplatedResources(out.Bytes())
+	if err != nil {
+		return err
+	}
+
+	cfg := map[string]v1.TemplatedResource{}
+	for _, r := range comp.Spec.GoTemplate.Resources {
+		cfg[r.Name] = r
+	}
+	for i := range tmpls {
+		c := cfg[*tmpls[i].Name]
+		tmpls[i].ConnectionDetails = c.ConnectionDetails
+		tmpls[i].ReadinessChecks = c.ReadinessChecks
+	}
+
+	comp.Spec.Resources = tmpls
+	return nil
+}
+
+// observed returns the unstructured content of the supplied composite
+// resource's existing composed resources, keyed by resource name.
+func (t *APIGoTemplater) observed(ctx context.Context, cr resource.Composite) (map[string]interface{}, error) {
+	observed := map[string]interface{}{}
+	for _, ref := range cr.GetResourceReferences() {
+		// If reference does not have a name then we haven't rendered it yet.
+		if ref.Name == "" {
+			continue
+		}
+		cd := composed.New(composed.FromReference(ref))
+		err := t.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errGetComposed)
+		}
+		if name := GetCompositionResourceName(cd); name != "" {
+			observed[name] = cd.UnstructuredContent()
+		}
+	}
+	return observed, nil
+}
+
+// ParseTemplatedResources parses the supplied YAML stream of composed resources
+// into named resource templates. Empty documents are ignored.
+func ParseTemplatedResources(stream []byte) ([]v1.ComposedTemplate, error) {
+	r := kyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(stream)))
+	names := map[string]bool{}
+	tmpls := make([]v1.ComposedTemplate, 0)
+	for i := 0; ; i++ {
+		doc, err := r.Read()
+		if err == io.EOF {
+			return tmpls, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errReadGoTemplate)
+		}
+		j, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtTemplatedResource, i)
+		}
+		if s := strings.TrimSpace(string(j)); s == "null" || s == "" {
+			i--
+			continue
+		}
+
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal(j, &obj); err != nil {
+			return nil, errors.Wrapf(err, errFmtTemplatedResource, i)
+		}
+		cd := composed.New()
+		cd.SetUnstructuredContent(obj)
+		if cd.GetAPIVersion() == "" || cd.GetKind() == "" {
+			return nil, errors.Errorf(errFmtTemplatedResourceKind, i)
+		}
+		name := GetCompositionResourceName(cd)
+		if name == "" {
+			return nil, errors.Errorf(errFmtTemplatedResourceName, i, AnnotationKeyCompositionResourceName)
+		}
+		if names[name] {
+			return nil, errors.Errorf(errFmtTemplatedResourceDupeName, name)
+		}
+		names[name] = true
+
+		tmpls = append(tmpls, v1.ComposedTemplate{Name: &name, Base: runtime.RawExtension{Raw: j}})
+	}
+}
+
+func unstructuredContent(o runtime.Object) (map[string]interface{}, error) {
+	if u, ok := o.(interface{ UnstructuredContent() map[string]interface{} }); ok {
+		return u.UnstructuredContent(), nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+}
+
+// TemplateFuncs returns the helper functions available to Go templates. They
+// are a subset of the functions provided by the popular Sprig library, and
+// take their arguments in the same order.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Defaults and flow control.
+		"default":  tplDefault,
+		"empty":    tplEmpty,
+		"coalesce": tplCoalesce,
+		"required": tplRequired,
+		"ternary":  tplTernary,
+
+		// Strings.
+		"toString":   tplToString,
+		"quote":      func(v interface{}) string { return strconv.Quote(tplToString(v)) },
+		"squote":     func(v interface{}) string { return "'" + tplToString(v) + "'" },
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       tplJoin,
+		"indent":     tplIndent,
+		"nindent":    func(spaces int, s string) string { return "\n" + tplIndent(spaces, s) },
+
+		// Encoding.
+		"b64enc":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":    tplB64Dec,
+		"sha256sum": func(s string) string { return fmt.Sprintf("%x", sha256.Sum256([]byte(s))) },
+		"toJson":    tplToJSON,
+		"toYaml":    tplToYAML,
+		"fromJson":  tplFromJSON,
+
+		// Lists and dictionaries.
+		"list":   func(v ...interface{}) []interface{} { return v },
+		"dict":   tplDict,
+		"hasKey": func(d map[string]interface{}, k string) bool { _, ok := d[k]; return ok },
+
+		// Integer math.
+		"add": tplIntMath(func(a, b int64) int64 { return a + b }),
+		"sub": tplIntMath(func(a, b int64) int64 { return a - b }),
+		"mul": tplIntMath(func(a, b int64) int64 { return a * b }),
+	}
+}
+
+func tplEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() { // nolint:exhaustive // Other kinds are never empty.
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func tplDefault(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || tplEmpty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+func tplCoalesce(v ...interface{}) interface{} {
+	for _, val := range v {
+		if !tplEmpty(val) {
+			return val
+		}
+	}
+	return nil
+}
+
+func tplRequired(msg string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, errors.Errorf(errFmtTemplateFnRequired, msg)
+	}
+	if s, ok := v.(string); ok && s == "" {
+		return nil, errors.Errorf(errFmtTemplateFnRequired, msg)
+	}
+	return v, nil
+}
+
+func tplTernary(t, f interface{}, cond bool) interface{} {
+	if cond {
+		return t
+	}
+	return f
+}
+
+func tplToString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case fmt.Stringer:
+		return s.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func tplJoin(sep string, v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return tplToString(v)
+	}
+	s := make([]string, rv.Len())
+	for i := range s {
+		s[i] = tplToString(rv.Index(i).Interface())
+	}
+	return strings.Join(s, sep)
+}
+
+func tplIndent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func tplB64Dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	return string(b), err
+}
+
+func tplToJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func tplToYAML(v interface{}) (string, error) {
+	b, err := yaml.Marshal(v)
+	return strings.TrimSuffix(string(b), "\n"), err
+}
+
+func tplFromJSON(s string) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal([]byte(s), &v)
+	return v, err
+}
+
+func tplDict(v ...interface{}) map[string]interface{} {
+	d := make(map[string]interface{}, len(v)/2)
+	for i := 0; i+1 < len(v); i += 2 {
+		d[tplToString(v[i])] = v[i+1]
+	}
+	return d
+}
+
+// tplIntMath returns a template function that converts its arguments to
+// integers before calling the supplied function. Template execution fails if
+// either argument can't be converted.
+func tplIntMath(fn func(a, b int64) int64) func(a, b interface{}) (int64, error) {
+	return func(a, b interface{}) (int64, error) {
+		x, err := tplToInt64(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := tplToInt64(b)
+		if err != nil {
+			return 0, err
+		}
+		return fn(x, y), nil
+	}
+}
+
+func tplToInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case float64:
+		return int64(n), nil
+	case string:
+		i, err := strconv.ParseInt(n, 10, 64)
+		return i, errors.Wrapf(err, errFmtTemplateFnInteger, v, v)
+	}
+	return 0, errors.Errorf(errFmtTemplateFnInteger, v, v)
+}
@@ -0,0 +1,423 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+	"text/template"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestRejectInvalidGoTemplate(t *testing.T) {
+	name := "cool"
+	resources := v1.CompositionModeResources
+	gotemplate := v1.CompositionModeGoTemplate
+	wat := v1.CompositionMode("Wat")
+
+	cases := map[string]struct {
+		reason string
+		comp   *v1.Composition
+		want   error
+	}{
+		"ResourcesMode": {
+			reason: "Compositions that don't specify a mode should be valid.",
+			comp:   &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &name}}}},
+			want:   nil,
+		},
+		"ResourcesModeWithGoTemplate": {
+			reason: "Resources mode Compositions may not specify a Go template.",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Mode:       &resources,
+				GoTemplate: &v1.GoTemplate{Source: "{{ }}"},
+			}},
+			want: errors.New(errGoTemplateMode),
+		},
+		"GoTemplateModeWithoutGoTemplate": {
+			reason: "GoTemplate mode Compositions must specify a Go template.",
+			comp:   &v1.Composition{Spec: v1.CompositionSpec{Mode: &gotemplate}},
+			want:   errors.New(errGoTemplateRequired),
+		},
+		"GoTemplateModeWithResources": {
+			reason: "GoTemplate mode Compositions may not specify resources.",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Mode:       &gotemplate,
+				GoTemplate: &v1.GoTemplate{Source: "a"},
+				Resources:  []v1.ComposedTemplate{{Name: &name}},
+			}},
+			want: errors.New(errGoTemplateResources),
+		},
+		"GoTemplateModeUnparseable": {
+			reason: "GoTemplate mode Compositions must specify a Go template that can be parsed.",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Mode:       &gotemplate,
+				GoTemplate: &v1.GoTemplate{Source: "{{ nope }}"},
+			}},
+			want: errors.Wrap(errors.New(`template: :1: function "nope" not defined`), errParseGoTemplate),
+		},
+		"GoTemplateMode": {
+			reason: "GoTemplate mode Compositions that specify a valid Go template should be valid.",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				Mode:       &gotemplate,
+				GoTemplate: &v1.GoTemplate{Source: "{{ .Composite.metadata.name | upper }}"},
+			}},
+			want: nil,
+		},
+		"UnknownMode": {
+			reason: "Compositions must specify a supported mode.",
+			comp:   &v1.Composition{Spec: v1.CompositionSpec{Mode: &wat}},
+			want:   errors.Errorf(errFmtCompositionMode, wat),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectInvalidGoTemplate(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRejectInvalidGoTemplate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestParseTemplatedResources(t *testing.T) {
+	a, b := "a", "b"
+
+	type want struct {
+		t   []v1.ComposedTemplate
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		stream string
+		want   want
+	}{
+		"Empty": {
+			reason: "An empty stream should produce no templates.",
+			stream: "\n---\n",
+			want: want{
+				t: []v1.ComposedTemplate{},
+			},
+		},
+		"Named": {
+			reason: "Each named resource should produce a named template.",
+			stream: `
+---
+apiVersion: v
+kind: K
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: a
+---
+apiVersion: v
+kind: K
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: b
+`,
+			want: want{
+				t: []v1.ComposedTemplate{
+					{Name: &a, Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"K","metadata":{"annotations":{"crossplane.io/composition-resource-name":"a"}}}`)}},
+					{Name: &b, Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"K","metadata":{"annotations":{"crossplane.io/composition-resource-name":"b"}}}`)}},
+				},
+			},
+		},
+		"MissingKind": {
+			reason: "Resources must specify an apiVersion and kind.",
+			stream: "metadata: {}",
+			want: want{
+				err: errors.Errorf(errFmtTemplatedResourceKind, 0),
+			},
+		},
+		"Anonymous": {
+			reason: "Resources must be named.",
+			stream: "apiVersion: v\nkind: K",
+			want: want{
+				err: errors.Errorf(errFmtTemplatedResourceName, 0, AnnotationKeyCompositionResourceName),
+			},
+		},
+		"DuplicateName": {
+			reason: "Resource names must be unique.",
+			stream: `
+apiVersion: v
+kind: K
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: a
+---
+apiVersion: v
+kind: K
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: a
+`,
+			want: want{
+				err: errors.Errorf(errFmtTemplatedResourceDupeName, "a"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseTemplatedResources([]byte(tc.stream))
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nParseTemplatedResources(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.t, got); diff != "" {
+				t.Errorf("\n%s\nParseTemplatedResources(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPIGoTemplater(t *testing.T) {
+	gotemplate := v1.CompositionModeGoTemplate
+	bucket := "bucket"
+
+	xr := func() *composite.Unstructured {
+		cr := composite.New()
+		_ = fieldpath.Pave(cr.Object).SetValue("spec.region", "us-west-2")
+		cr.SetResourceReferences([]corev1.ObjectReference{{APIVersion: "v", Kind: "Bucket", Name: "cool-bucket"}})
+		return cr
+	}
+	source := `
+apiVersion: v
+kind: Bucket
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: bucket
+spec:
+  region: {{ .Composite.spec.region | quote }}
+  arn: {{ .Observed.bucket.status.arn | default "unknown" | quote }}
+`
+	readiness := []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeNone}}
+
+	type args struct {
+		kube client.Reader
+		cr   resource.Composite
+		comp *v1.Composition
+	}
+	type want struct {
+		comp *v1.Composition
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ResourcesMode": {
+			reason: "We should not modify Compositions that aren't GoTemplate mode Compositions.",
+			args: args{
+				cr:   xr(),
+				comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{}}}},
+			},
+			want: want{
+				comp: &v1.Composition{Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{{}}}},
+			},
+		},
+		"GetObservedError": {
+			reason: "We should return any error encountered while getting observed composed resources.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cr:   xr(),
+				comp: &v1.Composition{Spec: v1.CompositionSpec{Mode: &gotemplate, GoTemplate: &v1.GoTemplate{Source: source}}},
+			},
+			want: want{
+				comp: &v1.Composition{Spec: v1.CompositionSpec{Mode: &gotemplate, GoTemplate: &v1.GoTemplate{Source: source}}},
+				err:  errors.Wrap(errBoom, errGetComposed),
+			},
+		},
+		"Success": {
+			reason: "We should render resource templates using the composite and observed composed resources.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					cd := obj.(*composed.Unstructured)
+					SetCompositionResourceName(cd, "bucket")
+					return fieldpath.Pave(cd.Object).SetValue("status.arn", "arn:cool")
+				})},
+				cr: xr(),
+				comp: &v1.Composition{Spec: v1.CompositionSpec{Mode: &gotemplate, GoTemplate: &v1.GoTemplate{
+					Source:    source,
+					Resources: []v1.TemplatedResource{{Name: "bucket", ReadinessChecks: readiness}},
+				}}},
+			},
+			want: want{
+				comp: &v1.Composition{Spec: v1.CompositionSpec{
+					Mode: &gotemplate,
+					GoTemplate: &v1.GoTemplate{
+						Source:    source,
+						Resources: []v1.TemplatedResource{{Name: "bucket", ReadinessChecks: readiness}},
+					},
+					Resources: []v1.ComposedTemplate{{
+						Name:            &bucket,
+						Base:            runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"Bucket","metadata":{"annotations":{"crossplane.io/composition-resource-name":"bucket"}},"spec":{"arn":"arn:cool","region":"us-west-2"}}`)},
+						ReadinessChecks: readiness,
+					}},
+				}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tp := NewAPIGoTemplater(tc.args.kube)
+			err := tp.RenderTemplates(context.Background(), tc.args.cr, tc.args.comp)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRenderTemplates(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.comp, tc.args.comp); diff != "" {
+				t.Errorf("\n%s\nRenderTemplates(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		source string
+		data   interface{}
+		want   string
+	}{
+		"Default": {
+			reason: "The default value should be used when the given value is empty.",
+			source: `{{ .missing | default "a" }}{{ .present | default "a" }}`,
+			data:   map[string]interface{}{"present": "b"},
+			want:   "ab",
+		},
+		"Strings": {
+			reason: "String helpers should take their arguments in Sprig order.",
+			source: `{{ "cool-xr" | trimPrefix "cool-" | upper | quote }}`,
+			want:   `"XR"`,
+		},
+		"Join": {
+			reason: "Lists should be joined using the supplied separator.",
+			source: `{{ .zones | join "," }}`,
+			data:   map[string]interface{}{"zones": []interface{}{"a", "b"}},
+			want:   "a,b",
+		},
+		"ToYamlNindent": {
+			reason: "Values should be rendered as indented YAML.",
+			source: `tags:{{ .tags | toYaml | nindent 2 }}`,
+			data:   map[string]interface{}{"tags": map[string]interface{}{"a": "b", "c": "d"}},
+			want:   "tags:\n  a: b\n  c: d",
+		},
+		"Math": {
+			reason: "Integer math should work with numbers read from JSON.",
+			source: `{{ add .count 1 }}`,
+			data:   map[string]interface{}{"count": float64(2)},
+			want:   "3",
+		},
+		"Dict": {
+			reason: "Dictionaries should be rendered as JSON.",
+			source: `{{ dict "a" 1 | toJson }}`,
+			want:   `{"a":1}`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tmpl := template.Must(template.New(name).Funcs(TemplateFuncs()).Parse(tc.source))
+			got := &bytes.Buffer{}
+			if err := tmpl.Execute(got, tc.data); err != nil {
+				t.Fatalf("\n%s\nExecute(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got.String()); diff != "" {
+				t.Errorf("\n%s\nExecute(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestTplToInt64(t *testing.T) {
+	type want struct {
+		i   int64
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		v      interface{}
+		want   want
+	}{
+		"Int64": {
+			reason: "Integers should be returned unchanged.",
+			v:      int64(42),
+			want:   want{i: 42},
+		},
+		"Float64": {
+			reason: "Numbers read from JSON should be truncated to integers.",
+			v:      float64(42.7),
+			want:   want{i: 42},
+		},
+		"String": {
+			reason: "Strings containing integers should be parsed.",
+			v:      "42",
+			want:   want{i: 42},
+		},
+		"InvalidString": {
+			reason: "Strings that don't contain integers should return an error rather than zero.",
+			v:      "cool",
+			want: want{
+				err: errors.Wrapf(&strconv.NumError{Func: "ParseInt", Num: "cool", Err: strconv.ErrSyntax}, errFmtTemplateFnInteger, "cool", "cool"),
+			},
+		},
+		"Missing": {
+			reason: "A missing value should return an error rather than zero.",
+			v:      nil,
+			want: want{
+				err: errors.Errorf(errFmtTemplateFnInteger, nil, nil),
+			},
+		},
+		"Bool": {
+			reason: "Values that can't be converted should return an error rather than zero.",
+			v:      true,
+			want: want{
+				err: errors.Errorf(errFmtTemplateFnInteger, true, true),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			i, err := tplToInt64(tc.v)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ntplToInt64(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.i, i); diff != "" {
+				t.Errorf("\n%s\ntplToInt64(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,495 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+const (
+	errListXRDs            = "cannot list CompositeResourceDefinitions"
+	errListCRDs            = "cannot list CustomResourceDefinitions"
+	errFmtDeriveCRD        = "cannot derive CustomResourceDefinition from CompositeResourceDefinition %q"
+	errFmtFetchSchema      = "cannot fetch schema of %s"
+	errFmtSchemaNotDefined = "no CompositeResourceDefinition or CustomResourceDefinition defines the schema of %s"
+
+	errFmtUnknownField   = "unknown field %q"
+	errFmtUnknownFieldOf = "unknown field %q of %q"
+	errFmtNotAnObject    = "%q is of type %s, not an object"
+	errFmtNotAnArray     = "%q is of type %s, not an array"
+)
+
+// A schemaNotDefined error indicates that the schemas of some kinds of resource
+// are not (yet) defined.
+type schemaNotDefined struct {
+	kinds []string
+}
+
+func (e schemaNotDefined) Error() string {
+	return fmt.Sprintf(errFmtSchemaNotDefined, strings.Join(e.kinds, "; "))
+}
+
+// IsSchemaNotDefined returns true if the supplied error indicates that the
+// schemas of some kinds of resource are not (yet) defined.
+func IsSchemaNotDefined(err error) bool {
+	return errors.As(err, &schemaNotDefined{})
+}
+
+// A SchemaFetcher fetches the OpenAPI v3 schema of a kind of resource.
+type SchemaFetcher interface {
+	// FetchSchema returns the schema of the supplied kind of resource, or nil
+	// if the kind has no schema. It returns an error that satisfies
+	// IsSchemaNotDefined if the kind should have a schema, but its schema is
+	// not (yet) defined.
+	FetchSchema(ctx context.Context, gvk schema.GroupVersionKind) (*extv1.JSONSchemaProps, error)
+}
+
+// A SchemaFetcherFn fetches the OpenAPI v3 schema of a kind of resource.
+type SchemaFetcherFn func(ctx context.Context, gvk schema.GroupVersionKind) (*extv1.JSONSchemaProps, error)
+
+// FetchSchema returns the schema of the supplied kind of resource.
+func (fn SchemaFetcherFn) FetchSchema(ctx context.Context, gvk schema.GroupVersionKind) (*extv1.JSONSchemaProps, error) {
+	return fn(ctx, gvk)
+}
+
+// An APISchemaFetcher fetches the schemas of composite resources from their
+// CompositeResourceDefinitions, and the schemas of all other custom resources
+// from their CustomResourceDefinitions.
+type APISchemaFetcher struct {
+	client client.Reader
+}
+
+// NewAPISchemaFetcher returns a SchemaFetcher that fetches schemas from the
+// API server.
+func NewAPISchemaFetcher(c client.Reader) *APISchemaFetcher {
+	return &APISchemaFetcher{client: c}
+}
+
+// FetchSchema returns the schema of the supplied kind of resource. Built-in
+// kinds are not defined by an XRD or CRD, and have no schema. The schema of any
+// other kind is not defined until an XRD or CRD defines it.
+func (f *APISchemaFetcher) FetchSchema(ctx context.Context, gvk schema.GroupVersionKind) (*extv1.JSONSchemaProps, error) {
+	if builtIn(gvk) {
+		return nil, nil
+	}
+
+	xl := &v1.CompositeResourceDefinitionList{}
+	if err := f.client.List(ctx, xl); err != nil {
+		return nil, errors.Wrap(err, errListXRDs)
+	}
+	for i := range xl.Items {
+		xrd := &xl.Items[i]
+		if xrd.Spec.Group != gvk.Group || xrd.Spec.Names.Kind != gvk.Kind {
+			continue
+		}
+		crd, err := xcrd.ForCompositeResource(xrd)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtDeriveCRD, xrd.GetName())
+		}
+		return versionSchema(crd, gvk)
+	}
+
+	cl := &extv1.CustomResourceDefinitionList{}
+	if err := f.client.List(ctx, cl); err != nil {
+		return nil, errors.Wrap(err, errListCRDs)
+	}
+	for i := range cl.Items {
+		crd := &cl.Items[i]
+		if crd.Spec.Group == gvk.Group && crd.Spec.Names.Kind == gvk.Kind {
+			return versionSchema(crd, gvk)
+		}
+	}
+
+	return nil, schemaNotDefined{kinds: []string{gvk.String()}}
+}
+
+// builtIn returns true if the supplied kind is built into Kubernetes. The group
+// of a CRD must contain a dot, and may not be a Kubernetes group.
+func builtIn(gvk schema.GroupVersionKind) bool {
+	return !strings.Contains(gvk.Group, ".") || strings.HasSuffix(gvk.Group, ".k8s.io")
+}
+
+func versionSchema(crd *extv1.CustomResourceDefinition, gvk schema.GroupVersionKind) (*extv1.JSONSchemaProps, error) {
+	for _, v := range crd.Spec.Versions {
+		if v.Name != gvk.Version {
+			continue
+		}
+		if v.Schema == nil {
+			return nil, nil
+		}
+		return v.Schema.OpenAPIV3Schema, nil
+	}
+	return nil, schemaNotDefined{kinds: []string{gvk.String()}}
+}
+
+// A FieldPathValidator validates the field paths of a Composition.
+type FieldPathValidator interface {
+	// ValidateFieldPaths returns the field paths of the supplied Composition
+	// that do not exist in the schema of the resource they refer to. It returns
+	// an error that satisfies IsSchemaNotDefined, along with any invalid field
+	// paths it found, if the schemas of some of the resources are not (yet)
+	// defined. It returns any other error if it cannot determine whether field
+	// paths are valid.
+	ValidateFieldPaths(ctx context.Context, comp *v1.Composition) (field.ErrorList, error)
+}
+
+// A FieldPathValidatorFn validates the field paths of a Composition.
+type FieldPathValidatorFn func(ctx context.Context, comp *v1.Composition) (field.ErrorList, error)
+
+// ValidateFieldPaths of the supplied Composition.
+func (fn FieldPathValidatorFn) ValidateFieldPaths(ctx context.Context, comp *v1.Composition) (field.ErrorList, error) {
+	return fn(ctx, comp)
+}
+
+// A SchemaFieldPathValidator validates the field paths of a Composition's
+// patches, connection details, and readiness checks against the schemas of the
+// composite and composed resources they refer to. Field paths of resources
+// whose schema is not defined are not validated.
+type SchemaFieldPathValidator struct {
+	schema SchemaFetcher
+}
+
+// NewSchemaFieldPathValidator returns a FieldPathValidator that validates
+// field paths against the schemas returned by the supplied SchemaFetcher.
+func NewSchemaFieldPathValidator(f SchemaFetcher) *SchemaFieldPathValidator {
+	return &SchemaFieldPathValidator{schema: f}
+}
+
+// ValidateFieldPaths of the supplied Composition.
+func (v *SchemaFieldPathValidator) ValidateFieldPaths(ctx context.Context, comp *v1.Composition) (field.ErrorList, error) {
+	cache := map[schema.GroupVersionKind]*extv1.JSONSchemaProps{}
+	undefined := []string{}
+	fetch := func(gvk schema.GroupVersionKind) (*extv1.JSONSchemaProps, error) {
+		if s, ok := cache[gvk]; ok {
+			return s, nil
+		}
+		s, err := v.schema.FetchSchema(ctx, gvk)
+		if IsSchemaNotDefined(err) {
+			undefined = append(undefined, gvk.String())
+			err = nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtFetchSchema, gvk)
+		}
+		cache[gvk] = s
+		return s, nil
+	}
+
+	xr, err := fetch(schema.FromAPIVersionAndKind(comp.Spec.CompositeTypeRef.APIVersion, comp.Spec.CompositeTypeRef.Kind))
+	if err != nil {
+		return nil, err
+	}
+
+	cds := make([]*extv1.JSONSchemaProps, len(comp.Spec.Resources))
+	named := map[string]*extv1.JSONSchemaProps{}
+	for i, t := range comp.Spec.Resources {
+		gvk, ok := baseGVK(t.Base)
+		if !ok {
+			continue
+		}
+		if cds[i], err = fetch(gvk); err != nil {
+			return nil, err
+		}
+		if t.Name != nil {
+			named[*t.Name] = cds[i]
+		}
+	}
+
+	sets := map[string]int{}
+	for i, ps := range comp.Spec.PatchSets {
+		sets[ps.Name] = i
+	}
+
+	errs := field.ErrorList{}
+	for i, t := range comp.Spec.Resources {
+		p := field.NewPath("spec", "resources").Index(i)
+		s := patchSchemas{composite: xr, composed: cds[i], named: named}
+
+		if t.Condition != nil {
+			errs = append(errs, validateFieldPath(xr, p.Child("condition", "fromFieldPath"), t.Condition.FromFieldPath)...)
+		}
+		if t.ForEach != nil {
+			errs = append(errs, validateFieldPath(xr, p.Child("forEach", "fromFieldPath"), t.ForEach.FromFieldPath)...)
+			s.forEach = forEachSchema(xr, t.ForEach.FromFieldPath)
+		}
+
+		for j, pa := range t.Patches {
+			if pa.Type != v1.PatchTypePatchSet {
+				errs = append(errs, validatePatch(p.Child("patches").Index(j), pa, s)...)
+				continue
+			}
+			if pa.PatchSetName == nil {
+				continue
+			}
+			k, ok := sets[*pa.PatchSetName]
+			if !ok {
+				continue
+			}
+			for l, spa := range comp.Spec.PatchSets[k].Patches {
+				errs = append(errs, validatePatch(field.NewPath("spec", "patchSets").Index(k).Child("patches").Index(l), spa, s)...)
+			}
+		}
+
+		for j, cd := range t.ConnectionDetails {
+			cp := p.Child("connectionDetails").Index(j)
+			if cd.FromFieldPath != nil {
+				errs = append(errs, validateFieldPath(cds[i], cp.Child("fromFieldPath"), *cd.FromFieldPath)...)
+			}
+			if cd.Combine != nil {
+				errs = append(errs, validateCombine(cds[i], cp.Child("combine"), cd.Combine)...)
+			}
+		}
+
+		for j, rc := range t.ReadinessChecks {
+			rp := p.Child("readinessChecks").Index(j)
+			errs = append(errs, validateFieldPath(cds[i], rp.Child("fieldPath"), rc.FieldPath)...)
+			if rc.MatchIntegerFieldPath != nil {
+				errs = append(errs, validateFieldPath(cds[i], rp.Child("matchIntegerFieldPath"), *rc.MatchIntegerFieldPath)...)
+			}
+		}
+	}
+
+	if len(undefined) > 0 {
+		return errs, schemaNotDefined{kinds: undefined}
+	}
+	return errs, nil
+}
+
+// patchSchemas are the schemas of the resources a composed template's patches
+// may read from and write to.
+type patchSchemas struct {
+	composite *extv1.JSONSchemaProps
+	composed  *extv1.JSONSchemaProps
+	forEach   *extv1.JSONSchemaProps
+	named     map[string]*extv1.JSONSchemaProps
+}
+
+func validatePatch(p *field.Path, pa v1.Patch, s patchSchemas) field.ErrorList {
+	from, to := s.composite, s.composed
+	switch pa.Type {
+	case v1.PatchTypeFromCompositeFieldPath, v1.PatchTypeCombineFromComposite:
+	case v1.PatchTypeToCompositeFieldPath, v1.PatchTypeCombineToComposite:
+		from, to = s.composed, s.composite
+	case v1.PatchTypeFromComposedFieldPath:
+		from = nil
+		if pa.FromResourceName != nil {
+			from = s.named[*pa.FromResourceName]
+		}
+	case v1.PatchTypeFromForEach:
+		from = s.forEach
+	default:
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	if pa.FromFieldPath != nil {
+		errs = append(errs, validateFieldPath(from, p.Child("fromFieldPath"), *pa.FromFieldPath)...)
+	}
+	if pa.Combine != nil {
+		errs = append(errs, validateCombine(from, p.Child("combine"), pa.Combine)...)
+	}
+
+	// The patch writes to its fromFieldPath if no toFieldPath is specified.
+	switch {
+	case pa.ToFieldPath != nil:
+		errs = append(errs, validateFieldPath(to, p.Child("toFieldPath"), *pa.ToFieldPath)...)
+	case pa.FromFieldPath != nil && pa.Type != v1.PatchTypeFromForEach:
+		errs = append(errs, validateFieldPath(to, p.Child("toFieldPath"), *pa.FromFieldPath)...)
+	}
+
+	return errs
+}
+
+func validateCombine(s *extv1.JSONSchemaProps, p *field.Path, c *v1.Combine) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, cv := range c.Variables {
+		errs = append(errs, validateFieldPath(s, p.Child("variables").Index(i).Child("fromFieldPath"), cv.FromFieldPath)...)
+	}
+	return errs
+}
+
+// validateFieldPath returns an error if the supplied field path cannot exist
+// in a resource of the supplied schema. Nothing is validated if the schema is
+// unknown (i.e. nil), or the field path is empty.
+func validateFieldPath(s *extv1.JSONSchemaProps, p *field.Path, path string) field.ErrorList {
+	if s == nil || path == "" {
+		return nil
+	}
+	segments, err := fieldpath.Parse(path)
+	if err != nil {
+		return field.ErrorList{field.Invalid(p, path, err.Error())}
+	}
+	if _, err := schemaAt(s, segments); err != nil {
+		return field.ErrorList{field.Invalid(p, path, err.Error())}
+	}
+	return nil
+}
+
+// forEachSchema returns the schema of the object FromForEach patches read from,
+// given the schema of the composite resource and the path of the array its
+// template is expanded for.
+func forEachSchema(xr *extv1.JSONSchemaProps, path string) *extv1.JSONSchemaProps {
+	if xr == nil {
+		return nil
+	}
+	segments, err := fieldpath.Parse(path)
+	if err != nil {
+		return nil
+	}
+	s, err := schemaAt(xr, segments)
+	if err != nil || s == nil || s.Items == nil || s.Items.Schema == nil {
+		return nil
+	}
+	return &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"element": *s.Items.Schema,
+			"index":   {Type: "integer"},
+		},
+	}
+}
+
+// objectMeta is the schema of the fields all resources have in common. CRDs
+// typically omit (or don't fully specify) these fields.
+var objectMeta = map[string]extv1.JSONSchemaProps{
+	"apiVersion": {Type: "string"},
+	"kind":       {Type: "string"},
+	"metadata": {
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"name":                       {Type: "string"},
+			"generateName":               {Type: "string"},
+			"namespace":                  {Type: "string"},
+			"selfLink":                   {Type: "string"},
+			"uid":                        {Type: "string"},
+			"resourceVersion":            {Type: "string"},
+			"generation":                 {Type: "integer"},
+			"creationTimestamp":          {Type: "string"},
+			"deletionTimestamp":          {Type: "string"},
+			"deletionGracePeriodSeconds": {Type: "integer"},
+			"clusterName":                {Type: "string"},
+			"labels":                     stringMap(),
+			"annotations":                stringMap(),
+			"finalizers":                 {Type: "array", Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{Type: "string"}}},
+			"ownerReferences":            unknownArray(),
+			"managedFields":              unknownArray(),
+		},
+	},
+}
+
+func stringMap() extv1.JSONSchemaProps {
+	return extv1.JSONSchemaProps{Type: "object", AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Schema: &extv1.JSONSchemaProps{Type: "string"}}}
+}
+
+func unknownArray() extv1.JSONSchemaProps {
+	preserve := true
+	return extv1.JSONSchemaProps{Type: "array", Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: &preserve}}}
+}
+
+// schemaAt returns the schema of the field at the supplied segments of the
+// supplied schema, or an error if the field cannot exist. It returns a nil
+// schema if the field may exist but its schema is unknown, for example because
+// a parent object preserves unknown fields.
+func schemaAt(s *extv1.JSONSchemaProps, segments fieldpath.Segments) (*extv1.JSONSchemaProps, error) {
+	for i, seg := range segments {
+		if s.XPreserveUnknownFields != nil && *s.XPreserveUnknownFields {
+			return nil, nil
+		}
+
+		parent := segments[:i]
+		switch seg.Type {
+		case fieldpath.SegmentField:
+			if i == 0 || s.XEmbeddedResource {
+				if m, ok := objectMeta[seg.Field]; ok {
+					s = &m
+					continue
+				}
+			}
+			if p, ok := s.Properties[seg.Field]; ok {
+				s = &p
+				continue
+			}
+			if ap := s.AdditionalProperties; ap != nil {
+				if ap.Schema != nil {
+					s = ap.Schema
+					continue
+				}
+				if ap.Allows {
+					return nil, nil
+				}
+			}
+			if s.Type == "object" || len(s.Properties) > 0 {
+				if len(parent) == 0 {
+					return nil, errors.Errorf(errFmtUnknownField, seg.Field)
+				}
+				return nil, errors.Errorf(errFmtUnknownFieldOf, seg.Field, parent.String())
+			}
+			if t := typeOf(s); t != "" {
+				return nil, errors.Errorf(errFmtNotAnObject, parent.String(), t)
+			}
+			return nil, nil
+		case fieldpath.SegmentIndex:
+			if it := s.Items; it != nil {
+				if it.Schema != nil {
+					s = it.Schema
+					continue
+				}
+				if int(seg.Index) < len(it.JSONSchemas) {
+					s = &it.JSONSchemas[seg.Index]
+					continue
+				}
+				return nil, nil
+			}
+			if t := typeOf(s); t != "" && t != "array" {
+				return nil, errors.Errorf(errFmtNotAnArray, parent.String(), t)
+			}
+			return nil, nil
+		}
+	}
+	return s, nil
+}
+
+func typeOf(s *extv1.JSONSchemaProps) string {
+	if s.XIntOrString {
+		return "int-or-string"
+	}
+	return s.Type
+}
+
+func baseGVK(b runtime.RawExtension) (schema.GroupVersionKind, bool) {
+	t := &metav1.TypeMeta{}
+	if err := json.Unmarshal(b.Raw, t); err != nil || t.APIVersion == "" || t.Kind == "" {
+		return schema.GroupVersionKind{}, false
+	}
+	return t.GroupVersionKind(), true
+}
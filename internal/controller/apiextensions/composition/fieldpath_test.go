@@ -0,0 +1,419 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestSchemaAt(t *testing.T) {
+	preserve := true
+	s := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"spec": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"region": {Type: "string"},
+					"port":   {XIntOrString: true},
+					"tags": {
+						Type:                 "object",
+						AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Schema: &extv1.JSONSchemaProps{Type: "string"}},
+					},
+					"rules": {
+						Type:  "array",
+						Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{Type: "string"}},
+					},
+					"config": {Type: "object", XPreserveUnknownFields: &preserve},
+				},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		reason string
+		path   string
+		want   field.ErrorList
+	}{
+		"Property": {
+			reason: "A path to a property defined by the schema should be valid.",
+			path:   "spec.region",
+		},
+		"ObjectMeta": {
+			reason: "A path to object metadata should be valid even though the schema does not define it.",
+			path:   "metadata.labels[example.org/cool]",
+		},
+		"AdditionalProperties": {
+			reason: "A path to any key of an object with additional properties should be valid.",
+			path:   "spec.tags.cool",
+		},
+		"ArrayIndex": {
+			reason: "A path to an element of an array should be valid.",
+			path:   "spec.rules[0]",
+		},
+		"PreserveUnknownFields": {
+			reason: "Any path within an object that preserves unknown fields should be valid.",
+			path:   "spec.config.anything[0].goes",
+		},
+		"UnknownField": {
+			reason: "A path to a property not defined by the schema should be invalid.",
+			path:   "spec.regoin",
+			want:   field.ErrorList{field.Invalid(field.NewPath("f"), "spec.regoin", `unknown field "regoin" of "spec"`)},
+		},
+		"UnknownMetadataField": {
+			reason: "A path to an object metadata field that does not exist should be invalid.",
+			path:   "metadata.lables",
+			want:   field.ErrorList{field.Invalid(field.NewPath("f"), "metadata.lables", `unknown field "lables" of "metadata"`)},
+		},
+		"UnknownRootField": {
+			reason: "A path to a top-level property not defined by the schema should be invalid.",
+			path:   "spek",
+			want:   field.ErrorList{field.Invalid(field.NewPath("f"), "spek", `unknown field "spek"`)},
+		},
+		"FieldOfScalar": {
+			reason: "A path to a field of a scalar should be invalid.",
+			path:   "spec.port.number",
+			want:   field.ErrorList{field.Invalid(field.NewPath("f"), "spec.port.number", `"spec.port" is of type int-or-string, not an object`)},
+		},
+		"IndexOfObject": {
+			reason: "A path to an index of an object should be invalid.",
+			path:   "spec[0]",
+			want:   field.ErrorList{field.Invalid(field.NewPath("f"), "spec[0]", `"spec" is of type object, not an array`)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := validateFieldPath(s, field.NewPath("f"), tc.path)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nvalidateFieldPath(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSchemaFieldPathValidator(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	xrGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XDatabase"}
+	cdGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Database"}
+
+	xr := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"spec": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"size": {Type: "string"},
+					"users": {
+						Type: "array",
+						Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{
+							Type:       "object",
+							Properties: map[string]extv1.JSONSchemaProps{"name": {Type: "string"}},
+						}},
+					},
+				},
+			},
+			"status": {
+				Type:       "object",
+				Properties: map[string]extv1.JSONSchemaProps{"endpoint": {Type: "string"}},
+			},
+		},
+	}
+	cd := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"spec": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"storageGB": {Type: "integer"},
+					"user":      {Type: "string"},
+				},
+			},
+			"status": {
+				Type:       "object",
+				Properties: map[string]extv1.JSONSchemaProps{"address": {Type: "string"}},
+			},
+		},
+	}
+
+	fetch := SchemaFetcherFn(func(_ context.Context, gvk schema.GroupVersionKind) (*extv1.JSONSchemaProps, error) {
+		switch gvk {
+		case xrGVK:
+			return xr, nil
+		case cdGVK:
+			return cd, nil
+		}
+		return nil, nil
+	})
+
+	base := runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Database"}`)}
+	unknown := runtime.RawExtension{Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap"}`)}
+
+	type want struct {
+		errs field.ErrorList
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		fetch  SchemaFetcher
+		comp   *v1.Composition
+		want   want
+	}{
+		"FetchSchemaError": {
+			reason: "We should return any error encountered while fetching a schema.",
+			fetch: SchemaFetcherFn(func(_ context.Context, _ schema.GroupVersionKind) (*extv1.JSONSchemaProps, error) {
+				return nil, errBoom
+			}),
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				CompositeTypeRef: v1.TypeReferenceTo(xrGVK),
+			}},
+			want: want{
+				err: errors.Wrapf(errBoom, errFmtFetchSchema, xrGVK),
+			},
+		},
+		"ValidFieldPaths": {
+			reason: "We should return no errors if all field paths are valid.",
+			fetch:  fetch,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				CompositeTypeRef: v1.TypeReferenceTo(xrGVK),
+				Resources: []v1.ComposedTemplate{{
+					Base: base,
+					Patches: []v1.Patch{
+						{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.StringPtr("spec.size"), ToFieldPath: pointer.StringPtr("spec.storageGB")},
+						{Type: v1.PatchTypeToCompositeFieldPath, FromFieldPath: pointer.StringPtr("status.address"), ToFieldPath: pointer.StringPtr("status.endpoint")},
+						{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.StringPtr("metadata.labels")},
+					},
+					ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.StringPtr("address"), FromFieldPath: pointer.StringPtr("status.address")}},
+					ReadinessChecks:   []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeNonEmpty, FieldPath: "status.address"}},
+				}},
+			}},
+			want: want{
+				errs: field.ErrorList{},
+			},
+		},
+		"InvalidFieldPaths": {
+			reason: "We should return an error for each invalid field path.",
+			fetch:  fetch,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				CompositeTypeRef: v1.TypeReferenceTo(xrGVK),
+				PatchSets: []v1.PatchSet{{
+					Name:    "common",
+					Patches: []v1.Patch{{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.StringPtr("spec.sise"), ToFieldPath: pointer.StringPtr("spec.storageGB")}},
+				}},
+				Resources: []v1.ComposedTemplate{{
+					Base: base,
+					Patches: []v1.Patch{
+						{Type: v1.PatchTypePatchSet, PatchSetName: pointer.StringPtr("common")},
+						{Type: v1.PatchTypeToCompositeFieldPath, FromFieldPath: pointer.StringPtr("status.adress"), ToFieldPath: pointer.StringPtr("status.endpoint")},
+					},
+					ConnectionDetails: []v1.ConnectionDetail{{Name: pointer.StringPtr("address"), FromFieldPath: pointer.StringPtr("status.adress")}},
+					ReadinessChecks:   []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeNonEmpty, FieldPath: "status.adress"}},
+				}},
+			}},
+			want: want{
+				errs: field.ErrorList{
+					field.Invalid(field.NewPath("spec", "patchSets").Index(0).Child("patches").Index(0).Child("fromFieldPath"), "spec.sise", `unknown field "sise" of "spec"`),
+					field.Invalid(field.NewPath("spec", "resources").Index(0).Child("patches").Index(1).Child("fromFieldPath"), "status.adress", `unknown field "adress" of "status"`),
+					field.Invalid(field.NewPath("spec", "resources").Index(0).Child("connectionDetails").Index(0).Child("fromFieldPath"), "status.adress", `unknown field "adress" of "status"`),
+					field.Invalid(field.NewPath("spec", "resources").Index(0).Child("readinessChecks").Index(0).Child("fieldPath"), "status.adress", `unknown field "adress" of "status"`),
+				},
+			},
+		},
+		"ForEach": {
+			reason: "FromForEach patches should be validated against the schema of the array's elements.",
+			fetch:  fetch,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				CompositeTypeRef: v1.TypeReferenceTo(xrGVK),
+				Resources: []v1.ComposedTemplate{{
+					Name:    pointer.StringPtr("user"),
+					Base:    base,
+					ForEach: &v1.ComposedTemplateForEach{FromFieldPath: "spec.users"},
+					Patches: []v1.Patch{
+						{Type: v1.PatchTypeFromForEach, FromFieldPath: pointer.StringPtr("element.name"), ToFieldPath: pointer.StringPtr("spec.user")},
+						{Type: v1.PatchTypeFromForEach, FromFieldPath: pointer.StringPtr("element.nmae"), ToFieldPath: pointer.StringPtr("spec.user")},
+					},
+				}},
+			}},
+			want: want{
+				errs: field.ErrorList{
+					field.Invalid(field.NewPath("spec", "resources").Index(0).Child("patches").Index(1).Child("fromFieldPath"), "element.nmae", `unknown field "nmae" of "element"`),
+				},
+			},
+		},
+		"UndefinedSchema": {
+			reason: "We should validate what we can, and return an error, if the schemas of some resources are not defined.",
+			fetch: SchemaFetcherFn(func(_ context.Context, gvk schema.GroupVersionKind) (*extv1.JSONSchemaProps, error) {
+				if gvk == xrGVK {
+					return xr, nil
+				}
+				return nil, schemaNotDefined{kinds: []string{gvk.String()}}
+			}),
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				CompositeTypeRef: v1.TypeReferenceTo(xrGVK),
+				Resources: []v1.ComposedTemplate{{
+					Base: base,
+					Patches: []v1.Patch{
+						{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.StringPtr("spec.sise"), ToFieldPath: pointer.StringPtr("spec.anything")},
+					},
+				}},
+			}},
+			want: want{
+				errs: field.ErrorList{
+					field.Invalid(field.NewPath("spec", "resources").Index(0).Child("patches").Index(0).Child("fromFieldPath"), "spec.sise", `unknown field "sise" of "spec"`),
+				},
+				err: schemaNotDefined{kinds: []string{cdGVK.String()}},
+			},
+		},
+		"UnknownSchema": {
+			reason: "We should not validate field paths of resources that have no schema.",
+			fetch:  fetch,
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				CompositeTypeRef: v1.TypeReferenceTo(xrGVK),
+				Resources: []v1.ComposedTemplate{{
+					Base: unknown,
+					Patches: []v1.Patch{
+						{Type: v1.PatchTypeFromCompositeFieldPath, FromFieldPath: pointer.StringPtr("spec.size"), ToFieldPath: pointer.StringPtr("data.anything")},
+					},
+				}},
+			}},
+			want: want{
+				errs: field.ErrorList{},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v := NewSchemaFieldPathValidator(tc.fetch)
+			errs, err := v.ValidateFieldPaths(context.Background(), tc.comp)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nValidateFieldPaths(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.errs, errs); diff != "" {
+				t.Errorf("\n%s\nValidateFieldPaths(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPISchemaFetcher(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	gvk := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Database"}
+	s := &extv1.JSONSchemaProps{Type: "object"}
+
+	type want struct {
+		s   *extv1.JSONSchemaProps
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		c      client.Reader
+		gvk    *schema.GroupVersionKind
+		want   want
+	}{
+		"BuiltIn": {
+			reason: "We should return a nil schema for built-in kinds without listing XRDs or CRDs.",
+			c:      &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+			gvk:    &schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			want:   want{},
+		},
+		"ListXRDsError": {
+			reason: "We should return any error encountered while listing XRDs.",
+			c:      &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+			want: want{
+				err: errors.Wrap(errBoom, errListXRDs),
+			},
+		},
+		"CRD": {
+			reason: "We should return the schema of the matching version of the matching CRD.",
+			c: &test.MockClient{MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+				if l, ok := obj.(*extv1.CustomResourceDefinitionList); ok {
+					l.Items = []extv1.CustomResourceDefinition{{Spec: extv1.CustomResourceDefinitionSpec{
+						Group: gvk.Group,
+						Names: extv1.CustomResourceDefinitionNames{Kind: gvk.Kind},
+						Versions: []extv1.CustomResourceDefinitionVersion{
+							{Name: "v1beta1"},
+							{Name: "v1", Schema: &extv1.CustomResourceValidation{OpenAPIV3Schema: s}},
+						},
+					}}}
+				}
+				return nil
+			})},
+			want: want{
+				s: s,
+			},
+		},
+		"VersionNotDefined": {
+			reason: "We should return an error if the matching CRD does not define the version.",
+			c: &test.MockClient{MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+				if l, ok := obj.(*extv1.CustomResourceDefinitionList); ok {
+					l.Items = []extv1.CustomResourceDefinition{{Spec: extv1.CustomResourceDefinitionSpec{
+						Group:    gvk.Group,
+						Names:    extv1.CustomResourceDefinitionNames{Kind: gvk.Kind},
+						Versions: []extv1.CustomResourceDefinitionVersion{{Name: "v1beta1"}},
+					}}}
+				}
+				return nil
+			})},
+			want: want{
+				err: schemaNotDefined{kinds: []string{gvk.String()}},
+			},
+		},
+		"NotDefined": {
+			reason: "We should return an error if no XRD or CRD defines the kind.",
+			c:      &test.MockClient{MockList: test.NewMockListFn(nil)},
+			want: want{
+				err: schemaNotDefined{kinds: []string{gvk.String()}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := NewAPISchemaFetcher(tc.c)
+			in := gvk
+			if tc.gvk != nil {
+				in = *tc.gvk
+			}
+			got, err := f.FetchSchema(context.Background(), in)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFetchSchema(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.s, got); diff != "" {
+				t.Errorf("\n%s\nFetchSchema(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
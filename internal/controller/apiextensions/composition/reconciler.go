@@ -0,0 +1,240 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package composition creates composition revisions.
+package composition
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	kcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+)
+
+const (
+	shortWait = 30 * time.Second
+
+	timeout        = 2 * time.Minute
+	maxConcurrency = 5
+
+	errGet          = "cannot get Composition"
+	errListRevs     = "cannot list CompositionRevisions"
+	errCreateRev    = "cannot create CompositionRevision"
+	errValidate     = "cannot validate Composition field paths"
+	errUpdateStatus = "cannot update Composition status"
+)
+
+// Event reasons.
+const (
+	reasonCreateRev event.Reason = "CreateRevision"
+	reasonValidate  event.Reason = "ValidateFieldPaths"
+)
+
+// Setup adds a controller that reconciles Compositions by creating new
+// CompositionRevisions for each revision of the Composition's spec, and by
+// validating the Composition's field paths. Field paths are validated again
+// whenever the XRD or CRD that defines one of a Composition's resources
+// changes.
+func Setup(mgr ctrl.Manager, log logging.Logger) error {
+	name := "revisions/" + strings.ToLower(v1.CompositionGroupKind)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		For(&v1.Composition{}).
+		Owns(&v1.CompositionRevision{}).
+		Watches(&source.Kind{Type: &v1.CompositeResourceDefinition{}}, &EnqueueRequestForCompositions{client: mgr.GetClient()}).
+		Watches(&source.Kind{Type: &extv1.CustomResourceDefinition{}}, &EnqueueRequestForCompositions{client: mgr.GetClient()}).
+		WithOptions(kcontroller.Options{MaxConcurrentReconciles: maxConcurrency}).
+		Complete(NewReconciler(mgr,
+			WithLogger(log.WithValues("controller", name)),
+			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+}
+
+// ReconcilerOption is used to configure the Reconciler.
+type ReconcilerOption func(*Reconciler)
+
+// WithLogger specifies how the Reconciler should log messages.
+func WithLogger(log logging.Logger) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.log = log
+	}
+}
+
+// WithRecorder specifies how the Reconciler should record Kubernetes events.
+func WithRecorder(er event.Recorder) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.record = er
+	}
+}
+
+// WithClient specifies how the Reconciler should interact with the Kubernetes
+// API.
+func WithClient(c client.Client) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.client = c
+	}
+}
+
+// WithFieldPathValidator specifies how the Reconciler should validate the
+// field paths of a Composition.
+func WithFieldPathValidator(v FieldPathValidator) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.fieldPaths = v
+	}
+}
+
+// NewReconciler returns a Reconciler of Compositions.
+func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
+	r := &Reconciler{
+		client:     mgr.GetClient(),
+		fieldPaths: NewSchemaFieldPathValidator(NewAPISchemaFetcher(mgr.GetClient())),
+
+		log:    logging.NewNopLogger(),
+		record: event.NewNopRecorder(),
+	}
+
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
+
+// A Reconciler reconciles Compositions by creating new CompositionRevisions.
+// Revisions are never garbage collected while their Composition exists; a
+// Composition accumulates one revision for each change to its spec.
+type Reconciler struct {
+	client     client.Client
+	fieldPaths FieldPathValidator
+
+	log    logging.Logger
+	record event.Recorder
+}
+
+// Reconcile a Composition.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("request", req)
+	log.Debug("Reconciling")
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	comp := &v1.Composition{}
+	if err := r.client.Get(ctx, req.NamespacedName, comp); err != nil {
+		log.Debug(errGet, "error", err)
+		return reconcile.Result{}, errors.Wrap(resource.IgnoreNotFound(err), errGet)
+	}
+
+	log = log.WithValues(
+		"uid", comp.GetUID(),
+		"version", comp.GetResourceVersion(),
+		"name", comp.GetName(),
+	)
+
+	if meta.WasDeleted(comp) {
+		// There's nothing to do if our Composition is being deleted. Any
+		// revisions we created will be garbage collected by Kubernetes.
+		return reconcile.Result{Requeue: false}, nil
+	}
+
+	// We requeue until the schemas of all of our resources are defined, so
+	// that we can validate all of our field paths. We're also queued whenever
+	// an XRD or CRD that defines one of our resources changes.
+	result := reconcile.Result{Requeue: false}
+
+	invalid, err := r.fieldPaths.ValidateFieldPaths(ctx, comp)
+	undefined := IsSchemaNotDefined(err)
+	if err != nil && !undefined {
+		log.Debug(errValidate, "error", err)
+		r.record.Event(comp, event.Warning(reasonValidate, errors.Wrap(err, errValidate)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
+	c := v1.ValidFieldPaths()
+	switch {
+	case len(invalid) > 0:
+		c = v1.InvalidFieldPaths(invalid.ToAggregate())
+	case undefined:
+		c = v1.SchemaUnknown(err)
+	}
+	if undefined {
+		log.Debug("Cannot validate all field paths", "error", err)
+		result = reconcile.Result{RequeueAfter: shortWait}
+	}
+	if !comp.Status.GetCondition(v1.TypeFieldPathsValid).Equal(c) {
+		comp.Status.SetConditions(c)
+		if err := r.client.Status().Update(ctx, comp); err != nil {
+			log.Debug(errUpdateStatus, "error", err)
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+	}
+
+	revs, err := composite.ListRevisions(ctx, r.client, comp.GetName())
+	if err != nil {
+		log.Debug(errListRevs, "error", err)
+		r.record.Event(comp, event.Warning(reasonCreateRev, errors.Wrap(err, errListRevs)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
+	// Revisions are immutable. If our Composition was reverted to the spec of
+	// an earlier revision we create a new revision with that spec, so that
+	// the latest revision always reflects our Composition's current spec.
+	hash := SpecHash(comp)
+	next := int64(1)
+	if latest := composite.LatestRevision(revs); latest != nil {
+		// We already have a revision of our current spec, and it's the
+		// latest revision. There's nothing to do.
+		if latest.GetLabels()[v1.LabelCompositionSpecHash] == hash {
+			return result, nil
+		}
+		next = latest.Spec.Revision + 1
+	}
+
+	rev := NewCompositionRevision(comp, next, hash)
+	err = r.client.Create(ctx, rev)
+	if kerrors.IsAlreadyExists(err) {
+		// Revision names are derived from their spec hash and revision
+		// number, so this revision was created by an earlier reconcile that
+		// our cache doesn't yet reflect. We requeue to list revisions again.
+		log.Debug("CompositionRevision already exists", "revision", rev.Spec.Revision)
+		return reconcile.Result{Requeue: true}, nil
+	}
+	if err != nil {
+		log.Debug(errCreateRev, "error", err)
+		r.record.Event(comp, event.Warning(reasonCreateRev, errors.Wrap(err, errCreateRev)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
+	log.Debug("Created new CompositionRevision", "revision", rev.Spec.Revision)
+	r.record.Event(comp, event.Normal(reasonCreateRev, "Created new CompositionRevision"))
+	return result, nil
+}
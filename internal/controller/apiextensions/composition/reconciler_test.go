@@ -0,0 +1,380 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestReconcile(t *testing.T) {
+	errBoom := errors.New("boom")
+	now := metav1.Now()
+
+	comp := &v1.Composition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cool",
+			Labels: map[string]string{"cool": "very"},
+		},
+		Spec: v1.CompositionSpec{
+			CompositeTypeRef: v1.TypeReference{APIVersion: "v", Kind: "k"},
+		},
+	}
+	comp.Status.SetConditions(v1.ValidFieldPaths())
+	hash := SpecHash(comp)
+
+	getComp := func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+		comp.DeepCopyInto(obj.(*v1.Composition))
+		return nil
+	}
+	listRevs := func(revs ...v1.CompositionRevision) test.MockListFn {
+		return func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+			obj.(*v1.CompositionRevisionList).Items = revs
+			return nil
+		}
+	}
+	rev := func(num int64, hash string) v1.CompositionRevision {
+		return v1.CompositionRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.LabelCompositionName:     comp.GetName(),
+					v1.LabelCompositionSpecHash: hash,
+				},
+			},
+			Spec: v1.CompositionRevisionSpec{Revision: num},
+		}
+	}
+
+	validPaths := FieldPathValidatorFn(func(_ context.Context, _ *v1.Composition) (field.ErrorList, error) {
+		return nil, nil
+	})
+	invalid := field.ErrorList{field.Invalid(field.NewPath("spec"), "bad", "boom")}
+	invalidPaths := FieldPathValidatorFn(func(_ context.Context, _ *v1.Composition) (field.ErrorList, error) {
+		return invalid, nil
+	})
+
+	type args struct {
+		mgr  manager.Manager
+		opts []ReconcilerOption
+	}
+	type want struct {
+		r   reconcile.Result
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"CompositionNotFound": {
+			reason: "We should not return an error if the Composition was not found.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"GetCompositionError": {
+			reason: "We should return any other error encountered while getting a Composition.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(errBoom),
+					}),
+				},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGet),
+			},
+		},
+		"CompositionDeleted": {
+			reason: "We should return without requeueing if the Composition was deleted.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+							obj.SetDeletionTimestamp(&now)
+							return nil
+						}),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"ValidateFieldPathsError": {
+			reason: "We should requeue after a short wait if we encounter an error validating field paths.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(FieldPathValidatorFn(func(_ context.Context, _ *v1.Composition) (field.ErrorList, error) {
+						return nil, errBoom
+					})),
+					WithClient(&test.MockClient{
+						MockGet: test.MockGetFn(getComp),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"UpdateStatusError": {
+			reason: "We should requeue after a short wait if we encounter an error updating our status.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(invalidPaths),
+					WithClient(&test.MockClient{
+						MockGet:          test.MockGetFn(getComp),
+						MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"InvalidFieldPaths": {
+			reason: "We should report invalid field paths using a status condition, then carry on.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(invalidPaths),
+					WithClient(&test.MockClient{
+						MockGet: test.MockGetFn(getComp),
+						MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+							want := v1.InvalidFieldPaths(invalid.ToAggregate())
+							got := obj.(*v1.Composition).Status.GetCondition(v1.TypeFieldPathsValid)
+							if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(xpv1.Condition{}, "LastTransitionTime")); diff != "" {
+								t.Errorf("Status().Update(...): -want, +got:\n%s", diff)
+							}
+							return nil
+						}),
+						MockList: listRevs(rev(1, hash)),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"SchemaUnknown": {
+			reason: "We should report that schemas are unknown using a status condition, carry on, then requeue after a short wait.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(FieldPathValidatorFn(func(_ context.Context, _ *v1.Composition) (field.ErrorList, error) {
+						return nil, schemaNotDefined{kinds: []string{"example.org/v1, Kind=Database"}}
+					})),
+					WithClient(&test.MockClient{
+						MockGet: test.MockGetFn(getComp),
+						MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+							want := v1.SchemaUnknown(schemaNotDefined{kinds: []string{"example.org/v1, Kind=Database"}})
+							got := obj.(*v1.Composition).Status.GetCondition(v1.TypeFieldPathsValid)
+							if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(xpv1.Condition{}, "LastTransitionTime")); diff != "" {
+								t.Errorf("Status().Update(...): -want, +got:\n%s", diff)
+							}
+							return nil
+						}),
+						MockList: listRevs(rev(1, hash)),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ListRevisionsError": {
+			reason: "We should requeue after a short wait if we encounter an error listing CompositionRevisions.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(validPaths),
+					WithClient(&test.MockClient{
+						MockGet:  test.MockGetFn(getComp),
+						MockList: test.NewMockListFn(errBoom),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"LatestRevisionIsCurrent": {
+			reason: "We should not create or update a revision if the latest revision matches our Composition's spec.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(validPaths),
+					WithClient(&test.MockClient{
+						MockGet:  test.MockGetFn(getComp),
+						MockList: listRevs(rev(1, "old"), rev(2, hash)),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"RevertToEarlierRevision": {
+			reason: "We should create a new revision, rather than update an older one, if an older revision matches our Composition's spec.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(validPaths),
+					WithClient(&test.MockClient{
+						MockGet:    test.MockGetFn(getComp),
+						MockList:   listRevs(rev(1, hash), rev(2, "new")),
+						MockUpdate: test.NewMockUpdateFn(errBoom),
+						MockCreate: test.NewMockCreateFn(nil, func(obj client.Object) error {
+							want := NewCompositionRevision(comp, 3, hash)
+							if diff := cmp.Diff(want, obj); diff != "" {
+								t.Errorf("Create(...): -want, +got:\n%s", diff)
+							}
+							return nil
+						}),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"CreateRevisionError": {
+			reason: "We should requeue after a short wait if we encounter an error creating a revision.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(validPaths),
+					WithClient(&test.MockClient{
+						MockGet:    test.MockGetFn(getComp),
+						MockList:   listRevs(),
+						MockCreate: test.NewMockCreateFn(errBoom),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"RevisionAlreadyExists": {
+			reason: "We should requeue if the revision we would create already exists, because our cache is stale.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(validPaths),
+					WithClient(&test.MockClient{
+						MockGet:    test.MockGetFn(getComp),
+						MockList:   listRevs(rev(1, "old")),
+						MockCreate: test.NewMockCreateFn(kerrors.NewAlreadyExists(schema.GroupResource{}, RevisionName(comp, 2, hash))),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: true},
+			},
+		},
+		"DuplicateLatestRevision": {
+			reason: "We should consistently pick one of several revisions with the latest revision number.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(validPaths),
+					WithClient(&test.MockClient{
+						MockGet: test.MockGetFn(getComp),
+						MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+							b, a := rev(2, hash), rev(2, "old")
+							b.SetName("b")
+							a.SetName("a")
+							obj.(*v1.CompositionRevisionList).Items = []v1.CompositionRevision{b, a}
+							return nil
+						},
+						MockCreate: test.NewMockCreateFn(errBoom),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"CreateRevision": {
+			reason: "We should create a new revision if none match our Composition's spec.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithFieldPathValidator(validPaths),
+					WithClient(&test.MockClient{
+						MockGet:  test.MockGetFn(getComp),
+						MockList: listRevs(rev(1, "old"), rev(2, "older")),
+						MockCreate: test.NewMockCreateFn(nil, func(obj client.Object) error {
+							want := NewCompositionRevision(comp, 3, hash)
+							if diff := cmp.Diff(want, obj); diff != "" {
+								t.Errorf("Create(...): -want, +got:\n%s", diff)
+							}
+							return nil
+						}),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewReconciler(tc.args.mgr, tc.args.opts...)
+			got, err := r.Reconcile(context.Background(), reconcile.Request{})
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.r, got); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+const (
+	// Kubernetes label values may be at most 63 characters long.
+	maxHashLength = 63
+
+	// The number of characters of the spec hash included in revision names.
+	revisionHashLength = 7
+)
+
+// SpecHash returns a hash of the supplied Composition's spec. The hash is
+// suitable for use as a label value.
+func SpecHash(comp *v1.Composition) string {
+	// Marshalling a CompositionSpec cannot fail; it contains no channels,
+	// functions, or other types that cannot be represented as JSON.
+	j, _ := json.Marshal(comp.Spec)
+	h := fmt.Sprintf("%x", sha256.Sum256(j))
+	return h[:maxHashLength]
+}
+
+// RevisionName returns the name of the supplied revision of the supplied
+// Composition. Revision names are deterministic, so that creating a revision
+// that already exists fails rather than creating a duplicate. They include the
+// revision number because a Composition that is reverted to an earlier spec
+// gets a new revision with the same spec hash.
+func RevisionName(comp *v1.Composition, revision int64, hash string) string {
+	suffix := fmt.Sprintf("-%s-%d", hash[:revisionHashLength], revision)
+
+	// Truncate long Composition names so that the revision name is a valid
+	// object name.
+	prefix := comp.GetName()
+	if max := validation.DNS1123SubdomainMaxLength - len(suffix); len(prefix) > max {
+		prefix = strings.TrimRight(prefix[:max], "-.")
+	}
+	return prefix + suffix
+}
+
+// NewCompositionRevision creates a new revision of the supplied Composition.
+func NewCompositionRevision(comp *v1.Composition, revision int64, hash string) *v1.CompositionRevision {
+	rev := &v1.CompositionRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: RevisionName(comp, revision, hash),
+		},
+		Spec: v1.CompositionRevisionSpec{
+			CompositionSpec: *comp.Spec.DeepCopy(),
+			Revision:        revision,
+		},
+	}
+
+	ref := meta.TypedReferenceTo(comp, v1.CompositionGroupVersionKind)
+	meta.AddOwnerReference(rev, meta.AsController(ref))
+
+	// Revisions inherit the labels of their Composition so that they may be
+	// selected in the same way.
+	meta.AddLabels(rev, comp.GetLabels())
+	meta.AddLabels(rev, map[string]string{v1.LabelCompositionSpecHash: hash})
+
+	// Composition names may be longer than label values. Such revisions can
+	// only be associated with their Composition by their owner reference.
+	if len(validation.IsValidLabelValue(comp.GetName())) == 0 {
+		meta.AddLabels(rev, map[string]string{v1.LabelCompositionName: comp.GetName()})
+	}
+
+	return rev
+}
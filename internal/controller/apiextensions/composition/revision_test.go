@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestRevisionName(t *testing.T) {
+	hash := strings.Repeat("a", maxHashLength)
+	long := strings.Repeat("a", 241) + "-" + strings.Repeat("b", 11)
+
+	cases := map[string]struct {
+		reason   string
+		comp     *v1.Composition
+		revision int64
+		want     string
+	}{
+		"Short": {
+			reason:   "Revision names should be the Composition name, a short spec hash, and the revision number.",
+			comp:     &v1.Composition{ObjectMeta: metav1.ObjectMeta{Name: "cool"}},
+			revision: 3,
+			want:     "cool-aaaaaaa-3",
+		},
+		"Long": {
+			reason:   "Long Composition names should be truncated, without trailing separators, so that the revision name is a valid object name.",
+			comp:     &v1.Composition{ObjectMeta: metav1.ObjectMeta{Name: long}},
+			revision: 42,
+			want:     strings.Repeat("a", 241) + "-aaaaaaa-42",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RevisionName(tc.comp, tc.revision, hash)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nRevisionName(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"context"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+type adder interface {
+	Add(item interface{})
+}
+
+// EnqueueRequestForCompositions enqueues a reconcile for each Composition that
+// refers to the kind of resource defined by a CompositeResourceDefinition or
+// CustomResourceDefinition whenever said definition changes, so that the
+// Composition's field paths are validated against its current schema.
+type EnqueueRequestForCompositions struct {
+	client client.Reader
+}
+
+// Create adds a NamespacedName for each Composition that refers to the kind of
+// resource defined by the supplied CreateEvent's Object.
+func (e *EnqueueRequestForCompositions) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Update adds a NamespacedName for each Composition that refers to the kind of
+// resource defined by the supplied UpdateEvent's Object.
+func (e *EnqueueRequestForCompositions) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.ObjectOld, q)
+	e.add(evt.ObjectNew, q)
+}
+
+// Delete adds a NamespacedName for each Composition that refers to the kind of
+// resource defined by the supplied DeleteEvent's Object.
+func (e *EnqueueRequestForCompositions) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Generic adds a NamespacedName for each Composition that refers to the kind of
+// resource defined by the supplied GenericEvent's Object.
+func (e *EnqueueRequestForCompositions) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+func (e *EnqueueRequestForCompositions) add(obj runtime.Object, queue adder) {
+	var gk schema.GroupKind
+	switch d := obj.(type) {
+	case *v1.CompositeResourceDefinition:
+		gk = schema.GroupKind{Group: d.Spec.Group, Kind: d.Spec.Names.Kind}
+	case *extv1.CustomResourceDefinition:
+		gk = schema.GroupKind{Group: d.Spec.Group, Kind: d.Spec.Names.Kind}
+	default:
+		return
+	}
+
+	l := &v1.CompositionList{}
+	if err := e.client.List(context.Background(), l); err != nil {
+		return
+	}
+
+	for i := range l.Items {
+		if refersTo(&l.Items[i], gk) {
+			queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: l.Items[i].GetName()}})
+		}
+	}
+}
+
+// refersTo returns true if the supplied Composition composes, or composes
+// resources of, the supplied kind.
+func refersTo(comp *v1.Composition, gk schema.GroupKind) bool {
+	ref := comp.Spec.CompositeTypeRef
+	if schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind).GroupKind() == gk {
+		return true
+	}
+	for _, t := range comp.Spec.Resources {
+		if gvk, ok := baseGVK(t.Base); ok && gvk.GroupKind() == gk {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+var (
+	_ handler.EventHandler = &EnqueueRequestForCompositions{}
+)
+
+type addFn func(item interface{})
+
+func (fn addFn) Add(item interface{}) {
+	fn(item)
+}
+
+func TestAdd(t *testing.T) {
+	crd := &extv1.CustomResourceDefinition{Spec: extv1.CustomResourceDefinitionSpec{
+		Group: "example.org",
+		Names: extv1.CustomResourceDefinitionNames{Kind: "Database"},
+	}}
+	xrd := &v1.CompositeResourceDefinition{Spec: v1.CompositeResourceDefinitionSpec{
+		Group: "example.org",
+		Names: extv1.CustomResourceDefinitionNames{Kind: "XDatabase"},
+	}}
+	list := test.NewMockListFn(nil, func(o client.ObjectList) error {
+		o.(*v1.CompositionList).Items = []v1.Composition{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "composes"},
+				Spec:       v1.CompositionSpec{CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XDatabase"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "composed"},
+				Spec: v1.CompositionSpec{
+					CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XCache"},
+					Resources:        []v1.ComposedTemplate{{Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Database"}`)}}},
+				},
+			},
+		}
+		return nil
+	})
+	want := func(name string) addFn {
+		return addFn(func(got interface{}) {
+			want := reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("-want, +got:\n%s\n", diff)
+			}
+		})
+	}
+
+	cases := map[string]struct {
+		client client.Reader
+		obj    runtime.Object
+		queue  adder
+	}{
+		"ObjectIsNotADefinition": {
+			queue: addFn(func(_ interface{}) { t.Errorf("queue.Add() called unexpectedly") }),
+		},
+		"ListCompositionsError": {
+			client: &test.MockClient{MockList: test.NewMockListFn(errors.New("boom"))},
+			obj:    crd,
+			queue:  addFn(func(_ interface{}) { t.Errorf("queue.Add() called unexpectedly") }),
+		},
+		"EnqueueComposingComposition": {
+			client: &test.MockClient{MockList: list},
+			obj:    xrd,
+			queue:  want("composes"),
+		},
+		"EnqueueComposedComposition": {
+			client: &test.MockClient{MockList: list},
+			obj:    crd,
+			queue:  want("composed"),
+		},
+	}
+
+	for _, tc := range cases {
+		e := &EnqueueRequestForCompositions{client: tc.client}
+		e.add(tc.obj, tc.queue)
+	}
+}
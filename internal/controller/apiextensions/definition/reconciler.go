@@ -34,7 +34,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	"github.com/crossplane/crossplane-runtime/pkg/controller"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
@@ -43,6 +42,7 @@ import (
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
 	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+	"github.com/crossplane/crossplane/internal/engine"
 	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
@@ -80,10 +80,12 @@ const (
 	reasonTerminateXR event.Reason = "TerminateComposite"
 )
 
-// A ControllerEngine can start and stop Kubernetes controllers on demand.
+// A ControllerEngine can start and stop Kubernetes controllers on demand, and
+// start new watches on behalf of the controllers it is running.
 type ControllerEngine interface {
 	IsRunning(name string) bool
-	Start(name string, o kcontroller.Options, w ...controller.Watch) error
+	Start(name string, o kcontroller.Options, w ...engine.Watch) error
+	StartWatches(ctx context.Context, name string, w ...engine.Watch) error
 	Stop(name string)
 	Err(name string) error
 }
@@ -106,7 +108,7 @@ func (fn CRDRenderFn) Render(d *v1.CompositeResourceDefinition) (*extv1.CustomRe
 
 // Setup adds a controller that reconciles CompositeResourceDefinitions by
 // defining a composite resource and starting a controller to reconcile it.
-func Setup(mgr ctrl.Manager, log logging.Logger) error {
+func Setup(mgr ctrl.Manager, log logging.Logger, o ...ReconcilerOption) error {
 	name := "defined/" + strings.ToLower(v1.CompositeResourceDefinitionGroupKind)
 
 	return ctrl.NewControllerManagedBy(mgr).
@@ -114,9 +116,10 @@ func Setup(mgr ctrl.Manager, log logging.Logger) error {
 		For(&v1.CompositeResourceDefinition{}).
 		Owns(&extv1.CustomResourceDefinition{}).
 		WithOptions(kcontroller.Options{MaxConcurrentReconciles: maxConcurrency}).
-		Complete(NewReconciler(mgr,
+		Complete(NewReconciler(mgr, append([]ReconcilerOption{
 			WithLogger(log.WithValues("controller", name)),
-			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name))),
+		}, o...)...))
 }
 
 // ReconcilerOption is used to configure the Reconciler.
@@ -160,6 +163,22 @@ func WithCRDRenderer(c CRDRenderer) ReconcilerOption {
 	}
 }
 
+// WithServerSideApply specifies that the controllers started by the Reconciler
+// should use server-side apply to apply composite resources and their composed resources.
+func WithServerSideApply() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.ssa = true
+	}
+}
+
+// WithFunctionOptions specifies which composition functions the controllers
+// started by the Reconciler may run.
+func WithFunctionOptions(o composite.FunctionOptions) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.functions = o
+	}
+}
+
 // WithClientApplicator specifies how the Reconciler should interact with the
 // Kubernetes API.
 func WithClientApplicator(ca resource.ClientApplicator) ReconcilerOption {
@@ -188,7 +207,7 @@ func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
 
 		composite: definition{
 			CRDRenderer:      CRDRenderFn(xcrd.ForCompositeResource),
-			ControllerEngine: controller.NewEngine(mgr),
+			ControllerEngine: engine.New(mgr),
 			Finalizer:        resource.NewAPIFinalizer(kube, finalizer),
 		},
 
@@ -208,6 +227,8 @@ type Reconciler struct {
 	mgr    manager.Manager
 
 	composite definition
+	ssa       bool
+	functions composite.FunctionOptions
 
 	log    logging.Logger
 	record event.Recorder
@@ -374,22 +395,38 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	}
 
 	recorder := r.record.WithAnnotations("controller", composite.ControllerName(d.GetName()))
-	o := kcontroller.Options{Reconciler: composite.NewReconciler(r.mgr,
-		resource.CompositeKind(d.GetCompositeGroupVersionKind()),
+	co := []composite.ReconcilerOption{
 		composite.WithConnectionPublisher(composite.NewAPIFilteredSecretPublisher(r.client, d.GetConnectionSecretKeys())),
 		composite.WithCompositionSelector(composite.NewCompositionSelectorChain(
 			composite.NewEnforcedCompositionSelector(*d, recorder),
 			composite.NewAPIDefaultCompositionSelector(r.client, *meta.ReferenceTo(d, v1.CompositeResourceDefinitionGroupVersionKind), recorder),
-			composite.NewAPILabelSelectorResolver(r.client),
+			composite.NewAPILabelSelectorResolver(r.client,
+				composite.WithSelectionPolicyFrom(*meta.ReferenceTo(d, v1.CompositeResourceDefinitionGroupVersionKind)),
+				composite.WithSelectionRecorder(recorder)),
+			composite.NewAPIRevisionSelector(r.client),
 		)),
+		composite.WithCompositionFetcher(composite.NewAPIRevisionFetcher(r.client)),
 		composite.WithLogger(log.WithValues("controller", composite.ControllerName(d.GetName()))),
 		composite.WithRecorder(recorder),
-	), MaxConcurrentReconciles: maxConcurrency}
+		composite.WithWatchStarter(composite.ControllerName(d.GetName()), r.composite.ControllerEngine),
+		composite.WithCompositionValidator(composite.DefaultValidationChain(r.functions)),
+		composite.WithFunctionRunner(composite.NewExecFunctionRunner(r.client, r.functions)),
+	}
+	if r.ssa {
+		co = append(co, composite.WithClientApplicator(resource.ClientApplicator{
+			Client:     r.client.Client,
+			Applicator: composite.NewAPIServerSideApplicator(r.client.Client),
+		}))
+	}
+	o := kcontroller.Options{
+		Reconciler:              composite.NewReconciler(r.mgr, resource.CompositeKind(d.GetCompositeGroupVersionKind()), co...),
+		MaxConcurrentReconciles: maxConcurrency,
+	}
 
 	u := &kunstructured.Unstructured{}
 	u.SetGroupVersionKind(d.GetCompositeGroupVersionKind())
 
-	if err := r.composite.Start(composite.ControllerName(d.GetName()), o, controller.For(u, &handler.EnqueueRequestForObject{})); err != nil {
+	if err := r.composite.Start(composite.ControllerName(d.GetName()), o, engine.WatchFor(u, &handler.EnqueueRequestForObject{})); err != nil {
 		log.Debug(errStartController, "error", err)
 		r.record.Event(d, event.Warning(reasonEstablishXR, errors.Wrap(err, errStartController)))
 		return reconcile.Result{RequeueAfter: shortWait}, nil
@@ -33,21 +33,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	"github.com/crossplane/crossplane-runtime/pkg/controller"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/engine"
 )
 
 type MockEngine struct {
 	ControllerEngine
-	MockStart func(name string, o kcontroller.Options, w ...controller.Watch) error
+	MockStart func(name string, o kcontroller.Options, w ...engine.Watch) error
 	MockStop  func(name string)
 	MockErr   func(name string) error
 }
 
-func (m *MockEngine) Start(name string, o kcontroller.Options, w ...controller.Watch) error {
+func (m *MockEngine) Start(name string, o kcontroller.Options, w ...engine.Watch) error {
 	return m.MockStart(name, o, w...)
 }
 
@@ -541,7 +541,7 @@ func TestReconcile(t *testing.T) {
 					}}),
 					WithControllerEngine(&MockEngine{
 						MockErr:   func(_ string) error { return nil },
-						MockStart: func(_ string, _ kcontroller.Options, _ ...controller.Watch) error { return errBoom },
+						MockStart: func(_ string, _ kcontroller.Options, _ ...engine.Watch) error { return errBoom },
 					}),
 				},
 			},
@@ -585,7 +585,7 @@ func TestReconcile(t *testing.T) {
 					}}),
 					WithControllerEngine(&MockEngine{
 						MockErr:   func(name string) error { return errBoom }, // This error should only be logged.
-						MockStart: func(_ string, _ kcontroller.Options, _ ...controller.Watch) error { return nil }},
+						MockStart: func(_ string, _ kcontroller.Options, _ ...engine.Watch) error { return nil }},
 					),
 				},
 			},
@@ -642,7 +642,7 @@ func TestReconcile(t *testing.T) {
 					}}),
 					WithControllerEngine(&MockEngine{
 						MockErr:   func(name string) error { return nil },
-						MockStart: func(_ string, _ kcontroller.Options, _ ...controller.Watch) error { return nil },
+						MockStart: func(_ string, _ kcontroller.Options, _ ...engine.Watch) error { return nil },
 						MockStop:  func(_ string) {},
 					}),
 				},
@@ -110,7 +110,7 @@ func (fn CRDRenderFn) Render(d *v1.CompositeResourceDefinition) (*extv1.CustomRe
 // Setup adds a controller that reconciles CompositeResourceDefinitions by
 // defining a composite resource claim and starting a controller to reconcile
 // it.
-func Setup(mgr ctrl.Manager, log logging.Logger) error {
+func Setup(mgr ctrl.Manager, log logging.Logger, o ...ReconcilerOption) error {
 	name := "offered/" + strings.ToLower(v1.CompositeResourceDefinitionGroupKind)
 
 	return ctrl.NewControllerManagedBy(mgr).
@@ -119,9 +119,10 @@ func Setup(mgr ctrl.Manager, log logging.Logger) error {
 		Owns(&extv1.CustomResourceDefinition{}).
 		WithEventFilter(resource.NewPredicates(OffersClaim())).
 		WithOptions(kcontroller.Options{MaxConcurrentReconciles: maxConcurrency}).
-		Complete(NewReconciler(mgr,
+		Complete(NewReconciler(mgr, append([]ReconcilerOption{
 			WithLogger(log.WithValues("controller", name)),
-			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name))),
+		}, o...)...))
 }
 
 // ReconcilerOption is used to configure the Reconciler.
@@ -165,6 +166,14 @@ func WithCRDRenderer(c CRDRenderer) ReconcilerOption {
 	}
 }
 
+// WithServerSideApply specifies that the controllers started by the Reconciler
+// should use server-side apply to apply composite resources on behalf of their claims.
+func WithServerSideApply() ReconcilerOption {
+	return func(r *Reconciler) {
+		r.ssa = true
+	}
+}
+
 // WithClientApplicator specifies how the Reconciler should interact with the
 // Kubernetes API.
 func WithClientApplicator(ca resource.ClientApplicator) ReconcilerOption {
@@ -213,6 +222,7 @@ type Reconciler struct {
 	client resource.ClientApplicator
 
 	claim definition
+	ssa   bool
 
 	log    logging.Logger
 	record event.Recorder
@@ -361,12 +371,23 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		return reconcile.Result{RequeueAfter: tinyWait}, nil
 	}
 
-	o := kcontroller.Options{Reconciler: claim.NewReconciler(r.mgr,
-		resource.CompositeClaimKind(d.GetClaimGroupVersionKind()),
-		resource.CompositeKind(d.GetCompositeGroupVersionKind()),
+	co := []claim.ReconcilerOption{
 		claim.WithLogger(log.WithValues("controller", claim.ControllerName(d.GetName()))),
 		claim.WithRecorder(r.record.WithAnnotations("controller", claim.ControllerName(d.GetName()))),
-	), MaxConcurrentReconciles: maxConcurrency}
+	}
+	if r.ssa {
+		co = append(co, claim.WithClientApplicator(resource.ClientApplicator{
+			Client:     r.client.Client,
+			Applicator: claim.NewAPIServerSideApplicator(r.client.Client),
+		}))
+	}
+	o := kcontroller.Options{
+		Reconciler: claim.NewReconciler(r.mgr,
+			resource.CompositeClaimKind(d.GetClaimGroupVersionKind()),
+			resource.CompositeKind(d.GetCompositeGroupVersionKind()),
+			co...),
+		MaxConcurrentReconciles: maxConcurrency,
+	}
 
 	if err := r.claim.Err(claim.ControllerName(d.GetName())); err != nil {
 		log.Debug("Composite resource controller encountered an error", "error", err)
@@ -0,0 +1,183 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// The field by which cached composite resources are indexed.
+const fieldCompositionName = "spec.compositionRef.name"
+
+const (
+	errCreateCache = "cannot create composite resource cache"
+	errIndexCache  = "cannot index composite resource cache"
+	errSyncCache   = "cannot sync composite resource cache"
+)
+
+// A NewCacheFn creates a new controller-runtime cache.
+type NewCacheFn func(cfg *rest.Config, o cache.Options) (cache.Cache, error)
+
+// A CachedUsageCounter counts the composite resources that use a Composition.
+// It reads composite resources from an informer cache of each kind of
+// composite resource, indexed by the name of the Composition each composite
+// resource uses. A cache is started the first time a kind of composite
+// resource is counted, and is shared by all Compositions of that kind.
+type CachedUsageCounter struct {
+	cfg    *rest.Config
+	scheme *runtime.Scheme
+	mapper kmeta.RESTMapper
+
+	newCache NewCacheFn
+	caches   map[schema.GroupVersionKind]kindCache
+	mx       sync.Mutex
+
+	ctx  context.Context
+	stop context.CancelFunc
+}
+
+// A kindCache caches one kind of composite resource. It is stopped when the
+// counter is stopped, or by calling stop.
+type kindCache struct {
+	cache.Cache
+	stop context.CancelFunc
+}
+
+// A CachedUsageCounterOption configures a CachedUsageCounter.
+type CachedUsageCounterOption func(*CachedUsageCounter)
+
+// WithNewCacheFn may be used to configure a different cache implementation.
+func WithNewCacheFn(fn NewCacheFn) CachedUsageCounterOption {
+	return func(c *CachedUsageCounter) {
+		c.newCache = fn
+	}
+}
+
+// NewCachedUsageCounter returns a UsageCounter that reads composite resources
+// from informer caches. Its caches are stopped when the counter is stopped;
+// add it to the supplied manager to stop it when the manager stops.
+func NewCachedUsageCounter(mgr manager.Manager, o ...CachedUsageCounterOption) *CachedUsageCounter {
+	ctx, stop := context.WithCancel(context.Background())
+	c := &CachedUsageCounter{
+		cfg:      mgr.GetConfig(),
+		scheme:   mgr.GetScheme(),
+		mapper:   mgr.GetRESTMapper(),
+		newCache: cache.New,
+		caches:   make(map[schema.GroupVersionKind]kindCache),
+		ctx:      ctx,
+		stop:     stop,
+	}
+	for _, fn := range o {
+		fn(c)
+	}
+	return c
+}
+
+// Start blocks until the supplied context is done, then stops all caches.
+func (c *CachedUsageCounter) Start(ctx context.Context) error {
+	<-ctx.Done()
+	c.stop()
+	return nil
+}
+
+// CountUsage returns the number of composite resources that use the supplied
+// Composition. A Composition of a kind of composite resource that is not
+// defined is not in use.
+func (c *CachedUsageCounter) CountUsage(ctx context.Context, comp *v1.Composition) (int64, error) {
+	gvk := schema.FromAPIVersionAndKind(comp.Spec.CompositeTypeRef.APIVersion, comp.Spec.CompositeTypeRef.Kind)
+	r, err := c.reader(ctx, gvk)
+	if kmeta.IsNoMatchError(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	l := &kunstructured.UnstructuredList{}
+	l.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.List(ctx, l, client.MatchingFields{fieldCompositionName: comp.GetName()}); err != nil {
+		return 0, errors.Wrap(err, errListXRs)
+	}
+	return int64(len(l.Items)), nil
+}
+
+func (c *CachedUsageCounter) reader(ctx context.Context, gvk schema.GroupVersionKind) (client.Reader, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if kc, ok := c.caches[gvk]; ok {
+		return kc, nil
+	}
+
+	ca, err := c.newCache(c.cfg, cache.Options{Scheme: c.scheme, Mapper: c.mapper})
+	if err != nil {
+		return nil, errors.Wrap(err, errCreateCache)
+	}
+
+	// Indexes must be added before a cache is started. Adding an index fails
+	// if the kind of composite resource is not (yet) defined, in which case we
+	// discard the cache and try again next time.
+	u := &kunstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	if err := ca.IndexField(ctx, u, fieldCompositionName, compositionName); err != nil {
+		if kmeta.IsNoMatchError(err) {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, errIndexCache)
+	}
+
+	// A cache that hasn't synced would report that a Composition is not in
+	// use, so we don't use a cache until it has synced. If it doesn't sync we
+	// stop it and try again with a new cache next time.
+	cctx, stop := context.WithCancel(c.ctx)
+	go func() {
+		_ = ca.Start(cctx)
+	}()
+	if !ca.WaitForCacheSync(ctx) {
+		stop()
+		return nil, errors.New(errSyncCache)
+	}
+	c.caches[gvk] = kindCache{Cache: ca, stop: stop}
+	return ca, nil
+}
+
+func compositionName(o client.Object) []string {
+	u, ok := o.(*kunstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	ref := (&composite.Unstructured{Unstructured: *u}).GetCompositionReference()
+	if ref == nil {
+		return nil
+	}
+	return []string{ref.Name}
+}
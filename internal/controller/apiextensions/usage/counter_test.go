@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+type mockCache struct {
+	cache.Cache
+
+	MockIndexField       func(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error
+	MockList             func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error
+	MockWaitForCacheSync func(ctx context.Context) bool
+}
+
+func (c *mockCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	return c.MockIndexField(ctx, obj, field, extractValue)
+}
+
+func (c *mockCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.MockList(ctx, list, opts...)
+}
+
+func (c *mockCache) WaitForCacheSync(ctx context.Context) bool {
+	return c.MockWaitForCacheSync(ctx)
+}
+
+func (c *mockCache) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestCachedUsageCounter(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	comp := &v1.Composition{Spec: v1.CompositionSpec{
+		CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XDatabase"},
+	}}
+	comp.SetName("cool")
+
+	index := func(err error) func(context.Context, client.Object, string, client.IndexerFunc) error {
+		return func(_ context.Context, _ client.Object, field string, _ client.IndexerFunc) error {
+			if field != fieldCompositionName {
+				t.Errorf("IndexField(...): want field %s, got %s", fieldCompositionName, field)
+			}
+			return err
+		}
+	}
+	list := func(n int) func(context.Context, client.ObjectList, ...client.ListOption) error {
+		return func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+			l := obj.(*kunstructured.UnstructuredList)
+			if l.GetKind() != "XDatabaseList" {
+				t.Errorf("List(...): want kind XDatabaseList, got %s", l.GetKind())
+			}
+			lo := &client.ListOptions{}
+			lo.ApplyOptions(opts)
+			if lo.FieldSelector == nil || lo.FieldSelector.String() != fieldCompositionName+"=cool" {
+				t.Errorf("List(...): want field selector %s=cool, got %v", fieldCompositionName, lo.FieldSelector)
+			}
+			l.Items = make([]kunstructured.Unstructured, n)
+			return nil
+		}
+	}
+
+	synced := func(context.Context) bool { return true }
+
+	type want struct {
+		usage int64
+		err   error
+	}
+
+	cases := map[string]struct {
+		reason string
+		c      cache.Cache
+		want   want
+	}{
+		"UndefinedKind": {
+			reason: "A Composition of a kind of composite resource that is not defined should not be in use.",
+			c:      &mockCache{MockIndexField: index(&kmeta.NoKindMatchError{})},
+			want:   want{usage: 0},
+		},
+		"IndexError": {
+			reason: "We should return any other error encountered while indexing the cache.",
+			c:      &mockCache{MockIndexField: index(errBoom)},
+			want:   want{err: errors.Wrap(errBoom, errIndexCache)},
+		},
+		"SyncError": {
+			reason: "We should return an error rather than count usage if the cache doesn't sync.",
+			c: &mockCache{
+				MockIndexField:       index(nil),
+				MockWaitForCacheSync: func(context.Context) bool { return false },
+				MockList: func(context.Context, client.ObjectList, ...client.ListOption) error {
+					t.Errorf("List(...): unexpected list of an unsynced cache")
+					return nil
+				},
+			},
+			want: want{err: errors.New(errSyncCache)},
+		},
+		"ListError": {
+			reason: "We should return any error encountered while listing composite resources.",
+			c: &mockCache{
+				MockIndexField:       index(nil),
+				MockWaitForCacheSync: synced,
+				MockList:             test.NewMockListFn(errBoom),
+			},
+			want: want{err: errors.Wrap(errBoom, errListXRs)},
+		},
+		"Success": {
+			reason: "We should return the number of composite resources indexed by the Composition's name.",
+			c: &mockCache{
+				MockIndexField:       index(nil),
+				MockWaitForCacheSync: synced,
+				MockList:             list(2),
+			},
+			want: want{usage: 2},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			uc := NewCachedUsageCounter(&fake.Manager{}, WithNewCacheFn(func(_ *rest.Config, _ cache.Options) (cache.Cache, error) {
+				return tc.c, nil
+			}))
+			defer uc.stop()
+
+			got, err := uc.CountUsage(context.Background(), comp)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCountUsage(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.usage, got); diff != "" {
+				t.Errorf("\n%s\nCountUsage(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package usage protects Compositions that are in use from deletion.
+package usage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	kcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+const (
+	shortWait = 30 * time.Second
+	longWait  = 1 * time.Minute
+
+	timeout        = 2 * time.Minute
+	maxConcurrency = 5
+	finalizer      = "in-use.apiextensions.crossplane.io"
+
+	errGet             = "cannot get Composition"
+	errListXRs         = "cannot list composite resources"
+	errCountUsage      = "cannot count composite resources that use Composition"
+	errAddCounter      = "cannot add usage counter to manager"
+	errNoUsageCounter  = "no usage counter is configured"
+	errAddFinalizer    = "cannot add Composition finalizer"
+	errRemoveFinalizer = "cannot remove Composition finalizer"
+	errUpdateStatus    = "cannot update Composition status"
+	errFmtInUse        = "cannot delete Composition while it is in use by %d composite resources"
+)
+
+// Event reasons.
+const (
+	reasonCountUsage event.Reason = "CountUsage"
+	reasonInUse      event.Reason = "InUse"
+)
+
+// Setup adds a controller that reconciles Compositions by counting the
+// composite resources that use them, and preventing their deletion while they
+// are in use.
+func Setup(mgr ctrl.Manager, log logging.Logger) error {
+	name := "usage/" + strings.ToLower(v1.CompositionGroupKind)
+
+	uc := NewCachedUsageCounter(mgr)
+	if err := mgr.Add(uc); err != nil {
+		return errors.Wrap(err, errAddCounter)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		For(&v1.Composition{}).
+		WithOptions(kcontroller.Options{MaxConcurrentReconciles: maxConcurrency}).
+		Complete(NewReconciler(mgr,
+			WithUsageCounter(uc),
+			WithLogger(log.WithValues("controller", name)),
+			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+}
+
+// A UsageCounter counts the composite resources that use a Composition.
+type UsageCounter interface {
+	// CountUsage returns the number of composite resources that use the
+	// supplied Composition.
+	CountUsage(ctx context.Context, comp *v1.Composition) (int64, error)
+}
+
+// A UsageCounterFn counts the composite resources that use a Composition.
+type UsageCounterFn func(ctx context.Context, comp *v1.Composition) (int64, error)
+
+// CountUsage returns the number of composite resources that use the supplied
+// Composition.
+func (fn UsageCounterFn) CountUsage(ctx context.Context, comp *v1.Composition) (int64, error) {
+	return fn(ctx, comp)
+}
+
+// ReconcilerOption is used to configure the Reconciler.
+type ReconcilerOption func(*Reconciler)
+
+// WithLogger specifies how the Reconciler should log messages.
+func WithLogger(log logging.Logger) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.log = log
+	}
+}
+
+// WithRecorder specifies how the Reconciler should record Kubernetes events.
+func WithRecorder(er event.Recorder) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.record = er
+	}
+}
+
+// WithClient specifies how the Reconciler should interact with the Kubernetes
+// API.
+func WithClient(c client.Client) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.client = c
+	}
+}
+
+// WithFinalizer specifies how the Reconciler should add and remove
+// finalizers to and from Compositions.
+func WithFinalizer(f resource.Finalizer) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.finalizer = f
+	}
+}
+
+// WithUsageCounter specifies how the Reconciler should count the composite
+// resources that use a Composition. A Reconciler must be configured with a
+// UsageCounter; it cannot count usage by default.
+func WithUsageCounter(uc UsageCounter) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.usage = uc
+	}
+}
+
+// NewReconciler returns a Reconciler of Compositions.
+func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
+	r := &Reconciler{
+		client:    mgr.GetClient(),
+		finalizer: resource.NewAPIFinalizer(mgr.GetClient(), finalizer),
+		usage: UsageCounterFn(func(_ context.Context, _ *v1.Composition) (int64, error) {
+			return 0, errors.New(errNoUsageCounter)
+		}),
+
+		log:    logging.NewNopLogger(),
+		record: event.NewNopRecorder(),
+	}
+
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
+
+// A Reconciler reconciles Compositions by counting the composite resources
+// that use them.
+type Reconciler struct {
+	client    client.Client
+	finalizer resource.Finalizer
+	usage     UsageCounter
+
+	log    logging.Logger
+	record event.Recorder
+}
+
+// Reconcile a Composition.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("request", req)
+	log.Debug("Reconciling")
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	comp := &v1.Composition{}
+	if err := r.client.Get(ctx, req.NamespacedName, comp); err != nil {
+		log.Debug(errGet, "error", err)
+		return reconcile.Result{}, errors.Wrap(resource.IgnoreNotFound(err), errGet)
+	}
+
+	log = log.WithValues(
+		"uid", comp.GetUID(),
+		"version", comp.GetResourceVersion(),
+		"name", comp.GetName(),
+	)
+
+	// Kinds of composite resource are defined at runtime, so rather than
+	// watching every kind of composite resource we poll for changes in usage.
+	usage, err := r.usage.CountUsage(ctx, comp)
+	if err != nil {
+		log.Debug(errCountUsage, "error", err)
+		r.record.Event(comp, event.Warning(reasonCountUsage, errors.Wrap(err, errCountUsage)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+	log = log.WithValues("usage", usage)
+
+	switch {
+	case usage == 0:
+		if err := r.finalizer.RemoveFinalizer(ctx, comp); err != nil {
+			log.Debug(errRemoveFinalizer, "error", err)
+			r.record.Event(comp, event.Warning(reasonCountUsage, errors.Wrap(err, errRemoveFinalizer)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+
+		// There's nothing left to do if our Composition was being deleted. It
+		// will be gone now that we've removed our finalizer.
+		if meta.WasDeleted(comp) {
+			log.Debug("Composition is no longer in use")
+			return reconcile.Result{Requeue: false}, nil
+		}
+	case meta.WasDeleted(comp):
+		// Our finalizer is blocking deletion of this Composition. Note that we
+		// can't add a finalizer to a Composition that is already being deleted.
+		log.Debug("Cannot delete Composition while it is in use")
+		r.record.Event(comp, event.Warning(reasonInUse, errors.Errorf(errFmtInUse, usage)))
+	default:
+		if err := r.finalizer.AddFinalizer(ctx, comp); err != nil {
+			log.Debug(errAddFinalizer, "error", err)
+			r.record.Event(comp, event.Warning(reasonCountUsage, errors.Wrap(err, errAddFinalizer)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+	}
+
+	if comp.Status.Usage != usage {
+		comp.Status.Usage = usage
+		if err := r.client.Status().Update(ctx, comp); err != nil {
+			log.Debug(errUpdateStatus, "error", err)
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+	}
+
+	if meta.WasDeleted(comp) {
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+	return reconcile.Result{RequeueAfter: longWait}, nil
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestReconcile(t *testing.T) {
+	errBoom := errors.New("boom")
+	now := metav1.Now()
+
+	getComp := func(deleted bool) test.MockGetFn {
+		return func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+			comp := obj.(*v1.Composition)
+			comp.SetName("cool")
+			comp.Spec.CompositeTypeRef = v1.TypeReference{APIVersion: "example.org/v1", Kind: "XDatabase"}
+			if deleted {
+				comp.SetDeletionTimestamp(&now)
+			}
+			return nil
+		}
+	}
+	count := func(usage int64, err error) ReconcilerOption {
+		return WithUsageCounter(UsageCounterFn(func(_ context.Context, _ *v1.Composition) (int64, error) {
+			return usage, err
+		}))
+	}
+	wantUsage := func(usage int64) test.MockStatusUpdateFn {
+		return test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+			if diff := cmp.Diff(usage, obj.(*v1.Composition).Status.Usage); diff != "" {
+				t.Errorf("Status().Update(...): -want usage, +got usage:\n%s", diff)
+			}
+			return nil
+		})
+	}
+	noop := resource.FinalizerFns{
+		AddFinalizerFn:    func(_ context.Context, _ resource.Object) error { return nil },
+		RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error { return nil },
+	}
+
+	type want struct {
+		r   reconcile.Result
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		opts   []ReconcilerOption
+		want   want
+	}{
+		"CompositionNotFound": {
+			reason: "We should not return an error if the Composition was not found.",
+			opts: []ReconcilerOption{
+				WithClient(&test.MockClient{
+					MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+				}),
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"GetCompositionError": {
+			reason: "We should return any other error encountered while getting a Composition.",
+			opts: []ReconcilerOption{
+				WithClient(&test.MockClient{
+					MockGet: test.NewMockGetFn(errBoom),
+				}),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGet),
+			},
+		},
+		"CountUsageError": {
+			reason: "We should requeue after a short wait if we encounter an error counting composite resources.",
+			opts: []ReconcilerOption{
+				WithClient(&test.MockClient{
+					MockGet: getComp(false),
+				}),
+				count(0, errBoom),
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"AddFinalizerError": {
+			reason: "We should requeue after a short wait if we encounter an error adding our finalizer.",
+			opts: []ReconcilerOption{
+				count(1, nil),
+				WithClient(&test.MockClient{
+					MockGet: getComp(false),
+				}),
+				WithFinalizer(resource.FinalizerFns{
+					AddFinalizerFn: func(_ context.Context, _ resource.Object) error { return errBoom },
+				}),
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"UpdateStatusError": {
+			reason: "We should requeue after a short wait if we encounter an error updating our status.",
+			opts: []ReconcilerOption{
+				count(1, nil),
+				WithClient(&test.MockClient{
+					MockGet:          getComp(false),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+				}),
+				WithFinalizer(noop),
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"InUse": {
+			reason: "We should add our finalizer and report usage if our Composition is in use.",
+			opts: []ReconcilerOption{
+				count(2, nil),
+				WithClient(&test.MockClient{
+					MockGet:          getComp(false),
+					MockStatusUpdate: wantUsage(2),
+				}),
+				WithFinalizer(resource.FinalizerFns{
+					AddFinalizerFn: func(_ context.Context, _ resource.Object) error { return nil },
+				}),
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: longWait},
+			},
+		},
+		"RemoveFinalizerError": {
+			reason: "We should requeue after a short wait if we encounter an error removing our finalizer.",
+			opts: []ReconcilerOption{
+				count(0, nil),
+				WithClient(&test.MockClient{
+					MockGet: getComp(false),
+				}),
+				WithFinalizer(resource.FinalizerFns{
+					RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error { return errBoom },
+				}),
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"DeletedInUse": {
+			reason: "We should requeue after a short wait if our Composition was deleted while it was in use.",
+			opts: []ReconcilerOption{
+				count(1, nil),
+				WithClient(&test.MockClient{
+					MockGet:          getComp(true),
+					MockStatusUpdate: wantUsage(1),
+				}),
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"DeletedNotInUse": {
+			reason: "We should remove our finalizer and not requeue if our Composition was deleted and is no longer in use.",
+			opts: []ReconcilerOption{
+				count(0, nil),
+				WithClient(&test.MockClient{
+					MockGet: getComp(true),
+				}),
+				WithFinalizer(resource.FinalizerFns{
+					RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error { return nil },
+				}),
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewReconciler(&fake.Manager{}, tc.opts...)
+			got, err := r.Reconcile(context.Background(), reconcile.Request{})
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.r, got); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
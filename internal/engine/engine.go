@@ -0,0 +1,298 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package engine manages the lifecycles of a set of controllers, and the
+// watches they start while running.
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Error strings
+const (
+	errCreateCache      = "cannot create new cache"
+	errCreateController = "cannot create new controller"
+	errCrashCache       = "cache error"
+	errCrashController  = "controller error"
+	errWatch            = "cannot setup watch"
+	errSyncInformer     = "cannot sync informer for watched kind"
+
+	errFmtNotRunning = "controller %q is not running"
+)
+
+// A NewCacheFn creates a new controller-runtime cache.
+type NewCacheFn func(cfg *rest.Config, o cache.Options) (cache.Cache, error)
+
+// A NewControllerFn creates a new controller-runtime controller.
+type NewControllerFn func(name string, m manager.Manager, o controller.Options) (controller.Controller, error)
+
+// The default new cache and new controller functions.
+var (
+	DefaultNewCacheFn      NewCacheFn      = cache.New
+	DefaultNewControllerFn NewControllerFn = controller.NewUnmanaged
+)
+
+// A running controller, its cache, the watches it has started, and the watches
+// it is starting.
+type running struct {
+	stop     context.CancelFunc
+	ctrl     controller.Controller
+	cache    cache.Cache
+	watches  map[string]bool
+	starting map[string]bool
+}
+
+// An Engine manages the lifecycles of controller-runtime controllers (and their
+// caches). The lifecycles of the controllers are not coupled to lifecycle of
+// the engine, nor to the lifecycle of the controller manager it uses. Unlike
+// the crossplane-runtime engine it is derived from, an Engine may start new
+// watches on a controller after it has been started.
+type Engine struct {
+	mgr manager.Manager
+
+	started map[string]*running
+	errors  map[string]error
+	mx      sync.RWMutex
+
+	newCache NewCacheFn
+	newCtrl  NewControllerFn
+}
+
+// An Option configures an Engine.
+type Option func(*Engine)
+
+// WithNewCacheFn may be used to configure a different cache implementation.
+// DefaultNewCacheFn is used by default.
+func WithNewCacheFn(fn NewCacheFn) Option {
+	return func(e *Engine) {
+		e.newCache = fn
+	}
+}
+
+// WithNewControllerFn may be used to configure a different controller
+// implementation. DefaultNewControllerFn is used by default.
+func WithNewControllerFn(fn NewControllerFn) Option {
+	return func(e *Engine) {
+		e.newCtrl = fn
+	}
+}
+
+// New produces a new Engine.
+func New(mgr manager.Manager, o ...Option) *Engine {
+	e := &Engine{
+		mgr: mgr,
+
+		started: make(map[string]*running),
+		errors:  make(map[string]error),
+
+		newCache: DefaultNewCacheFn,
+		newCtrl:  DefaultNewControllerFn,
+	}
+
+	for _, eo := range o {
+		eo(e)
+	}
+
+	return e
+}
+
+// IsRunning indicates whether the named controller is running - i.e. whether it
+// has been started and does not appear to have crashed.
+func (e *Engine) IsRunning(name string) bool {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+
+	_, running := e.started[name]
+	return running
+}
+
+// Err returns any error encountered by the named controller. The returned error
+// is always nil if the named controller is running.
+func (e *Engine) Err(name string) error {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+
+	return e.errors[name]
+}
+
+// Stop the named controller.
+func (e *Engine) Stop(name string) {
+	e.done(name, nil)
+}
+
+func (e *Engine) done(name string, err error) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	r, ok := e.started[name]
+	if ok {
+		r.stop()
+		delete(e.started, name)
+	}
+
+	// Don't overwrite the first error if done is called multiple times.
+	if e.errors[name] != nil {
+		return
+	}
+	e.errors[name] = err
+}
+
+// Watch an object.
+type Watch struct {
+	kind       client.Object
+	handler    handler.EventHandler
+	predicates []predicate.Predicate
+}
+
+// WatchFor returns a Watch for the supplied kind of object. Events will be
+// handled by the supplied EventHandler, and may be filtered by the supplied
+// predicates.
+func WatchFor(kind client.Object, h handler.EventHandler, p ...predicate.Predicate) Watch {
+	return Watch{kind: kind, handler: h, predicates: p}
+}
+
+// key uniquely identifies a watch by the kind of object it watches and the
+// type of its handler. Watches of unstructured objects are distinguished by
+// their group, version, and kind.
+func (w Watch) key() string {
+	gvk := w.kind.GetObjectKind().GroupVersionKind()
+	return fmt.Sprintf("%T/%s/%T", w.kind, gvk, w.handler)
+}
+
+// Start the named controller. Each controller is started with its own cache
+// whose lifecycle is coupled to the controller. The controller is started with
+// the supplied options, and configured with the supplied watches. Start does
+// not block.
+func (e *Engine) Start(name string, o controller.Options, w ...Watch) error {
+	if e.IsRunning(name) {
+		return nil
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	r := &running{stop: stop, watches: make(map[string]bool), starting: make(map[string]bool)}
+	e.mx.Lock()
+	e.started[name] = r
+	e.errors[name] = nil
+	e.mx.Unlock()
+
+	// Each controller gets its own cache because there's currently no way to
+	// stop an informer. In practice a controller-runtime cache is a map of
+	// kinds to informers. If we delete the CRD for a kind we need to stop the
+	// relevant informer, or it will spew errors about the kind not existing. We
+	// work around this by stopping the entire cache.
+	ca, err := e.newCache(e.mgr.GetConfig(), cache.Options{Scheme: e.mgr.GetScheme(), Mapper: e.mgr.GetRESTMapper()})
+	if err != nil {
+		return errors.Wrap(err, errCreateCache)
+	}
+
+	ctrl, err := e.newCtrl(name, e.mgr, o)
+	if err != nil {
+		return errors.Wrap(err, errCreateController)
+	}
+
+	for _, wt := range w {
+		if err := ctrl.Watch(source.NewKindWithCache(wt.kind, ca), wt.handler, wt.predicates...); err != nil {
+			return errors.Wrap(err, errWatch)
+		}
+		r.watches[wt.key()] = true
+	}
+
+	e.mx.Lock()
+	r.ctrl, r.cache = ctrl, ca
+	e.mx.Unlock()
+
+	go func() {
+		<-e.mgr.Elected()
+		e.done(name, errors.Wrap(ca.Start(ctx), errCrashCache))
+	}()
+	go func() {
+		<-e.mgr.Elected()
+		e.done(name, errors.Wrap(ctrl.Start(ctx), errCrashController))
+	}()
+
+	return nil
+}
+
+// StartWatches starts the supplied watches on the named controller, which must
+// be running. Watches that the controller has already started (or is starting)
+// are ignored, so it's safe to call StartWatches repeatedly with the same
+// watches. The watches share the controller's cache, and thus stop when the
+// controller stops. StartWatches returns an error if the cache can't sync a
+// watched kind before the supplied context is done. The watch will be started
+// again the next time StartWatches is called.
+func (e *Engine) StartWatches(ctx context.Context, name string, w ...Watch) error {
+	e.mx.RLock()
+	r, ok := e.started[name]
+	ok = ok && r.ctrl != nil
+	e.mx.RUnlock()
+	if !ok {
+		return errors.Errorf(errFmtNotRunning, name)
+	}
+
+	for _, wt := range w {
+		k := wt.key()
+
+		e.mx.Lock()
+		if r.watches[k] || r.starting[k] {
+			e.mx.Unlock()
+			continue
+		}
+		r.starting[k] = true
+		e.mx.Unlock()
+
+		// Starting a watch may block until the cache's informer for the
+		// watched kind has synced, so we don't hold the lock while we do it.
+		err := startWatch(ctx, r.ctrl, r.cache, wt)
+
+		e.mx.Lock()
+		delete(r.starting, k)
+		if err == nil {
+			r.watches[k] = true
+		}
+		e.mx.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startWatch starts the supplied watch on a running controller. Watch blocks
+// until the informer for the watched kind has synced, without a deadline. An
+// informer may never sync, for example if we're forbidden from listing the
+// kind. We get (and thus sync) the informer before we start the watch so that
+// we wait only as long as the supplied context allows.
+func startWatch(ctx context.Context, ctrl controller.Controller, ca cache.Cache, wt Watch) error {
+	if _, err := ca.GetInformer(ctx, wt.kind); err != nil {
+		return errors.Wrap(err, errSyncInformer)
+	}
+	return errors.Wrap(ctrl.Watch(source.NewKindWithCache(wt.kind, ca), wt.handler, wt.predicates...), errWatch)
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+type MockCache struct {
+	cache.Cache
+
+	MockStart       func(stop context.Context) error
+	MockGetInformer func(ctx context.Context, obj client.Object) (cache.Informer, error)
+}
+
+func (c *MockCache) Start(stop context.Context) error {
+	return c.MockStart(stop)
+}
+
+func (c *MockCache) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
+	return c.MockGetInformer(ctx, obj)
+}
+
+type MockController struct {
+	controller.Controller
+
+	MockStart func(stop context.Context) error
+	MockWatch func(s source.Source, h handler.EventHandler, p ...predicate.Predicate) error
+}
+
+func (c *MockController) Start(stop context.Context) error {
+	return c.MockStart(stop)
+}
+
+func (c *MockController) Watch(s source.Source, h handler.EventHandler, p ...predicate.Predicate) error {
+	return c.MockWatch(s, h, p...)
+}
+
+func TestEngine(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type args struct {
+		name string
+		o    controller.Options
+		w    []Watch
+	}
+	type want struct {
+		err   error
+		crash error
+	}
+	cases := map[string]struct {
+		reason string
+		e      *Engine
+		args   args
+		want   want
+	}{
+		"NewCacheError": {
+			reason: "Errors creating a new cache should be returned",
+			e: New(&fake.Manager{},
+				WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) { return nil, errBoom }),
+			),
+			args: args{
+				name: "coolcontroller",
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errCreateCache),
+			},
+		},
+		"NewControllerError": {
+			reason: "Errors creating a new controller should be returned",
+			e: New(&fake.Manager{},
+				WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) { return nil, nil }),
+				WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) { return nil, errBoom }),
+			),
+			args: args{
+				name: "coolcontroller",
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errCreateController),
+			},
+		},
+		"WatchError": {
+			reason: "Errors adding a watch should be returned",
+			e: New(&fake.Manager{},
+				WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) { return nil, nil }),
+				WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+					c := &MockController{MockWatch: func(source.Source, handler.EventHandler, ...predicate.Predicate) error { return errBoom }}
+					return c, nil
+				}),
+			),
+			args: args{
+				name: "coolcontroller",
+				w:    []Watch{WatchFor(&fake.Managed{}, nil)},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errWatch),
+			},
+		},
+		"CacheCrashError": {
+			reason: "Errors starting or running a cache should be returned",
+			e: New(&fake.Manager{},
+				WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+					c := &MockCache{MockStart: func(stop context.Context) error { return errBoom }}
+					return c, nil
+				}),
+				WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+					c := &MockController{MockStart: func(stop context.Context) error {
+						return nil
+					}}
+					return c, nil
+				}),
+			),
+			args: args{
+				name: "coolcontroller",
+			},
+			want: want{
+				crash: errors.Wrap(errBoom, errCrashCache),
+			},
+		},
+		"ControllerCrashError": {
+			reason: "Errors starting or running a controller should be returned",
+			e: New(&fake.Manager{},
+				WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+					c := &MockCache{MockStart: func(stop context.Context) error {
+						return nil
+					}}
+					return c, nil
+				}),
+				WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+					c := &MockController{MockStart: func(stop context.Context) error {
+						return errBoom
+					}}
+					return c, nil
+				}),
+			),
+			args: args{
+				name: "coolcontroller",
+			},
+			want: want{
+				crash: errors.Wrap(errBoom, errCrashController),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.e.Start(tc.args.name, tc.args.o, tc.args.w...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.Start(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+
+			// Give the goroutines a little time to return an error. If this
+			// becomes flaky or time consuming we could use a ticker instead.
+			time.Sleep(100 * time.Millisecond)
+
+			tc.e.Stop(tc.args.name)
+			if diff := cmp.Diff(tc.want.crash, tc.e.Err(tc.args.name), test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.Err(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestStartWatches(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	kind := func(k string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: k})
+		return u
+	}
+
+	// blocked returns a mock that starts a cache and controller that run until
+	// they're stopped.
+	blocked := func(inf func(context.Context, client.Object) (cache.Informer, error), watch func(source.Source, handler.EventHandler, ...predicate.Predicate) error) []Option {
+		return []Option{
+			WithNewCacheFn(func(*rest.Config, cache.Options) (cache.Cache, error) {
+				return &MockCache{
+					MockStart:       func(ctx context.Context) error { <-ctx.Done(); return nil },
+					MockGetInformer: inf,
+				}, nil
+			}),
+			WithNewControllerFn(func(string, manager.Manager, controller.Options) (controller.Controller, error) {
+				c := &MockController{
+					MockStart: func(ctx context.Context) error { <-ctx.Done(); return nil },
+					MockWatch: watch,
+				}
+				return c, nil
+			}),
+		}
+	}
+
+	type args struct {
+		start bool
+		w     []Watch
+	}
+	type want struct {
+		err     error
+		watches int
+	}
+	synced := func(context.Context, client.Object) (cache.Informer, error) { return nil, nil }
+
+	cases := map[string]struct {
+		reason   string
+		args     args
+		informer func(context.Context, client.Object) (cache.Informer, error)
+		watch    func(source.Source, handler.EventHandler, ...predicate.Predicate) error
+		want     want
+	}{
+		"NotRunning": {
+			reason: "We should return an error if the controller is not running.",
+			args: args{
+				w: []Watch{WatchFor(kind("A"), &handler.EnqueueRequestForObject{})},
+			},
+			informer: synced,
+			watch:    func(source.Source, handler.EventHandler, ...predicate.Predicate) error { return nil },
+			want: want{
+				err: errors.Errorf(errFmtNotRunning, "coolcontroller"),
+			},
+		},
+		"WatchError": {
+			reason: "Errors starting a watch should be returned.",
+			args: args{
+				start: true,
+				w:     []Watch{WatchFor(kind("A"), &handler.EnqueueRequestForObject{})},
+			},
+			informer: synced,
+			watch:    func(source.Source, handler.EventHandler, ...predicate.Predicate) error { return errBoom },
+			want: want{
+				err:     errors.Wrap(errBoom, errWatch),
+				watches: 2,
+			},
+		},
+		"InformerSyncTimeout": {
+			reason: "We should return an error without starting a watch if its informer doesn't sync before the context is done, and start it next time.",
+			args: args{
+				start: true,
+				w:     []Watch{WatchFor(kind("A"), &handler.EnqueueRequestForObject{})},
+			},
+			informer: func() func(context.Context, client.Object) (cache.Informer, error) {
+				calls := 0
+				return func(ctx context.Context, _ client.Object) (cache.Informer, error) {
+					calls++
+					if calls > 1 {
+						return nil, nil
+					}
+					<-ctx.Done()
+					return nil, errBoom
+				}
+			}(),
+			watch: func(source.Source, handler.EventHandler, ...predicate.Predicate) error { return nil },
+			want: want{
+				err:     errors.Wrap(errBoom, errSyncInformer),
+				watches: 1,
+			},
+		},
+		"DeduplicateWatches": {
+			reason: "Each distinct watch should only be started once.",
+			args: args{
+				start: true,
+				w: []Watch{
+					WatchFor(kind("A"), &handler.EnqueueRequestForObject{}),
+					WatchFor(kind("A"), &handler.EnqueueRequestForObject{}),
+					WatchFor(kind("B"), &handler.EnqueueRequestForObject{}),
+					WatchFor(kind("B"), &handler.EnqueueRequestForOwner{}),
+				},
+			},
+			informer: synced,
+			watch:    func(source.Source, handler.EventHandler, ...predicate.Predicate) error { return nil },
+			want: want{
+				watches: 3,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			watches := 0
+			e := New(&fake.Manager{}, blocked(tc.informer, func(s source.Source, h handler.EventHandler, p ...predicate.Predicate) error {
+				watches++
+				return tc.watch(s, h, p...)
+			})...)
+
+			if tc.args.start {
+				if err := e.Start("coolcontroller", controller.Options{}); err != nil {
+					t.Fatalf("e.Start(...): %s", err)
+				}
+				defer e.Stop("coolcontroller")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			err := e.StartWatches(ctx, "coolcontroller", tc.args.w...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.StartWatches(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+
+			// Starting the same watches again should only start the watches
+			// that we failed to start.
+			_ = e.StartWatches(context.Background(), "coolcontroller", tc.args.w...)
+			if diff := cmp.Diff(tc.want.watches, watches); diff != "" {
+				t.Errorf("\n%s\ne.StartWatches(...): -want watches, +got watches:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+)
+
+// Error strings.
+const (
+	errListXRDs = "cannot list CompositeResourceDefinitions"
+
+	errFmtParseCompositeTypeRef = "cannot parse compositeTypeRef apiVersion %q"
+	errFmtCompositeTypeRef      = "compositeTypeRef must reference version %q of CompositeResourceDefinition %q, which is its referenceable version"
+)
+
+// A CompositionValidator validates Compositions.
+type CompositionValidator struct {
+	client    client.Reader
+	validator composite.CompositionValidator
+}
+
+// NewCompositionValidator returns an ObjectValidator that validates
+// Compositions using the same validators used when composing resources. It
+// also validates that a Composition's compositeTypeRef references the
+// referenceable version of its CompositeResourceDefinition. Compositions may
+// only use the functions permitted by the supplied options.
+func NewCompositionValidator(c client.Reader, o composite.FunctionOptions) *CompositionValidator {
+	return &CompositionValidator{client: c, validator: composite.DefaultValidationChain(o)}
+}
+
+// Validate the supplied JSON encoded Composition.
+func (v *CompositionValidator) Validate(ctx context.Context, obj []byte) error {
+	comp := &v1.Composition{}
+	if err := decode(obj, comp); err != nil {
+		return err
+	}
+	if err := v.validator.Validate(comp); err != nil {
+		return err
+	}
+	return v.validateCompositeTypeRef(ctx, comp)
+}
+
+// validateCompositeTypeRef validates that the supplied Composition references
+// the referenceable version of its CompositeResourceDefinition. Compositions
+// are often created at the same time as their XRD, for example by a package,
+// so we permit Compositions of types that are not (yet) defined.
+func (v *CompositionValidator) validateCompositeTypeRef(ctx context.Context, comp *v1.Composition) error {
+	ref := comp.Spec.CompositeTypeRef
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return errors.Wrapf(err, errFmtParseCompositeTypeRef, ref.APIVersion)
+	}
+
+	l := &v1.CompositeResourceDefinitionList{}
+	if err := v.client.List(ctx, l); err != nil {
+		return errors.Wrap(err, errListXRDs)
+	}
+	for _, xrd := range l.Items {
+		if xrd.Spec.Group != gv.Group || xrd.Spec.Names.Kind != ref.Kind {
+			continue
+		}
+		if rv := xrd.GetCompositeGroupVersionKind().Version; rv != gv.Version {
+			return errors.Errorf(errFmtCompositeTypeRef, rv, xrd.GetName())
+		}
+	}
+	return nil
+}
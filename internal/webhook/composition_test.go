@@ -0,0 +1,252 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+)
+
+var errBoom = errors.New("boom")
+
+func TestCompositionValidator(t *testing.T) {
+	xrd := v1.CompositeResourceDefinition{Spec: v1.CompositeResourceDefinitionSpec{
+		Group: "example.org",
+		Names: extv1.CustomResourceDefinitionNames{Kind: "CoolComposite"},
+		Versions: []v1.CompositeResourceDefinitionVersion{
+			{Name: "v1alpha1", Served: true},
+			{Name: "v1", Served: true, Referenceable: true},
+		},
+	}}
+	xrd.SetName("coolcomposites.example.org")
+
+	list := func(xrds ...v1.CompositeResourceDefinition) test.MockListFn {
+		return func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+			obj.(*v1.CompositeResourceDefinitionList).Items = xrds
+			return nil
+		}
+	}
+	comp := func(apiVersion string, p ...v1.Patch) []byte {
+		c := &v1.Composition{Spec: v1.CompositionSpec{
+			CompositeTypeRef: v1.TypeReference{APIVersion: apiVersion, Kind: "CoolComposite"},
+			Resources:        []v1.ComposedTemplate{{Name: pointer.StringPtr("cool"), Patches: p}},
+		}}
+		j, _ := json.Marshal(c)
+		return j
+	}
+
+	withFn := func(command string) []byte {
+		c := &v1.Composition{Spec: v1.CompositionSpec{
+			CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "CoolComposite"},
+			Resources:        []v1.ComposedTemplate{{Name: pointer.StringPtr("cool")}},
+			Functions:        []v1.Function{{Name: "fn", Type: v1.FunctionTypeExec, Exec: &v1.ExecFunction{Command: command}}},
+		}}
+		j, _ := json.Marshal(c)
+		return j
+	}
+
+	type args struct {
+		kube client.Reader
+		fo   composite.FunctionOptions
+		obj  []byte
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"DecodeError": {
+			reason: "We should return an error if the Composition cannot be decoded.",
+			args: args{
+				obj: []byte("{"),
+			},
+			want: errors.Wrap(errors.New("unexpected end of JSON input"), errDecode),
+		},
+		"InvalidPatch": {
+			reason: "We should reject Compositions with patches that don't specify their required fields.",
+			args: args{
+				obj: comp("example.org/v1", v1.Patch{Type: v1.PatchTypeFromCompositeFieldPath}),
+			},
+			want: errors.Wrapf(errors.New("FromFieldPath is required by type FromCompositeFieldPath"), "invalid patch at index %d of resource template at index %d", 0, 0),
+		},
+		"UndefinedPatchSet": {
+			reason: "We should reject Compositions that reference undefined PatchSets.",
+			args: args{
+				obj: comp("example.org/v1", v1.Patch{Type: v1.PatchTypePatchSet, PatchSetName: pointer.StringPtr("nope")}),
+			},
+			want: errors.Wrap(errors.New("cannot find PatchSet by name nope"), "cannot inline Composition patch sets"),
+		},
+		"FunctionsDisabled": {
+			reason: "We should reject Compositions that use functions when functions are not enabled.",
+			args: args{
+				obj: withFn("/functions/fn"),
+			},
+			want: errors.New("composition functions are an alpha feature that is not enabled"),
+		},
+		"ExecNotAllowed": {
+			reason: "We should reject Compositions with Exec functions that run executables outside the configured directory.",
+			args: args{
+				fo:  composite.FunctionOptions{Enabled: true, ExecDir: "/functions"},
+				obj: withFn("/bin/sh"),
+			},
+			want: errors.New(`function "fn" cannot run "/bin/sh": Exec functions may only run executables within "/functions"`),
+		},
+		"FunctionAllowed": {
+			reason: "We should accept Compositions with Exec functions that run executables within the configured directory.",
+			args: args{
+				kube: &test.MockClient{MockList: list(xrd)},
+				fo:   composite.FunctionOptions{Enabled: true, ExecDir: "/functions"},
+				obj:  withFn("/functions/fn"),
+			},
+		},
+		"ListXRDsError": {
+			reason: "We should return any error encountered while listing XRDs.",
+			args: args{
+				kube: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				obj:  comp("example.org/v1"),
+			},
+			want: errors.Wrap(errBoom, errListXRDs),
+		},
+		"NotReferenceable": {
+			reason: "We should reject Compositions that don't reference the referenceable version of their XRD.",
+			args: args{
+				kube: &test.MockClient{MockList: list(xrd)},
+				obj:  comp("example.org/v1alpha1"),
+			},
+			want: errors.Errorf(errFmtCompositeTypeRef, "v1", "coolcomposites.example.org"),
+		},
+		"Referenceable": {
+			reason: "We should accept Compositions that reference the referenceable version of their XRD.",
+			args: args{
+				kube: &test.MockClient{MockList: list(xrd)},
+				obj:  comp("example.org/v1"),
+			},
+		},
+		"UndefinedType": {
+			reason: "We should accept Compositions whose XRD does not yet exist.",
+			args: args{
+				kube: &test.MockClient{MockList: list()},
+				obj:  comp("other.org/v1"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v := NewCompositionValidator(tc.args.kube, tc.args.fo)
+			got := v.Validate(context.Background(), tc.args.obj)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+type validatorFn func(ctx context.Context, obj []byte) error
+
+func (fn validatorFn) Validate(ctx context.Context, obj []byte) error { return fn(ctx, obj) }
+
+func TestValidatorHandle(t *testing.T) {
+	req := func(op admissionv1.Operation) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: op,
+			Object:    runtime.RawExtension{Raw: []byte("{}")},
+		}}
+	}
+
+	cases := map[string]struct {
+		reason string
+		v      ObjectValidator
+		req    admission.Request
+		want   bool
+	}{
+		"Delete": {
+			reason: "We should allow deletes without validating the object.",
+			v:      validatorFn(func(_ context.Context, _ []byte) error { return errBoom }),
+			req:    req(admissionv1.Delete),
+			want:   true,
+		},
+		"Invalid": {
+			reason: "We should deny creates and updates of invalid objects.",
+			v:      validatorFn(func(_ context.Context, _ []byte) error { return errBoom }),
+			req:    req(admissionv1.Update),
+			want:   false,
+		},
+		"UnchangedSpec": {
+			reason: "We should allow updates that don't change the object's spec without validating it.",
+			v:      validatorFn(func(_ context.Context, _ []byte) error { return errBoom }),
+			req: admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Update,
+				OldObject: runtime.RawExtension{Raw: []byte(`{"spec":{"cool":true}}`)},
+				Object:    runtime.RawExtension{Raw: []byte(`{"metadata":{"finalizers":["cool"]},"spec":{"cool":true}}`)},
+			}},
+			want: true,
+		},
+		"ChangedSpec": {
+			reason: "We should validate updates that change the object's spec.",
+			v:      validatorFn(func(_ context.Context, _ []byte) error { return errBoom }),
+			req: admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Update,
+				OldObject: runtime.RawExtension{Raw: []byte(`{"spec":{"cool":true}}`)},
+				Object:    runtime.RawExtension{Raw: []byte(`{"spec":{"cool":false}}`)},
+			}},
+			want: false,
+		},
+		"Deleting": {
+			reason: "We should allow updates to objects that are being deleted without validating them.",
+			v:      validatorFn(func(_ context.Context, _ []byte) error { return errBoom }),
+			req: admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Update,
+				OldObject: runtime.RawExtension{Raw: []byte(`{"spec":{"cool":true}}`)},
+				Object:    runtime.RawExtension{Raw: []byte(`{"metadata":{"deletionTimestamp":"2021-01-01T00:00:00Z"},"spec":{"cool":false}}`)},
+			}},
+			want: true,
+		},
+		"Valid": {
+			reason: "We should allow creates and updates of valid objects.",
+			v:      validatorFn(func(_ context.Context, _ []byte) error { return nil }),
+			req:    req(admissionv1.Create),
+			want:   true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := NewValidator(tc.v, logging.NewNopLogger()).Handle(context.Background(), tc.req)
+			if diff := cmp.Diff(tc.want, got.Allowed); diff != "" {
+				t.Errorf("\n%s\nHandle(...): -want allowed, +got allowed:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
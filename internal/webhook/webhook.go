@@ -0,0 +1,125 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook implements admission webhooks that validate Crossplane's
+// API extension types.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+)
+
+// Webhook paths.
+const (
+	PathValidateComposition                 = "/validate-apiextensions-crossplane-io-v1-composition"
+	PathValidateCompositeResourceDefinition = "/validate-apiextensions-crossplane-io-v1-compositeresourcedefinition"
+)
+
+const errDecode = "cannot decode object"
+
+// Options configures the validating webhooks.
+type Options struct {
+	// Functions configures which composition functions Compositions may use.
+	Functions composite.FunctionOptions
+}
+
+// Setup registers validating webhooks for Compositions and
+// CompositeResourceDefinitions with the supplied manager's webhook server.
+func Setup(mgr ctrl.Manager, log logging.Logger, o Options) error {
+	srv := mgr.GetWebhookServer()
+	srv.Register(PathValidateComposition, &webhook.Admission{
+		Handler: NewValidator(NewCompositionValidator(mgr.GetClient(), o.Functions), log.WithValues("webhook", "compositions")),
+	})
+	srv.Register(PathValidateCompositeResourceDefinition, &webhook.Admission{
+		Handler: NewValidator(XRDValidatorFn(ValidateCompositeResourceDefinition), log.WithValues("webhook", "compositeresourcedefinitions")),
+	})
+	return nil
+}
+
+// An ObjectValidator validates the object encoded in an admission request.
+type ObjectValidator interface {
+	// Validate the supplied JSON encoded object.
+	Validate(ctx context.Context, obj []byte) error
+}
+
+// A Validator is an admission handler that denies requests to create or update
+// invalid objects.
+type Validator struct {
+	validator ObjectValidator
+	log       logging.Logger
+}
+
+// NewValidator returns an admission handler that uses the supplied
+// ObjectValidator to deny requests to create or update invalid objects.
+func NewValidator(v ObjectValidator, log logging.Logger) *Validator {
+	return &Validator{validator: v, log: log}
+}
+
+// Handle an admission request.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+	if req.Operation == admissionv1.Update && skipValidation(req.OldObject.Raw, req.Object.Raw) {
+		// Updates that don't change an object's spec, for example to add or
+		// remove a finalizer, must be allowed even if the object would no
+		// longer pass validation. Otherwise such objects could never be
+		// deleted.
+		return admission.Allowed("")
+	}
+	if err := v.validator.Validate(ctx, req.Object.Raw); err != nil {
+		v.log.Debug("Denied invalid object", "kind", req.Kind.Kind, "name", req.Name, "error", err)
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("")
+}
+
+// skipValidation returns true if an update from the supplied old to the supplied
+// new JSON encoded object needn't be validated, because the object is being
+// deleted or its spec is unchanged.
+func skipValidation(old, obj []byte) bool {
+	type object struct {
+		Metadata struct {
+			DeletionTimestamp *metav1.Time `json:"deletionTimestamp,omitempty"`
+		} `json:"metadata"`
+		Spec interface{} `json:"spec"`
+	}
+	o, n := &object{}, &object{}
+	if err := json.Unmarshal(old, o); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(obj, n); err != nil {
+		return false
+	}
+	return n.Metadata.DeletionTimestamp != nil || reflect.DeepEqual(o.Spec, n.Spec)
+}
+
+func decode(obj []byte, into interface{}) error {
+	return errors.Wrap(json.Unmarshal(obj, into), errDecode)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+// Error strings.
+const (
+	errCompositeCRD = "cannot derive composite resource CustomResourceDefinition"
+	errClaimCRD     = "cannot derive composite resource claim CustomResourceDefinition"
+
+	errFmtXRDName            = "name must be %q, i.e. <spec.names.plural>.<spec.group>"
+	errFmtDuplicateVersion   = "version %q is specified more than once"
+	errFmtReferenceable      = "exactly one version must be referenceable, found %d"
+	errFmtReferenceableServe = "referenceable version %q must be served"
+)
+
+// An XRDValidatorFn validates CompositeResourceDefinitions.
+type XRDValidatorFn func(xrd *v1.CompositeResourceDefinition) error
+
+// Validate the supplied JSON encoded CompositeResourceDefinition.
+func (fn XRDValidatorFn) Validate(_ context.Context, obj []byte) error {
+	xrd := &v1.CompositeResourceDefinition{}
+	if err := decode(obj, xrd); err != nil {
+		return err
+	}
+	return fn(xrd)
+}
+
+// ValidateCompositeResourceDefinition validates that the CustomResourceDefinitions
+// of the supplied CompositeResourceDefinition's composite resource and claim
+// (if any) can be derived, and would be accepted by the API server.
+func ValidateCompositeResourceDefinition(xrd *v1.CompositeResourceDefinition) error {
+	// The composite resource CRD is named for its XRD, but CRD names must be
+	// of the form <plural>.<group>.
+	if want := xrd.Spec.Names.Plural + "." + xrd.Spec.Group; xrd.GetName() != want {
+		return errors.Errorf(errFmtXRDName, want)
+	}
+
+	seen := map[string]bool{}
+	referenceable := 0
+	for _, vr := range xrd.Spec.Versions {
+		if seen[vr.Name] {
+			return errors.Errorf(errFmtDuplicateVersion, vr.Name)
+		}
+		seen[vr.Name] = true
+
+		// The referenceable version is the CRD's storage version. Exactly
+		// one version of a CRD must be stored, and it must be served.
+		if !vr.Referenceable {
+			continue
+		}
+		referenceable++
+		if !vr.Served {
+			return errors.Errorf(errFmtReferenceableServe, vr.Name)
+		}
+	}
+	if referenceable != 1 {
+		return errors.Errorf(errFmtReferenceable, referenceable)
+	}
+
+	if _, err := xcrd.ForCompositeResource(xrd); err != nil {
+		return errors.Wrap(err, errCompositeCRD)
+	}
+	if !xrd.OffersClaim() {
+		return nil
+	}
+	_, err := xcrd.ForCompositeResourceClaim(xrd)
+	return errors.Wrap(err, errClaimCRD)
+}
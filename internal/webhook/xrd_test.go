@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestValidateCompositeResourceDefinition(t *testing.T) {
+	xrd := func(name string, claim *extv1.CustomResourceDefinitionNames, vs ...v1.CompositeResourceDefinitionVersion) *v1.CompositeResourceDefinition {
+		return &v1.CompositeResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1.CompositeResourceDefinitionSpec{
+				Group:      "example.org",
+				Names:      extv1.CustomResourceDefinitionNames{Plural: "coolcomposites", Kind: "CoolComposite"},
+				ClaimNames: claim,
+				Versions:   vs,
+			},
+		}
+	}
+	v := func(name string, referenceable, served bool) v1.CompositeResourceDefinitionVersion {
+		return v1.CompositeResourceDefinitionVersion{Name: name, Referenceable: referenceable, Served: served}
+	}
+
+	cases := map[string]struct {
+		reason string
+		xrd    *v1.CompositeResourceDefinition
+		want   error
+	}{
+		"Valid": {
+			reason: "A well formed XRD should be valid.",
+			xrd:    xrd("coolcomposites.example.org", nil, v("v1alpha1", false, true), v("v1", true, true)),
+		},
+		"WrongName": {
+			reason: "An XRD must be named <plural>.<group>.",
+			xrd:    xrd("cool.example.org", nil, v("v1", true, true)),
+			want:   errors.Errorf(errFmtXRDName, "coolcomposites.example.org"),
+		},
+		"DuplicateVersion": {
+			reason: "An XRD must not specify the same version twice.",
+			xrd:    xrd("coolcomposites.example.org", nil, v("v1", true, true), v("v1", false, true)),
+			want:   errors.Errorf(errFmtDuplicateVersion, "v1"),
+		},
+		"NoReferenceableVersion": {
+			reason: "An XRD must have a referenceable version.",
+			xrd:    xrd("coolcomposites.example.org", nil, v("v1", false, true)),
+			want:   errors.Errorf(errFmtReferenceable, 0),
+		},
+		"UnservedReferenceableVersion": {
+			reason: "An XRD's referenceable version must be served.",
+			xrd:    xrd("coolcomposites.example.org", nil, v("v1", true, false)),
+			want:   errors.Errorf(errFmtReferenceableServe, "v1"),
+		},
+		"InvalidSchema": {
+			reason: "An XRD's schema must be valid.",
+			xrd: xrd("coolcomposites.example.org", nil, v1.CompositeResourceDefinitionVersion{
+				Name:          "v1",
+				Referenceable: true,
+				Served:        true,
+				Schema:        &v1.CompositeResourceValidation{OpenAPIV3Schema: runtime.RawExtension{Raw: []byte("{")}},
+			}),
+			want: errors.Wrap(errors.Wrap(errors.Wrap(errors.New("unexpected end of JSON input"), "cannot parse validation schema"), `cannot get "spec" properties from validation schema`), errCompositeCRD),
+		},
+		"ConflictingClaimNames": {
+			reason: "An XRD's claim names must not conflict with its composite resource names.",
+			xrd:    xrd("coolcomposites.example.org", &extv1.CustomResourceDefinitionNames{Plural: "coolcomposites", Kind: "CoolClaim"}, v("v1", true, true)),
+			want:   errors.Wrap(errors.Wrap(errors.New(`"coolcomposites" conflicts with composite resource name`), "invalid resource claim names"), errClaimCRD),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ValidateCompositeResourceDefinition(tc.xrd)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nValidateCompositeResourceDefinition(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
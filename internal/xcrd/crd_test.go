@@ -225,8 +225,7 @@ func TestForCompositeResource(t *testing.T) {
 										},
 									},
 									"compositionSelector": {
-										Type:     "object",
-										Required: []string{"matchLabels"},
+										Type: "object",
 										Properties: map[string]extv1.JSONSchemaProps{
 											"matchLabels": {
 												Type: "object",
@@ -235,6 +234,55 @@ func TestForCompositeResource(t *testing.T) {
 													Schema: &extv1.JSONSchemaProps{Type: "string"},
 												},
 											},
+											"matchExpressions": {
+												Type: "array",
+												Items: &extv1.JSONSchemaPropsOrArray{
+													Schema: &extv1.JSONSchemaProps{
+														Type:     "object",
+														Required: []string{"key", "operator"},
+														Properties: map[string]extv1.JSONSchemaProps{
+															"key": {Type: "string"},
+															"operator": {
+																Type: "string",
+																Enum: []extv1.JSON{
+																	{Raw: []byte(`"In"`)},
+																	{Raw: []byte(`"NotIn"`)},
+																	{Raw: []byte(`"Exists"`)},
+																	{Raw: []byte(`"DoesNotExist"`)},
+																},
+															},
+															"values": {
+																Type: "array",
+																Items: &extv1.JSONSchemaPropsOrArray{
+																	Schema: &extv1.JSONSchemaProps{Type: "string"},
+																},
+															},
+														},
+													},
+												},
+											},
+										},
+									},
+									"compositionRevisionRef": {
+										Type:     "object",
+										Required: []string{"name"},
+										Properties: map[string]extv1.JSONSchemaProps{
+											"name": {Type: "string"},
+										},
+									},
+									"compositionUpdatePolicy": {
+										Type: "string",
+										Enum: []extv1.JSON{
+											{Raw: []byte(`"Automatic"`)},
+											{Raw: []byte(`"Manual"`)},
+										},
+										Default: &extv1.JSON{Raw: []byte(`"Automatic"`)},
+									},
+									"deletionPolicy": {
+										Type: "string",
+										Enum: []extv1.JSON{
+											{Raw: []byte(`"Delete"`)},
+											{Raw: []byte(`"Orphan"`)},
 										},
 									},
 									"claimRef": {
@@ -300,6 +348,31 @@ func TestForCompositeResource(t *testing.T) {
 											"lastPublishedTime": {Type: "string", Format: "date-time"},
 										},
 									},
+									"compositionSelection": {
+										Type: "object",
+										Properties: map[string]extv1.JSONSchemaProps{
+											"policy":  {Type: "string"},
+											"message": {Type: "string"},
+										},
+									},
+									"resourceStatuses": {
+										Description: "Statuses of the composed resources of the resource.",
+										Type:        "array",
+										Items: &extv1.JSONSchemaPropsOrArray{
+											Schema: &extv1.JSONSchemaProps{
+												Type:     "object",
+												Required: []string{"ready", "synced"},
+												Properties: map[string]extv1.JSONSchemaProps{
+													"templateName": {Type: "string"},
+													"kind":         {Type: "string"},
+													"name":         {Type: "string"},
+													"ready":        {Type: "boolean"},
+													"synced":       {Type: "boolean"},
+													"message":      {Type: "string"},
+												},
+											},
+										},
+									},
 								},
 							},
 						},
@@ -584,8 +657,7 @@ func TestForCompositeResourceClaim(t *testing.T) {
 											},
 										},
 										"compositionSelector": {
-											Type:     "object",
-											Required: []string{"matchLabels"},
+											Type: "object",
 											Properties: map[string]extv1.JSONSchemaProps{
 												"matchLabels": {
 													Type: "object",
@@ -594,8 +666,50 @@ func TestForCompositeResourceClaim(t *testing.T) {
 														Schema: &extv1.JSONSchemaProps{Type: "string"},
 													},
 												},
+												"matchExpressions": {
+													Type: "array",
+													Items: &extv1.JSONSchemaPropsOrArray{
+														Schema: &extv1.JSONSchemaProps{
+															Type:     "object",
+															Required: []string{"key", "operator"},
+															Properties: map[string]extv1.JSONSchemaProps{
+																"key": {Type: "string"},
+																"operator": {
+																	Type: "string",
+																	Enum: []extv1.JSON{
+																		{Raw: []byte(`"In"`)},
+																		{Raw: []byte(`"NotIn"`)},
+																		{Raw: []byte(`"Exists"`)},
+																		{Raw: []byte(`"DoesNotExist"`)},
+																	},
+																},
+																"values": {
+																	Type: "array",
+																	Items: &extv1.JSONSchemaPropsOrArray{
+																		Schema: &extv1.JSONSchemaProps{Type: "string"},
+																	},
+																},
+															},
+														},
+													},
+												},
 											},
 										},
+										"compositionRevisionRef": {
+											Type:     "object",
+											Required: []string{"name"},
+											Properties: map[string]extv1.JSONSchemaProps{
+												"name": {Type: "string"},
+											},
+										},
+										"compositionUpdatePolicy": {
+											Type: "string",
+											Enum: []extv1.JSON{
+												{Raw: []byte(`"Automatic"`)},
+												{Raw: []byte(`"Manual"`)},
+											},
+											Default: &extv1.JSON{Raw: []byte(`"Automatic"`)},
+										},
 										"resourceRef": {
 											Type:     "object",
 											Required: []string{"apiVersion", "kind", "name"},
@@ -643,6 +757,31 @@ func TestForCompositeResourceClaim(t *testing.T) {
 												"lastPublishedTime": {Type: "string", Format: "date-time"},
 											},
 										},
+										"compositionSelection": {
+											Type: "object",
+											Properties: map[string]extv1.JSONSchemaProps{
+												"policy":  {Type: "string"},
+												"message": {Type: "string"},
+											},
+										},
+										"resourceStatuses": {
+											Description: "Statuses of the composed resources of the resource.",
+											Type:        "array",
+											Items: &extv1.JSONSchemaPropsOrArray{
+												Schema: &extv1.JSONSchemaProps{
+													Type:     "object",
+													Required: []string{"ready", "synced"},
+													Properties: map[string]extv1.JSONSchemaProps{
+														"templateName": {Type: "string"},
+														"kind":         {Type: "string"},
+														"name":         {Type: "string"},
+														"ready":        {Type: "boolean"},
+														"synced":       {Type: "boolean"},
+														"message":      {Type: "string"},
+													},
+												},
+											},
+										},
 									},
 								},
 							},
@@ -27,7 +27,7 @@ const (
 
 // KeepClaimSpecProps is the list of XRC spec properties to keep
 // when translating an XRC into an XR.
-var KeepClaimSpecProps = []string{"compositionRef", "compositionSelector"}
+var KeepClaimSpecProps = []string{"compositionRef", "compositionSelector", "compositionRevisionRef", "compositionUpdatePolicy"}
 
 // TODO(negz): Add descriptions to schema fields.
 
@@ -73,17 +73,27 @@ func CompositeResourceSpecProps() map[string]extv1.JSONSchemaProps {
 				"name": {Type: "string"},
 			},
 		},
-		"compositionSelector": {
+		"compositionSelector": CompositionSelectorProps(),
+		"compositionRevisionRef": {
 			Type:     "object",
-			Required: []string{"matchLabels"},
+			Required: []string{"name"},
 			Properties: map[string]extv1.JSONSchemaProps{
-				"matchLabels": {
-					Type: "object",
-					AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
-						Allows: true,
-						Schema: &extv1.JSONSchemaProps{Type: "string"},
-					},
-				},
+				"name": {Type: "string"},
+			},
+		},
+		"compositionUpdatePolicy": {
+			Type: "string",
+			Enum: []extv1.JSON{
+				{Raw: []byte(`"Automatic"`)},
+				{Raw: []byte(`"Manual"`)},
+			},
+			Default: &extv1.JSON{Raw: []byte(`"Automatic"`)},
+		},
+		"deletionPolicy": {
+			Type: "string",
+			Enum: []extv1.JSON{
+				{Raw: []byte(`"Delete"`)},
+				{Raw: []byte(`"Orphan"`)},
 			},
 		},
 		"claimRef": {
@@ -121,6 +131,50 @@ func CompositeResourceSpecProps() map[string]extv1.JSONSchemaProps {
 	}
 }
 
+// CompositionSelectorProps is an OpenAPIV3Schema for the compositionSelector
+// field of composite resources and claims; i.e. a label selector.
+func CompositionSelectorProps() extv1.JSONSchemaProps {
+	return extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"matchLabels": {
+				Type: "object",
+				AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+					Allows: true,
+					Schema: &extv1.JSONSchemaProps{Type: "string"},
+				},
+			},
+			"matchExpressions": {
+				Type: "array",
+				Items: &extv1.JSONSchemaPropsOrArray{
+					Schema: &extv1.JSONSchemaProps{
+						Type:     "object",
+						Required: []string{"key", "operator"},
+						Properties: map[string]extv1.JSONSchemaProps{
+							"key": {Type: "string"},
+							"operator": {
+								Type: "string",
+								Enum: []extv1.JSON{
+									{Raw: []byte(`"In"`)},
+									{Raw: []byte(`"NotIn"`)},
+									{Raw: []byte(`"Exists"`)},
+									{Raw: []byte(`"DoesNotExist"`)},
+								},
+							},
+							"values": {
+								Type: "array",
+								Items: &extv1.JSONSchemaPropsOrArray{
+									Schema: &extv1.JSONSchemaProps{Type: "string"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 // CompositeResourceClaimSpecProps is a partial OpenAPIV3Schema for the spec
 // fields that Crossplane expects to be present for all published infrastructure
 // resources.
@@ -133,19 +187,22 @@ func CompositeResourceClaimSpecProps() map[string]extv1.JSONSchemaProps {
 				"name": {Type: "string"},
 			},
 		},
-		"compositionSelector": {
+		"compositionSelector": CompositionSelectorProps(),
+		"compositionRevisionRef": {
 			Type:     "object",
-			Required: []string{"matchLabels"},
+			Required: []string{"name"},
 			Properties: map[string]extv1.JSONSchemaProps{
-				"matchLabels": {
-					Type: "object",
-					AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
-						Allows: true,
-						Schema: &extv1.JSONSchemaProps{Type: "string"},
-					},
-				},
+				"name": {Type: "string"},
 			},
 		},
+		"compositionUpdatePolicy": {
+			Type: "string",
+			Enum: []extv1.JSON{
+				{Raw: []byte(`"Automatic"`)},
+				{Raw: []byte(`"Manual"`)},
+			},
+			Default: &extv1.JSON{Raw: []byte(`"Automatic"`)},
+		},
 		"resourceRef": {
 			Type:     "object",
 			Required: []string{"apiVersion", "kind", "name"},
@@ -193,6 +250,31 @@ func CompositeResourceStatusProps() map[string]extv1.JSONSchemaProps {
 				"lastPublishedTime": {Type: "string", Format: "date-time"},
 			},
 		},
+		"compositionSelection": {
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"policy":  {Type: "string"},
+				"message": {Type: "string"},
+			},
+		},
+		"resourceStatuses": {
+			Description: "Statuses of the composed resources of the resource.",
+			Type:        "array",
+			Items: &extv1.JSONSchemaPropsOrArray{
+				Schema: &extv1.JSONSchemaProps{
+					Type:     "object",
+					Required: []string{"ready", "synced"},
+					Properties: map[string]extv1.JSONSchemaProps{
+						"templateName": {Type: "string"},
+						"kind":         {Type: "string"},
+						"name":         {Type: "string"},
+						"ready":        {Type: "boolean"},
+						"synced":       {Type: "boolean"},
+						"message":      {Type: "string"},
+					},
+				},
+			},
+		},
 	}
 }
 